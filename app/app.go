@@ -3,9 +3,11 @@ package app
 import (
 	"context"
 	"os"
+	"os/signal"
 	"runtime/debug"
 	"strings"
-	"sync"
+	"syscall"
+	"time"
 
 	"github.com/rosaekapratama/go-starter/avro"
 	"github.com/rosaekapratama/go-starter/config"
@@ -16,11 +18,13 @@ import (
 	"github.com/rosaekapratama/go-starter/google"
 	"github.com/rosaekapratama/go-starter/google/cloud/oauth"
 	"github.com/rosaekapratama/go-starter/google/cloud/pubsub"
+	"github.com/rosaekapratama/go-starter/google/cloud/pubsub/outbox"
 	"github.com/rosaekapratama/go-starter/google/cloud/pubsub/subscriber"
 	"github.com/rosaekapratama/go-starter/google/cloud/scheduler"
 	"github.com/rosaekapratama/go-starter/google/cloud/storage"
 	"github.com/rosaekapratama/go-starter/google/drive"
 	"github.com/rosaekapratama/go-starter/google/firebase"
+	"github.com/rosaekapratama/go-starter/keycloak"
 	"github.com/rosaekapratama/go-starter/log"
 	"github.com/rosaekapratama/go-starter/log/transport/repositories"
 	"github.com/rosaekapratama/go-starter/loginit"
@@ -36,6 +40,10 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+const (
+	shutdownTimeout = 30 * time.Second
+)
+
 var (
 	configObject *config.Object
 )
@@ -130,6 +138,11 @@ func initRun(ctx context.Context) {
 	// Init database package
 	database.Init(ctx, configInstance)
 
+	// Init pub/sub outbox package
+	if google.Manager != nil {
+		outbox.Init(ctx, configInstance, google.Manager.GetPubSubClient())
+	}
+
 	// Init redis package
 	redis.Init(ctx, configInstance)
 
@@ -178,6 +191,9 @@ func initRun(ctx context.Context) {
 	// Init REST client
 	restclient.Init(ctx, configInstance, clientRestLogRepository)
 
+	// Init keycloak token verifier
+	keycloak.Init(ctx, configInstance)
+
 	// Init REST server
 	restserver.Init(ctx, configInstance, serverRestLogRepository)
 
@@ -198,7 +214,34 @@ func Run() {
 	// Run GRPC server
 	go grpcserver.Run()
 
-	group := sync.WaitGroup{}
-	group.Add(1)
-	group.Wait()
+	// Run pub/sub outbox relay
+	if outbox.Manager != nil {
+		outbox.Manager.Start()
+	}
+
+	// Wait for termination signal, then shut down gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	shutdown()
+}
+
+func shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	log.Info(ctx, "Shutting down application")
+
+	if err := restserver.Shutdown(ctx); err != nil {
+		log.Warnf(ctx, "Failed to shut down REST server gracefully, error=%v", err)
+	}
+
+	if outbox.Manager != nil {
+		outbox.Manager.Stop()
+	}
+
+	if grpcclient.Manager != nil {
+		if err := grpcclient.Manager.CloseAll(ctx); err != nil {
+			log.Warnf(ctx, "Failed to close GRPC client connections, error=%v", err)
+		}
+	}
 }
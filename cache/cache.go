@@ -0,0 +1,503 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/hashicorp/golang-lru/v2"
+	goRedis "github.com/redis/go-redis/v9"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/otel"
+	"github.com/rosaekapratama/go-starter/redis"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	spanGetOrLoad = "cache.GetOrLoad %s"
+
+	counterHit  = "cache.hit"
+	counterMiss = "cache.miss"
+
+	tierLocal = "local"
+	tierRedis = "redis"
+
+	defaultLocalSize = 1000
+
+	// PTTL result of key without expiration
+	pttlNoExpiration time.Duration = -1
+
+	// Curly brackets are redis hash tag, so every key of a cache lives in the same cluster slot
+	// and tag scripts below can touch them at once
+	keyFormat        = "cache:{%s}:%s"
+	tagKeyFormat     = "cache:{%s}:tag:%s"
+	channelKeyFormat = "cache:{%s}:invalidate"
+)
+
+var (
+	// countersRegistered is true once cache counters are registered or failed to register
+	countersRegistered atomic.Bool
+
+	// setScript sets cache key (KEYS[1]) to value (ARGV[1]) and adds it into tag sets (KEYS[2..]) at once,
+	// tag set expiry is only extended, never shortened, ARGV[2] is key ttl in milliseconds, zero means no expiration
+	setScript = goRedis.NewScript(`
+local ttl = tonumber(ARGV[2])
+if ttl > 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+else
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+for i = 2, #KEYS do
+	local tagKey = KEYS[i]
+	local existed = redis.call('EXISTS', tagKey)
+	redis.call('SADD', tagKey, KEYS[1])
+	if ttl <= 0 then
+		redis.call('PERSIST', tagKey)
+	elseif existed == 0 then
+		redis.call('PEXPIRE', tagKey, ttl)
+	else
+		local current = redis.call('PTTL', tagKey)
+		if current >= 0 and current < ttl then
+			redis.call('PEXPIRE', tagKey, ttl)
+		end
+	end
+end
+return 0`)
+
+	// invalidateScript deletes every member key of tag sets (KEYS) and the tag sets themselves,
+	// it returns the deleted member keys
+	invalidateScript = goRedis.NewScript(`
+local deleted = {}
+for _, tagKey in ipairs(KEYS) do
+	for _, key in ipairs(redis.call('SMEMBERS', tagKey)) do
+		if redis.call('DEL', key) == 1 then
+			table.insert(deleted, key)
+		end
+	end
+	redis.call('DEL', tagKey)
+end
+return deleted`)
+)
+
+type invalidation struct {
+	// Instance ID of the publisher, its own message is ignored
+	Source string   `json:"source,omitempty"`
+	Keys   []string `json:"keys,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+// New returns cache of type T with the given name, name is used as key prefix and metric attribute.
+// Remote tier is redis.Client by default, it is resolved on the first use of the cache,
+// so package-level cache can be declared before redis package is initiated,
+// cache works in local tier only if no redis client is available by then.
+// Cache with local tier subscribes invalidation of other instances, so short-lived cache must be closed by Close.
+// Example to use
+//
+//	userCache := cache.New[*User]("user", cache.WithLocalCache(1000, time.Minute))
+//	user, err := userCache.GetOrLoad(ctx, userId, func(ctx context.Context) (*User, error) {
+//		return userRepository.FindByID(ctx, userId)
+//	}, time.Hour, "tenant:"+tenantId)
+func New[T any](name string, opts ...Option) Cache[T] {
+	o := &options{
+		serializer: JSONSerializer(),
+		localSize:  defaultLocalSize,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.Apply(o)
+		}
+	}
+
+	return &cacheImpl[T]{
+		instanceId: uuid.NewString(),
+		name:       name,
+		opts:       o,
+		serializer: o.serializer,
+	}
+}
+
+// init resolves redis client and local tier, and starts invalidation subscription, on the first use of the cache
+func (c *cacheImpl[T]) init() {
+	c.initOnce.Do(func() {
+		ctx := context.Background()
+		c.client = c.opts.client
+		if !c.opts.clientSet {
+			c.client = redis.Client
+		}
+
+		localEnabled := c.opts.localEnabled
+		if c.client == nil && !localEnabled {
+			log.Warnf(ctx, "Redis client is not available, cache works in local tier only, name=%s", c.name)
+			localEnabled = true
+		}
+		if !localEnabled {
+			return
+		}
+		c.local = newLocalTier[T](c.opts.localSize, c.opts.localTTL)
+
+		// Local entries of other instances are evicted through redis pub/sub
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.client != nil && !c.closed {
+			subscribeCtx, cancel := context.WithCancel(ctx)
+			c.cancel = cancel
+			c.done = make(chan struct{})
+			go c.subscribeInvalidation(subscribeCtx)
+		}
+	})
+}
+
+func (c *cacheImpl[T]) Get(ctx context.Context, key string) (value T, found bool, err error) {
+	c.init()
+	if c.local != nil {
+		if value, found = c.local.get(key); found {
+			c.count(ctx, counterHit, tierLocal)
+			return
+		}
+		c.count(ctx, counterMiss, tierLocal)
+	}
+
+	if c.client == nil {
+		return
+	}
+
+	b, err := c.client.Get(ctx, c.key(key)).Bytes()
+	if errors.Is(err, goRedis.Nil) {
+		c.count(ctx, counterMiss, tierRedis)
+		return value, false, nil
+	}
+	if err != nil {
+		log.Errorf(ctx, err, "Failed to get cache, name=%s, key=%s", c.name, key)
+		return
+	}
+
+	if err = c.serializer.Unmarshal(b, &value); err != nil {
+		log.Errorf(ctx, err, "Failed to unmarshal cache, name=%s, key=%s", c.name, key)
+		return
+	}
+	c.count(ctx, counterHit, tierRedis)
+
+	// Backfill local tier with the remaining ttl of redis key
+	if c.local != nil {
+		ttl, err := c.client.PTTL(ctx, c.key(key)).Result()
+		if err == nil && (ttl > integer.Zero || ttl == pttlNoExpiration) {
+			c.local.set(key, value, ttl, nil)
+		}
+	}
+	return value, true, nil
+}
+
+func (c *cacheImpl[T]) Set(ctx context.Context, key string, value T, ttl time.Duration, tags ...string) error {
+	c.init()
+	if c.client != nil {
+		b, err := c.serializer.Marshal(value)
+		if err != nil {
+			log.Errorf(ctx, err, "Failed to marshal cache, name=%s, key=%s", c.name, key)
+			return err
+		}
+
+		if len(tags) == integer.Zero {
+			err = c.client.Set(ctx, c.key(key), b, ttl).Err()
+		} else {
+			// Script is run outside of pipeline, so it falls back to EVAL if redis lost the script cache
+			keys := make([]string, integer.Zero, len(tags)+integer.One)
+			keys = append(keys, c.key(key))
+			for _, tag := range tags {
+				keys = append(keys, c.tagKey(tag))
+			}
+			err = setScript.Run(ctx, c.client, keys, b, ttl.Milliseconds()).Err()
+		}
+		if err != nil {
+			log.Errorf(ctx, err, "Failed to set cache, name=%s, key=%s", c.name, key)
+			return err
+		}
+
+		// Overwritten value must be evicted from local tier of other instances
+		c.publishInvalidation(ctx, &invalidation{Keys: []string{key}})
+	}
+
+	if c.local != nil {
+		c.local.set(key, value, ttl, tags)
+	}
+	return nil
+}
+
+func (c *cacheImpl[T]) GetOrLoad(ctx context.Context, key string, loader func(ctx context.Context) (T, error), ttl time.Duration, tags ...string) (T, error) {
+	value, found, err := c.Get(ctx, key)
+	if err == nil && found {
+		return value, nil
+	}
+
+	// Redis failure is not fatal, value is still loaded from the source
+	v, err, _ := c.group.Do(key, func() (interface{}, error) {
+		// Value is shared, so it is not cancelled by the caller which happens to load it
+		ctx, span := otel.Trace(context.WithoutCancel(ctx), fmt.Sprintf(spanGetOrLoad, c.name))
+		defer span.End()
+
+		value, err := loader(ctx)
+		if err != nil {
+			return value, err
+		}
+		if err := c.Set(ctx, key, value, ttl, tags...); err != nil {
+			log.Warnf(ctx, "Loaded value is not cached, name=%s, key=%s, error=%v", c.name, key, err)
+		}
+		return value, nil
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	value, _ = v.(T)
+	return value, nil
+}
+
+func (c *cacheImpl[T]) Delete(ctx context.Context, keys ...string) error {
+	c.init()
+	if len(keys) == integer.Zero {
+		return nil
+	}
+
+	if c.local != nil {
+		c.local.remove(keys...)
+	}
+
+	if c.client != nil {
+		redisKeys := make([]string, len(keys))
+		for i, key := range keys {
+			redisKeys[i] = c.key(key)
+		}
+		if err := c.client.Del(ctx, redisKeys...).Err(); err != nil {
+			log.Errorf(ctx, err, "Failed to delete cache, name=%s, keys=%v", c.name, keys)
+			return err
+		}
+		c.publishInvalidation(ctx, &invalidation{Keys: keys})
+	}
+	return nil
+}
+
+func (c *cacheImpl[T]) InvalidateTags(ctx context.Context, tags ...string) error {
+	c.init()
+	if len(tags) == integer.Zero {
+		return nil
+	}
+
+	if c.local != nil {
+		c.local.removeTags(tags...)
+	}
+
+	if c.client != nil {
+		tagKeys := make([]string, len(tags))
+		for i, tag := range tags {
+			tagKeys[i] = c.tagKey(tag)
+		}
+		redisKeys, err := invalidateScript.Run(ctx, c.client, tagKeys).StringSlice()
+		if err != nil {
+			log.Errorf(ctx, err, "Failed to invalidate cache tags, name=%s, tags=%v", c.name, tags)
+			return err
+		}
+		log.Debugf(ctx, "Cache tags are invalidated, name=%s, tags=%v, count=%d", c.name, tags, len(redisKeys))
+
+		// Local entries backfilled from redis have no tags, so the deleted keys are sent as well
+		keys := make([]string, len(redisKeys))
+		prefix := c.key(str.Empty)
+		for i, redisKey := range redisKeys {
+			keys[i] = strings.TrimPrefix(redisKey, prefix)
+		}
+		c.publishInvalidation(ctx, &invalidation{Keys: keys, Tags: tags})
+	}
+	return nil
+}
+
+func (c *cacheImpl[T]) Close(ctx context.Context) error {
+	c.mu.Lock()
+	c.closed = true
+	cancel, done := c.cancel, c.done
+	c.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *cacheImpl[T]) publishInvalidation(ctx context.Context, inv *invalidation) {
+	if c.local == nil {
+		return
+	}
+
+	inv.Source = c.instanceId
+	b, err := json.Marshal(inv)
+	if err != nil {
+		log.Warnf(ctx, "Failed to marshal cache invalidation, name=%s, error=%v", c.name, err)
+		return
+	}
+	if err = c.client.Publish(ctx, fmt.Sprintf(channelKeyFormat, c.name), b).Err(); err != nil {
+		log.Warnf(ctx, "Failed to publish cache invalidation, name=%s, error=%v", c.name, err)
+	}
+}
+
+// subscribeInvalidation evicts local entries invalidated by other instances until ctx is cancelled
+func (c *cacheImpl[T]) subscribeInvalidation(ctx context.Context) {
+	defer close(c.done)
+	pubsub := c.client.Subscribe(ctx, fmt.Sprintf(channelKeyFormat, c.name))
+	defer func() {
+		_ = pubsub.Close()
+	}()
+
+	messages := pubsub.Channel()
+	for {
+		var message *goRedis.Message
+		select {
+		case <-ctx.Done():
+			return
+		case m, ok := <-messages:
+			if !ok {
+				return
+			}
+			message = m
+		}
+
+		inv := &invalidation{}
+		if err := json.Unmarshal([]byte(message.Payload), inv); err != nil {
+			log.Warnf(ctx, "Invalid cache invalidation message, name=%s, error=%v", c.name, err)
+			continue
+		}
+		if inv.Source == c.instanceId {
+			continue
+		}
+		c.local.remove(inv.Keys...)
+		c.local.removeTags(inv.Tags...)
+	}
+}
+
+// registerCounters registers cache counters once otel is initiated, so it is retried until then
+func registerCounters(ctx context.Context) {
+	registered := true
+	for _, counterName := range []string{counterHit, counterMiss} {
+		if otel.HasCounter(counterName) {
+			continue
+		}
+		if err := otel.AddCounter(ctx, counterName, "1"); err != nil {
+			log.Warnf(ctx, "Failed to add cache counter, name=%s, error=%v", counterName, err)
+			continue
+		}
+		registered = registered && otel.HasCounter(counterName)
+	}
+	countersRegistered.Store(registered)
+}
+
+func (c *cacheImpl[T]) count(ctx context.Context, counterName string, tier string) {
+	if !countersRegistered.Load() {
+		registerCounters(ctx)
+	}
+	otel.Count(ctx, counterName, integer.One, metric.WithAttributes(
+		attribute.String("cache", c.name),
+		attribute.String("tier", tier),
+	))
+}
+
+func (c *cacheImpl[T]) key(key string) string {
+	return fmt.Sprintf(keyFormat, c.name, key)
+}
+
+func (c *cacheImpl[T]) tagKey(tag string) string {
+	return fmt.Sprintf(tagKeyFormat, c.name, tag)
+}
+
+func newLocalTier[T any](size int, ttl time.Duration) *localTier[T] {
+	if size <= integer.Zero {
+		size = defaultLocalSize
+	}
+	t := &localTier[T]{
+		ttl:  ttl,
+		tags: make(map[string]map[string]struct{}),
+	}
+
+	// Error is only returned on non-positive size
+	t.lru, _ = lru.NewWithEvict[string, *localEntry[T]](size, func(key string, entry *localEntry[T]) {
+		t.untag(key, entry.tags)
+	})
+	return t
+}
+
+func (t *localTier[T]) get(key string) (value T, found bool) {
+	entry, ok := t.lru.Get(key)
+	if !ok {
+		return
+	}
+	if !entry.expireAt.IsZero() && time.Now().After(entry.expireAt) {
+		t.lru.Remove(key)
+		return
+	}
+	return entry.value, true
+}
+
+func (t *localTier[T]) set(key string, value T, ttl time.Duration, tags []string) {
+	if t.ttl > integer.Zero && (ttl <= integer.Zero || t.ttl < ttl) {
+		ttl = t.ttl
+	}
+	entry := &localEntry[T]{value: value, tags: tags}
+	if ttl > integer.Zero {
+		entry.expireAt = time.Now().Add(ttl)
+	}
+
+	// Remove replaced entry first, so its tags are cleared by eviction callback
+	t.lru.Remove(key)
+	t.lru.Add(key, entry)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, tag := range tags {
+		keys, ok := t.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			t.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+}
+
+func (t *localTier[T]) remove(keys ...string) {
+	for _, key := range keys {
+		t.lru.Remove(key)
+	}
+}
+
+func (t *localTier[T]) removeTags(tags ...string) {
+	keys := make([]string, integer.Zero)
+	t.mu.Lock()
+	for _, tag := range tags {
+		for key := range t.tags[tag] {
+			keys = append(keys, key)
+		}
+		delete(t.tags, tag)
+	}
+	t.mu.Unlock()
+	t.remove(keys...)
+}
+
+func (t *localTier[T]) untag(key string, tags []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, tag := range tags {
+		if keys, ok := t.tags[tag]; ok {
+			delete(keys, key)
+			if len(keys) == integer.Zero {
+				delete(t.tags, tag)
+			}
+		}
+	}
+}
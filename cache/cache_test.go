@@ -0,0 +1,264 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/alicebob/miniredis/v2"
+	goRedis "github.com/redis/go-redis/v9"
+	"github.com/rosaekapratama/go-starter/redis"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var ctx context.Context
+
+type testValue struct {
+	Name string `json:"name" msgpack:"name"`
+}
+
+type CacheTestSuite struct {
+	suite.Suite
+	cache Cache[*testValue]
+}
+
+func (s *CacheTestSuite) SetupTest() {
+	ctx = context.Background()
+	s.cache = New[*testValue]("test", WithRedisClient(nil), WithLocalCache(10, time.Minute))
+}
+
+func TestCacheTestSuite(t *testing.T) {
+	suite.Run(t, new(CacheTestSuite))
+}
+
+func (s *CacheTestSuite) TestGetOrLoadSharesLoader() {
+	var calls int32
+	loader := func(ctx context.Context) (*testValue, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		return &testValue{Name: "john"}, nil
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := s.cache.GetOrLoad(ctx, "1", loader, time.Minute)
+			s.NoError(err)
+			s.Equal("john", value.Name)
+		}()
+	}
+	wg.Wait()
+	s.Equal(int32(1), atomic.LoadInt32(&calls))
+
+	value, found, err := s.cache.Get(ctx, "1")
+	s.NoError(err)
+	s.True(found)
+	s.Equal("john", value.Name)
+}
+
+func (s *CacheTestSuite) TestGetOrLoadIgnoresLoaderCancel() {
+	started := make(chan struct{})
+	loader := func(ctx context.Context) (*testValue, error) {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		return &testValue{Name: "john"}, ctx.Err()
+	}
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	first := make(chan error, 1)
+	go func() {
+		_, err := s.cache.GetOrLoad(cancelCtx, "1", loader, time.Minute)
+		first <- err
+	}()
+	<-started
+
+	// Second caller waits for the loader of the first one which is cancelled meanwhile
+	second := make(chan error, 1)
+	go func() {
+		value, err := s.cache.GetOrLoad(ctx, "1", loader, time.Minute)
+		if err == nil {
+			s.Equal("john", value.Name)
+		}
+		second <- err
+	}()
+	cancel()
+
+	s.NoError(<-first)
+	s.NoError(<-second)
+}
+
+func (s *CacheTestSuite) TestInvalidateTags() {
+	s.NoError(s.cache.Set(ctx, "1", &testValue{Name: "a"}, time.Minute, "tenant:1"))
+	s.NoError(s.cache.Set(ctx, "2", &testValue{Name: "b"}, time.Minute, "tenant:2"))
+
+	s.NoError(s.cache.InvalidateTags(ctx, "tenant:1"))
+
+	_, found, _ := s.cache.Get(ctx, "1")
+	s.False(found)
+	_, found, _ = s.cache.Get(ctx, "2")
+	s.True(found)
+}
+
+func (s *CacheTestSuite) TestExpiration() {
+	s.NoError(s.cache.Set(ctx, "1", &testValue{Name: "a"}, 10*time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+
+	_, found, _ := s.cache.Get(ctx, "1")
+	s.False(found)
+}
+
+func (s *CacheTestSuite) TestSerializers() {
+	for _, serializer := range []Serializer{JSONSerializer(), MsgpackSerializer()} {
+		b, err := serializer.Marshal(&testValue{Name: "john"})
+		s.NoError(err)
+		var value *testValue
+		s.NoError(serializer.Unmarshal(b, &value))
+		s.Equal("john", value.Name)
+	}
+
+	serializer := ProtobufSerializer()
+	b, err := serializer.Marshal(wrapperspb.String("john"))
+	s.NoError(err)
+	var value *wrapperspb.StringValue
+	s.NoError(serializer.Unmarshal(b, &value))
+	s.Equal("john", value.GetValue())
+}
+
+type RedisCacheTestSuite struct {
+	suite.Suite
+	server *miniredis.Miniredis
+	client *goRedis.Client
+}
+
+func (s *RedisCacheTestSuite) SetupTest() {
+	ctx = context.Background()
+	s.server = miniredis.RunT(s.T())
+	s.client = goRedis.NewClient(&goRedis.Options{Addr: s.server.Addr()})
+}
+
+func (s *RedisCacheTestSuite) TearDownTest() {
+	_ = s.client.Close()
+}
+
+func TestRedisCacheTestSuite(t *testing.T) {
+	suite.Run(t, new(RedisCacheTestSuite))
+}
+
+// newCache returns cache with local tier, it waits until invalidation channel is subscribed
+func (s *RedisCacheTestSuite) newCache() Cache[*testValue] {
+	c := New[*testValue]("test", WithRedisClient(s.client), WithLocalCache(10, time.Minute))
+	c.(*cacheImpl[*testValue]).init()
+	channel := fmt.Sprintf(channelKeyFormat, "test")
+	subscribers := s.server.PubSubNumSub(channel)[channel]
+	s.Eventually(func() bool {
+		return s.server.PubSubNumSub(channel)[channel] > subscribers
+	}, time.Second, 10*time.Millisecond)
+	s.T().Cleanup(func() {
+		_ = c.Close(context.Background())
+	})
+	return c
+}
+
+func (s *RedisCacheTestSuite) TestTaggedSet() {
+	c := New[*testValue]("test", WithRedisClient(s.client))
+	s.Require().NoError(c.Set(ctx, "1", &testValue{Name: "a"}, time.Minute, "tenant:1"))
+
+	// Script cache is lost on redis restart or failover
+	s.Require().NoError(s.client.ScriptFlush(ctx).Err())
+	s.Require().NoError(c.Set(ctx, "2", &testValue{Name: "b"}, time.Minute, "tenant:1"))
+
+	members, err := s.server.Members("cache:{test}:tag:tenant:1")
+	s.Require().NoError(err)
+	s.ElementsMatch([]string{"cache:{test}:1", "cache:{test}:2"}, members)
+	s.Equal(time.Minute, s.server.TTL("cache:{test}:2"))
+	s.Equal(time.Minute, s.server.TTL("cache:{test}:tag:tenant:1"))
+
+	s.Require().NoError(c.InvalidateTags(ctx, "tenant:1"))
+	s.False(s.server.Exists("cache:{test}:1"))
+	s.False(s.server.Exists("cache:{test}:2"))
+	s.False(s.server.Exists("cache:{test}:tag:tenant:1"))
+	_, found, err := c.Get(ctx, "1")
+	s.NoError(err)
+	s.False(found)
+}
+
+func (s *RedisCacheTestSuite) TestCrossInstanceInvalidation() {
+	first := s.newCache()
+	second := s.newCache()
+	s.Require().NoError(first.Set(ctx, "1", &testValue{Name: "a"}, time.Minute, "tenant:1"))
+	s.Require().NoError(first.Set(ctx, "2", &testValue{Name: "b"}, time.Minute))
+
+	// Second instance backfills its local tier from redis
+	value, found, err := second.Get(ctx, "1")
+	s.Require().NoError(err)
+	s.True(found)
+	s.Equal("a", value.Name)
+	_, _, _ = second.Get(ctx, "2")
+
+	// Overwrite evicts local entry of the other instance, but not the one of the writer
+	s.Require().NoError(first.Set(ctx, "1", &testValue{Name: "c"}, time.Minute, "tenant:1"))
+	s.Eventually(func() bool {
+		_, found := second.(*cacheImpl[*testValue]).local.get("1")
+		return !found
+	}, time.Second, 10*time.Millisecond)
+	value, _, _ = second.Get(ctx, "1")
+	s.Equal("c", value.Name)
+	value, found = first.(*cacheImpl[*testValue]).local.get("1")
+	s.True(found)
+	s.Equal("c", value.Name)
+
+	s.Require().NoError(first.InvalidateTags(ctx, "tenant:1"))
+	s.Eventually(func() bool {
+		_, found := second.(*cacheImpl[*testValue]).local.get("1")
+		return !found
+	}, time.Second, 10*time.Millisecond)
+	_, found = second.(*cacheImpl[*testValue]).local.get("2")
+	s.True(found)
+}
+
+func (s *RedisCacheTestSuite) TestClose() {
+	c := s.newCache()
+	channel := fmt.Sprintf(channelKeyFormat, "test")
+	s.Require().NoError(c.Close(ctx))
+	s.Eventually(func() bool {
+		return s.server.PubSubNumSub(channel)[channel] == 0
+	}, time.Second, 10*time.Millisecond)
+
+	// Closing again or closing cache without subscription is no-op
+	s.NoError(c.Close(ctx))
+	s.NoError(New[*testValue]("test", WithRedisClient(s.client)).Close(ctx))
+}
+
+func (s *RedisCacheTestSuite) TestLazyClient() {
+	// Package-level cache is declared before redis package is initiated
+	c := New[*testValue]("test", WithLocalCache(10, time.Minute))
+	defer func(client goRedis.UniversalClient) {
+		redis.Client = client
+	}(redis.Client)
+	redis.Client = s.client
+
+	s.Require().NoError(c.Set(ctx, "1", &testValue{Name: "a"}, time.Minute))
+	s.True(s.server.Exists("cache:{test}:1"))
+	channel := fmt.Sprintf(channelKeyFormat, "test")
+	s.Eventually(func() bool {
+		return s.server.PubSubNumSub(channel)[channel] == 1
+	}, time.Second, 10*time.Millisecond)
+	s.NoError(c.Close(ctx))
+	s.Eventually(func() bool {
+		return s.server.PubSubNumSub(channel)[channel] == 0
+	}, time.Second, 10*time.Millisecond)
+
+	// Counters are registered once otel is initiated, not burnt by the first use before it
+	s.False(countersRegistered.Load())
+
+	// Cache closed before its first use never subscribes
+	c = New[*testValue]("test", WithLocalCache(10, time.Minute))
+	s.NoError(c.Close(ctx))
+	s.NoError(c.Set(ctx, "1", &testValue{Name: "b"}, time.Minute))
+	s.Equal(0, s.server.PubSubNumSub(channel)[channel])
+}
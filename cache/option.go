@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+type Option interface {
+	Apply(o *options)
+}
+
+type clientOption struct {
+	client redis.UniversalClient
+}
+
+type serializerOption struct {
+	serializer Serializer
+}
+
+type localCacheOption struct {
+	size int
+	ttl  time.Duration
+}
+
+func (o *clientOption) Apply(opts *options) {
+	opts.client = o.client
+	opts.clientSet = true
+}
+
+func (o *serializerOption) Apply(opts *options) {
+	opts.serializer = o.serializer
+}
+
+func (o *localCacheOption) Apply(opts *options) {
+	opts.localEnabled = true
+	opts.localSize = o.size
+	opts.localTTL = o.ttl
+}
+
+// WithRedisClient set redis client of the remote tier, default is redis.Client on the first use of the cache,
+// nil client makes cache work in local tier only
+func WithRedisClient(client redis.UniversalClient) Option {
+	return &clientOption{client: client}
+}
+
+// WithSerializer set serializer of the remote tier, default is JSONSerializer
+func WithSerializer(serializer Serializer) Option {
+	return &serializerOption{serializer: serializer}
+}
+
+// WithLocalCache enable in-memory LRU tier in front of redis with the given max entries,
+// local entry lives for the shorter of ttl and its cache ttl, zero ttl follows the cache ttl only
+func WithLocalCache(size int, ttl time.Duration) Option {
+	return &localCacheOption{size: size, ttl: ttl}
+}
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"reflect"
+)
+
+type jsonSerializer struct {
+}
+
+type msgpackSerializer struct {
+}
+
+type protobufSerializer struct {
+}
+
+func (s *jsonSerializer) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (s *jsonSerializer) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (s *msgpackSerializer) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (s *msgpackSerializer) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+func (s *protobufSerializer) Marshal(v interface{}) ([]byte, error) {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("cache value must implement proto.Message, type=%T", v)
+	}
+	return proto.Marshal(message)
+}
+
+// Unmarshal accepts proto.Message or pointer to nil proto.Message pointer, the latter is allocated before unmarshal
+func (s *protobufSerializer) Unmarshal(data []byte, v interface{}) error {
+	if message, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(data, message)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && rv.Elem().Kind() == reflect.Ptr {
+		elem := rv.Elem()
+		if elem.IsNil() {
+			elem.Set(reflect.New(elem.Type().Elem()))
+		}
+		if message, ok := elem.Interface().(proto.Message); ok {
+			return proto.Unmarshal(data, message)
+		}
+	}
+	return fmt.Errorf("cache value must implement proto.Message, type=%T", v)
+}
+
+// JSONSerializer encodes cache value as JSON, it is the default serializer
+func JSONSerializer() Serializer {
+	return &jsonSerializer{}
+}
+
+// MsgpackSerializer encodes cache value as MessagePack
+func MsgpackSerializer() Serializer {
+	return &msgpackSerializer{}
+}
+
+// ProtobufSerializer encodes cache value as protobuf binary, cache type must be a proto.Message pointer
+func ProtobufSerializer() Serializer {
+	return &protobufSerializer{}
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"github.com/hashicorp/golang-lru/v2"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+	"sync"
+	"time"
+)
+
+type Cache[T any] interface {
+	// Get returns cached value of the key, found is false if the key doesn't exist or is expired
+	Get(ctx context.Context, key string) (value T, found bool, err error)
+
+	// Set caches value of the key, zero ttl means no expiration,
+	// the key is invalidated by InvalidateTags of any of the given tags
+	Set(ctx context.Context, key string, value T, ttl time.Duration, tags ...string) error
+
+	// GetOrLoad returns cached value of the key, or caches and returns value from loader if not found.
+	// Concurrent calls of the same key on this instance share one loader call.
+	GetOrLoad(ctx context.Context, key string, loader func(ctx context.Context) (T, error), ttl time.Duration, tags ...string) (T, error)
+
+	// Delete removes the keys from all tiers
+	Delete(ctx context.Context, keys ...string) error
+
+	// InvalidateTags removes every key cached with any of the given tags from all tiers
+	InvalidateTags(ctx context.Context, tags ...string) error
+
+	// Close stops invalidation subscription of local tier and waits until it is closed or ctx is done,
+	// cache which is not alive for the whole app lifetime must be closed once it is no longer used
+	Close(ctx context.Context) error
+}
+
+type Serializer interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type cacheImpl[T any] struct {
+	// instanceId identifies invalidation messages published by this instance
+	instanceId string
+	name       string
+	opts       *options
+	serializer Serializer
+	group      singleflight.Group
+
+	// client and local are resolved by init on the first use
+	initOnce sync.Once
+	client   redis.UniversalClient
+	local    *localTier[T]
+
+	// cancel stops invalidation subscription, done is closed once it is stopped,
+	// closed cache doesn't start subscription on its first use
+	mu     sync.Mutex
+	closed bool
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type localTier[T any] struct {
+	lru  *lru.Cache[string, *localEntry[T]]
+	ttl  time.Duration
+	tags map[string]map[string]struct{}
+	mu   sync.Mutex
+}
+
+type localEntry[T any] struct {
+	value    T
+	tags     []string
+	expireAt time.Time
+}
+
+type options struct {
+	client       redis.UniversalClient
+	clientSet    bool
+	serializer   Serializer
+	localSize    int
+	localTTL     time.Duration
+	localEnabled bool
+}
@@ -37,6 +37,7 @@ type Object struct {
 	Zeebe         *ZeebeConfig                    `yaml:"zeebe"`
 	ElasticSearch map[string]*ElasticSearchConfig `yaml:"elasticSearch"`
 	Sftp          *SftpConfig                     `yaml:"sftp"`
+	Keycloak      *KeycloakConfig                 `yaml:"keycloak"`
 }
 
 type AppConfig struct {
@@ -61,6 +62,62 @@ type RestClientConfig struct {
 	Logging  *RestClientLoggingConfig `yaml:"logging"`
 	Timeout  int                      `yaml:"timeout"`
 	Insecure bool                     `yaml:"insecure"`
+	// Named clients keyed by client name, retrieved by restclient.Manager.GetClient
+	Clients map[string]*NamedRestClientConfig `yaml:"clients"`
+}
+
+type NamedRestClientConfig struct {
+	// Base URL prepended to relative request URL
+	BaseUrl string `yaml:"baseUrl"`
+	// Wait time in second, default is timeout of default client
+	Timeout int `yaml:"timeout"`
+	// Skip server certificate verification, ignored if tls is enabled, use tls.insecureSkipVerify instead
+	Insecure bool `yaml:"insecure"`
+	// TLS and mutual TLS of the connection
+	TLS *TLSConfig `yaml:"tls"`
+	// Headers sent on every request
+	Headers map[string]string `yaml:"headers"`
+	// Auth sent on every request, only one of them should be set
+	Auth *RestClientAuthConfig `yaml:"auth"`
+	// Proxy URL, default is proxy of HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+	Proxy string `yaml:"proxy"`
+}
+
+type RestClientAuthConfig struct {
+	Basic  *BasicAuthConfig    `yaml:"basic"`
+	Bearer *BearerAuthConfig   `yaml:"bearer"`
+	ApiKey *ApiKeyAuthConfig   `yaml:"apiKey"`
+	OAuth2 *OAuth2ClientConfig `yaml:"oauth2"`
+}
+
+type BasicAuthConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+type BearerAuthConfig struct {
+	Token string `yaml:"token"`
+}
+
+type ApiKeyAuthConfig struct {
+	// Header name of the API key, default is X-API-Key
+	Header string `yaml:"header"`
+	Value  string `yaml:"value"`
+}
+
+type OAuth2ClientConfig struct {
+	// Grant to get the token, one of clientCredentials, tokenExchange or forward, default is clientCredentials,
+	// forward sends token of the incoming request as is
+	Grant string `yaml:"grant"`
+	// Token endpoint, ex: https://sso.example.com/realms/myrealm/protocol/openid-connect/token
+	TokenUrl     string   `yaml:"tokenUrl"`
+	ClientId     string   `yaml:"clientId"`
+	ClientSecret string   `yaml:"clientSecret"`
+	Scopes       []string `yaml:"scopes"`
+	// Audience of exchanged token, it is client ID of the target service in keycloak
+	Audience string `yaml:"audience"`
+	// Token is refreshed this long before it expires, default is 30s
+	ExpiryDelta *yaml.Duration `yaml:"expiryDelta"`
 }
 
 type RestClientLoggingConfig struct {
@@ -82,10 +139,47 @@ type SoapClientLoggingConfig struct {
 }
 
 type GrpcClientConfig struct {
-	Address  string                       `yaml:"address"`
-	Timeout  int                          `yaml:"timeout"`
-	Insecure bool                         `yaml:"insecure"`
-	Logging  *GrpcClientConnLoggingConfig `yaml:"logging"`
+	Address     string                       `yaml:"address"`
+	Timeout     int                          `yaml:"timeout"`
+	Insecure    bool                         `yaml:"insecure"`
+	Logging     *GrpcClientConnLoggingConfig `yaml:"logging"`
+	HealthCheck *HealthCheckConfig           `yaml:"healthCheck"`
+	// Token sent as per RPC credential of every call
+	OAuth2 *OAuth2ClientConfig `yaml:"oauth2"`
+	// TLS and mutual TLS of the connection, insecure is ignored if tls is enabled
+	TLS       *TLSConfig                 `yaml:"tls"`
+	Keepalive *GrpcClientKeepaliveConfig `yaml:"keepalive"`
+	// Load balancing policy, pick_first or round_robin, default is pick_first.
+	// Use dns:///host:port address to balance over every resolved IP,
+	// xds:///service address gets the policy from xDS control plane instead
+	LoadBalancing string                 `yaml:"loadBalancing"`
+	Retry         *GrpcClientRetryConfig `yaml:"retry"`
+	// Maximum size of received message, ex: 16MB, default is 4MB
+	MaxRecvMsgSize string `yaml:"maxRecvMsgSize"`
+	// Maximum size of sent message, ex: 16MB, default is unlimited
+	MaxSendMsgSize string `yaml:"maxSendMsgSize"`
+}
+
+type GrpcClientKeepaliveConfig struct {
+	// Ping is sent after this long without activity, minimum is 10s, default is no ping
+	Time *yaml.Duration `yaml:"time"`
+	// Connection is closed if ping is not acknowledged within this long, default is 20s
+	Timeout *yaml.Duration `yaml:"timeout"`
+	// Send ping even if there is no active RPC
+	PermitWithoutStream bool `yaml:"permitWithoutStream"`
+}
+
+type GrpcClientRetryConfig struct {
+	// Maximum attempts including the original call, maximum is 5, default is 3
+	MaxAttempts int `yaml:"maxAttempts"`
+	// Backoff before the first retry, default is 100ms
+	InitialBackoff *yaml.Duration `yaml:"initialBackoff"`
+	// Maximum backoff between retries, default is 1s
+	MaxBackoff *yaml.Duration `yaml:"maxBackoff"`
+	// Backoff multiplier of every retry, default is 2
+	BackoffMultiplier float64 `yaml:"backoffMultiplier"`
+	// Retried status codes, ex: UNAVAILABLE, default is UNAVAILABLE
+	RetryableStatusCodes []string `yaml:"retryableStatusCodes"`
 }
 
 type GrpcClientConnLoggingConfig struct {
@@ -114,9 +208,66 @@ type ServerConfig struct {
 }
 
 type RestServerConfig struct {
-	Logging  *RestServerLoggingConfig `yaml:"logging"`
-	Port     *HttpHttpsPortConfig     `yaml:"port"`
-	Disabled bool                     `yaml:"disabled"`
+	Logging     *RestServerLoggingConfig `yaml:"logging"`
+	Port        *HttpHttpsPortConfig     `yaml:"port"`
+	TLS         *ServerTLSConfig         `yaml:"tls"`
+	OpenAPI     *OpenAPIConfig           `yaml:"openapi"`
+	Compression *CompressionConfig       `yaml:"compression"`
+	ETag        *ETagConfig              `yaml:"etag"`
+	Response    *RestResponseConfig      `yaml:"response"`
+	Disabled    bool                     `yaml:"disabled"`
+}
+
+type RestResponseConfig struct {
+	// Response format, base wraps every response in BaseResponse,
+	// problem renders error as RFC 7807 application/problem+json and success as raw data, default is base
+	Format string `yaml:"format"`
+	// Problem type is this URL followed by response code, default type is about:blank
+	ProblemTypeBaseUrl string `yaml:"problemTypeBaseUrl"`
+}
+
+type CompressionConfig struct {
+	// Disable response compression, it is enabled by default
+	Disabled bool `yaml:"disabled"`
+	// Minimum response body size to be compressed, default is 1KB
+	MinSize string `yaml:"minSize"`
+	// Supported encodings in order of preference when client accepts them equally, default is br, zstd, gzip
+	Encodings []string `yaml:"encodings"`
+}
+
+type ETagConfig struct {
+	// Disable automatic ETag and If-None-Match handling of GET responses, it is enabled by default
+	Disabled bool `yaml:"disabled"`
+}
+
+type OpenAPIConfig struct {
+	// Serve OpenAPI document, and its UI if it is set by restserver.SetOpenAPIUi
+	Enabled bool `yaml:"enabled"`
+	// Document info, default title is app name and default version is 1.0.0
+	Title       string `yaml:"title"`
+	Version     string `yaml:"version"`
+	Description string `yaml:"description"`
+	// Path of OpenAPI document, default is /openapi.json
+	Path string `yaml:"path"`
+	// Path of UI, default is /docs
+	UiPath string `yaml:"uiPath"`
+}
+
+type ServerTLSConfig struct {
+	// Serve HTTPS on https port
+	Enabled bool `yaml:"enabled"`
+	// PEM encoded server certificate and its key file
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+	// PEM encoded CA bundle to verify client certificate, enables mutual TLS
+	ClientCaFile string `yaml:"clientCaFile"`
+	// Client certificate policy, one of none, request, require, verifyIfGiven or requireAndVerify,
+	// default is requireAndVerify if clientCaFile is set, none otherwise
+	ClientAuth string `yaml:"clientAuth"`
+	// Interval to check certificate files for changes, default is 1m
+	ReloadInterval *yaml.Duration `yaml:"reloadInterval"`
+	// Redirect plain HTTP request to HTTPS instead of serving it, health check is still served
+	RedirectHttp bool `yaml:"redirectHttp"`
 }
 
 type RestServerLoggingConfig struct {
@@ -128,9 +279,21 @@ type RestServerLoggingConfig struct {
 type GrpcServerConfig struct {
 	Logging  *GrpcServerLoggingConfig `yaml:"logging"`
 	Port     *HttpHttpsPortConfig     `yaml:"port"`
+	Auth     *GrpcServerAuthConfig    `yaml:"auth"`
 	Disabled bool                     `yaml:"disabled"`
 }
 
+type GrpcServerAuthConfig struct {
+	// If true then every call must have bearer token in authorization metadata,
+	// it is verified by keycloak verifier and its claims are set to context
+	Enabled bool `yaml:"enabled"`
+	// Full method names callable without token, ex: /mypackage.MyService/MyMethod,
+	// health check and reflection services are always callable without token
+	PublicMethods []string `yaml:"publicMethods"`
+	// Realm roles required by full method name, user must have all of the roles
+	MethodRoles map[string][]string `yaml:"methodRoles"`
+}
+
 type GrpcServerLoggingConfig struct {
 	PayloadLogSizeLimit string `yaml:"payloadLogSizeLimit"`
 	Stdout              bool   `yaml:"stdout"`
@@ -174,6 +337,7 @@ type DatabaseConfig struct {
 	SkipDefaultTransaction    bool                `yaml:"skipDefaultTransaction"`
 	SlowThreshold             int                 `yaml:"slowThreshold"`
 	IgnoreRecordNotFoundError bool                `yaml:"ignoreRecordNotFoundError"`
+	HealthCheck               *HealthCheckConfig  `yaml:"healthCheck"`
 }
 
 type DatabaseConnConfig struct {
@@ -186,7 +350,38 @@ type RedisConfig struct {
 	Mode                string `yaml:"mode"`
 	RedisSingleConfig   `yaml:",inline"`
 	RedisSentinelConfig `yaml:",inline"`
-	Disabled            bool `yaml:"disabled"`
+	RedisClusterConfig  `yaml:",inline"`
+	TLS                 *TLSConfig         `yaml:"tls"`
+	HealthCheck         *HealthCheckConfig `yaml:"healthCheck"`
+	Disabled            bool               `yaml:"disabled"`
+}
+
+type RedisClusterConfig struct {
+	// A seed list of host:port addresses of cluster nodes.
+	ClusterAddrs []string `yaml:"clusterAddrs"`
+	// The maximum number of retries before giving up. Command is retried
+	// on network errors and MOVED/ASK redirects.
+	// Default is 3 retries.
+	MaxRedirects *int `yaml:"maxRedirects"`
+	// Enables read-only commands on slave nodes.
+	ReadOnly *bool `yaml:"readOnly"`
+
+	// RouteByLatency and RouteRandomly are shared with sentinel config,
+	// both of them enable ReadOnly automatically on cluster mode.
+}
+
+type TLSConfig struct {
+	// Enable TLS connection
+	Enabled bool `yaml:"enabled"`
+	// PEM encoded CA certificate file to verify the server, system CA pool is used if empty
+	CaFile string `yaml:"caFile"`
+	// PEM encoded client certificate and its key file, required by server with mutual TLS
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+	// Server name to verify the hostname on the returned certificate, default is the dialed host
+	ServerName string `yaml:"serverName"`
+	// Skip server certificate verification, should only be used for testing
+	InsecureSkipVerify bool `yaml:"insecureSkipVerify"`
 }
 
 type RedisSingleConfig struct {
@@ -362,8 +557,32 @@ type GoogleCloudOauth2Config struct {
 }
 
 type GoogleCloudPubsubConfig struct {
-	Publisher  *GoogleCloudPubsubPublisherConfig  `yaml:"publisher"`
-	Subscriber *GoogleCloudPubsubSubscriberConfig `yaml:"subscriber"`
+	Publisher   *GoogleCloudPubsubPublisherConfig  `yaml:"publisher"`
+	Subscriber  *GoogleCloudPubsubSubscriberConfig `yaml:"subscriber"`
+	Outbox      *GoogleCloudPubsubOutboxConfig     `yaml:"outbox"`
+	HealthCheck *HealthCheckConfig                 `yaml:"healthCheck"`
+}
+
+type GoogleCloudPubsubOutboxConfig struct {
+	// Database ID which holds the outbox table, outbox is disabled if empty
+	Database string `yaml:"database"`
+	// Outbox table name, default is outbox_messages
+	Table string `yaml:"table"`
+	// Max messages picked on each relay poll, default is 100
+	BatchSize int `yaml:"batchSize"`
+	// Relay poll interval, default is 1s
+	PollInterval *yaml.Duration `yaml:"pollInterval"`
+	// Max publish attempts before message is marked as failed, default is 10
+	MaxAttempts int `yaml:"maxAttempts"`
+	// Backoff before the first retry, doubled on each next retry, default is 1s
+	MinRetryBackoff *yaml.Duration `yaml:"minRetryBackoff"`
+	// Max backoff between retries, default is 5m
+	MaxRetryBackoff *yaml.Duration `yaml:"maxRetryBackoff"`
+	// How long published messages are kept before cleaned up, default is 168h
+	Retention *yaml.Duration `yaml:"retention"`
+	// How long message claimed by relay is reserved before other relay may claim it again, default is 1m
+	Lease    *yaml.Duration `yaml:"lease"`
+	Disabled bool           `yaml:"disabled"`
 }
 
 type GoogleCloudPubsubPublisherConfig struct {
@@ -392,20 +611,40 @@ type GoogleCloudOauth2VerificationConfig struct {
 	Sub   string `yaml:"sub"`
 }
 
+type KeycloakConfig struct {
+	// Keycloak base URL, ex: https://sso.example.com, token issuer must be {baseUrl}/realms/{realm}
+	BaseUrl string `yaml:"baseUrl"`
+	// Realms which tokens are accepted, any realm of the base URL is accepted if empty
+	Realms []string `yaml:"realms"`
+	// Accepted aud claim values, audience is not checked if empty
+	Audiences []string `yaml:"audiences"`
+	// Tolerated clock difference on exp, nbf and iat validation, default is 30s
+	ClockSkew *yaml.Duration `yaml:"clockSkew"`
+	// JWKS background refresh interval, default is 1h,
+	// JWKS is also refreshed when token is signed by unknown key ID
+	JwksRefreshInterval *yaml.Duration `yaml:"jwksRefreshInterval"`
+	// Min interval between JWKS refreshes, default is 1m
+	JwksRefreshRateLimit *yaml.Duration `yaml:"jwksRefreshRateLimit"`
+	// If true then token signature and claims are not verified, only decoded
+	Disabled bool `yaml:"disabled"`
+}
+
 type ZeebeConfig struct {
-	Address                string `yaml:"address"`
-	ClientId               string `yaml:"clientId"`
-	ClientSecret           string `yaml:"clientSecret"`
-	AuthorizationServerURL string `yaml:"authorizationServerURL"`
-	Disabled               bool   `yaml:"disabled"`
+	Address                string             `yaml:"address"`
+	ClientId               string             `yaml:"clientId"`
+	ClientSecret           string             `yaml:"clientSecret"`
+	AuthorizationServerURL string             `yaml:"authorizationServerURL"`
+	HealthCheck            *HealthCheckConfig `yaml:"healthCheck"`
+	Disabled               bool               `yaml:"disabled"`
 }
 
 type ElasticSearchConfig struct {
-	Addresses []string                    `yaml:"addresses"`
-	Username  string                      `yaml:"username"`
-	Password  string                      `yaml:"password"`
-	Logging   *ElasticSearchLoggingConfig `yaml:"logging"`
-	Disabled  bool                        `yaml:"disabled"`
+	Addresses   []string                    `yaml:"addresses"`
+	Username    string                      `yaml:"username"`
+	Password    string                      `yaml:"password"`
+	Logging     *ElasticSearchLoggingConfig `yaml:"logging"`
+	HealthCheck *HealthCheckConfig          `yaml:"healthCheck"`
+	Disabled    bool                        `yaml:"disabled"`
 }
 
 type ElasticSearchLoggingConfig struct {
@@ -414,6 +653,11 @@ type ElasticSearchLoggingConfig struct {
 	Database            string `yaml:"database"`
 }
 
+type HealthCheckConfig struct {
+	Timeout  int  `yaml:"timeout"`  // In seconds, default is 3 seconds
+	Disabled bool `yaml:"disabled"` // If true then the client is not registered to /v1/health
+}
+
 type SftpConfig struct {
 	client map[string]*SftpClientConfig `yaml:"client"`
 }
@@ -10,4 +10,8 @@ const (
 	ImagePng               = "image/png"
 	ImageGif               = "image/gif"
 	TextPlain              = "text/plain"
+	TextXml                = "text/xml"
+	ApplicationSoapXml     = "application/soap+xml"
+	ApplicationXopXml      = "application/xop+xml"
+	MultipartRelated       = "multipart/related"
 )
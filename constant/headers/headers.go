@@ -46,6 +46,8 @@ const (
 	ContentLanguage               = "Content-Language"
 	ContentLocation               = "Content-Location"
 	ContentDisposition            = "Content-Disposition"
+	ContentID                     = "Content-ID"
+	ContentTransferEncoding       = "Content-Transfer-Encoding"
 	ContentRange                  = "Content-Range"
 	ETag                          = "ETag"
 	Expires                       = "Expires"
@@ -82,6 +84,12 @@ const (
 	XRatelimitLimit        = "X-Ratelimit-Limit"
 	XRatelimitRemaining    = "X-Ratelimit-Remaining"
 	XRatelimitReset        = "X-Ratelimit-Reset"
+	RateLimitLimit         = "RateLimit-Limit"
+	RateLimitRemaining     = "RateLimit-Remaining"
+	RateLimitReset         = "RateLimit-Reset"
+	XAPIKey                = "X-API-Key"
+	IdempotencyKey         = "Idempotency-Key"
+	IdempotentReplayed     = "Idempotent-Replayed"
 	SOAPAction             = "SOAPAction"
 	Host                   = "Host"
 	Realm                  = "Realm"
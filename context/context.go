@@ -22,6 +22,8 @@ const (
 	realmKey          = "realm"
 	emailKey          = "email"
 	rolesKey          = "roles"
+	clientRolesKey    = "clientRoles"
+	scopesKey         = "scopes"
 )
 
 var (
@@ -160,6 +162,29 @@ func ContextWithRoles(parentContext context.Context, roles []string) context.Con
 	return context.WithValue(parentContext, rolesKey, roles)
 }
 
+// ClientRolesFromContext returns roles of each client, keyed by client ID
+func ClientRolesFromContext(ctx context.Context) (clientRoles map[string][]string, exists bool) {
+	if ctx.Value(clientRolesKey) == nil {
+		return nil, false
+	}
+	return ctx.Value(clientRolesKey).(map[string][]string), true
+}
+
+func ContextWithClientRoles(parentContext context.Context, clientRoles map[string][]string) context.Context {
+	return context.WithValue(parentContext, clientRolesKey, clientRoles)
+}
+
+func ScopesFromContext(ctx context.Context) (scopes []string, exists bool) {
+	if ctx.Value(scopesKey) == nil {
+		return nil, false
+	}
+	return ctx.Value(scopesKey).([]string), true
+}
+
+func ContextWithScopes(parentContext context.Context, scopes []string) context.Context {
+	return context.WithValue(parentContext, scopesKey, scopes)
+}
+
 func MetadataContextFromIncomingContext(ctx context.Context) context.Context {
 	md, exists := metadata.FromIncomingContext(ctx)
 	if exists {
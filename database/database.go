@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/rosaekapratama/go-starter/constant/sym"
+	"github.com/rosaekapratama/go-starter/healthcheck"
 	"github.com/rosaekapratama/go-starter/utils"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm/logger"
@@ -29,6 +30,7 @@ import (
 
 const (
 	errDatabaseManagerIsDisabled = "Database manager is disabled"
+	healthCheckNameFormat        = "database:%s"
 
 	PostgreSQL = "postgresql"
 	MySQL      = "mysql"
@@ -202,6 +204,9 @@ func Init(ctx context.Context, config config.Config) {
 
 		gormDBMap[id] = gormDB
 		sqlDBMap[id] = sqlDB
+
+		// Add health checker
+		healthcheck.AddCheckerWithConfig(fmt.Sprintf(healthCheckNameFormat, id), databaseConfig.HealthCheck, sqlDB.PingContext)
 	}
 
 	Manager = &managerImpl{
@@ -0,0 +1,348 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/otel"
+	"github.com/rosaekapratama/go-starter/page"
+	"github.com/rosaekapratama/go-starter/response"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"reflect"
+)
+
+const (
+	spanFindByID     = "database.Repository.FindByID"
+	spanFindAll      = "database.Repository.FindAll"
+	spanFindPage     = "database.Repository.FindPage"
+	spanFindByCursor = "database.Repository.FindByCursor"
+	spanCount        = "database.Repository.Count"
+	spanCreate       = "database.Repository.Create"
+	spanUpdate       = "database.Repository.Update"
+	spanDelete       = "database.Repository.Delete"
+	spanDeleteByID   = "database.Repository.DeleteByID"
+	spanHardDelete   = "database.Repository.HardDelete"
+)
+
+var primaryKeyColumn = clause.Column{Table: clause.CurrentTable, Name: clause.PrimaryKey}
+
+// NewRepository returns generic repository of entity T on the given connection ID,
+// every call joins the transaction carried by its context if any, see Transaction.
+func NewRepository[T any](connectionId string) Repository[T] {
+	return &repositoryImpl[T]{connectionId: connectionId}
+}
+
+func (r *repositoryImpl[T]) FindByID(ctx context.Context, id interface{}, specs ...Specification) (*T, error) {
+	ctx, span := otel.Trace(ctx, spanFindByID)
+	defer span.End()
+
+	db, err := GetDB(ctx, r.connectionId)
+	if err != nil {
+		return nil, err
+	}
+
+	entity := new(T)
+	err = db.Scopes(toScopes(specs)...).Where(clause.Eq{Column: primaryKeyColumn, Value: id}).Take(entity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, response.DataNotFound
+	}
+	if err != nil {
+		log.Errorf(ctx, err, "Failed to find entity by ID, type=%T, id=%v", entity, id)
+		return nil, err
+	}
+	return entity, nil
+}
+
+func (r *repositoryImpl[T]) FindAll(ctx context.Context, specs ...Specification) ([]*T, error) {
+	ctx, span := otel.Trace(ctx, spanFindAll)
+	defer span.End()
+
+	db, err := GetDB(ctx, r.connectionId)
+	if err != nil {
+		return nil, err
+	}
+
+	entities := make([]*T, integer.Zero)
+	err = db.Scopes(toScopes(specs)...).Find(&entities).Error
+	if err != nil {
+		log.Errorf(ctx, err, "Failed to find all entities, type=%T", new(T))
+		return nil, err
+	}
+	return entities, nil
+}
+
+func (r *repositoryImpl[T]) FindPage(ctx context.Context, pageRequest *page.PageRequest, specs ...Specification) ([]*T, *page.PageResponse, error) {
+	ctx, span := otel.Trace(ctx, spanFindPage)
+	defer span.End()
+
+	if pageRequest == nil {
+		pageRequest = page.NewPageRequest(page.DefaultPageNum, page.DefaultPageSize)
+	}
+	if !pageRequest.IsValid() {
+		return nil, nil, response.InvalidPageRequest
+	}
+	if pageRequest.PageSize > page.MaxPageSize {
+		return nil, nil, response.PageSizeExceedsMaxLimit
+	}
+
+	db, err := GetDB(ctx, r.connectionId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// ORDER BY clause is removed by gorm on count query
+	var total int64
+	err = db.Model(new(T)).Scopes(toScopes(specs)...).Count(&total).Error
+	if err != nil {
+		log.Errorf(ctx, err, "Failed to count entities, type=%T", new(T))
+		return nil, nil, err
+	}
+
+	entities := make([]*T, integer.Zero)
+	if total > int64(pageRequest.GetOffset()) {
+		err = db.Scopes(toScopes(specs)...).
+			Offset(pageRequest.GetOffset()).
+			Limit(pageRequest.GetLimit()).
+			Find(&entities).Error
+		if err != nil {
+			log.Errorf(ctx, err, "Failed to find page of entities, type=%T, pageNum=%d, pageSize=%d", new(T), pageRequest.PageNum, pageRequest.PageSize)
+			return nil, nil, err
+		}
+	}
+	return entities, page.NewPageResponse(pageRequest, int(total)), nil
+}
+
+func (r *repositoryImpl[T]) FindByCursor(ctx context.Context, cursorRequest *CursorRequest, specs ...Specification) ([]*T, *CursorResponse, error) {
+	ctx, span := otel.Trace(ctx, spanFindByCursor)
+	defer span.End()
+
+	if cursorRequest == nil {
+		cursorRequest = &CursorRequest{}
+	}
+	size := cursorRequest.Size
+	if size == integer.Zero {
+		size = page.DefaultPageSize
+	}
+	if size < integer.Zero {
+		return nil, nil, response.InvalidPageRequest
+	}
+	if size > page.MaxPageSize {
+		return nil, nil, response.PageSizeExceedsMaxLimit
+	}
+
+	db, err := GetDB(ctx, r.connectionId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Resolve the keyset column from entity schema
+	stmt := &gorm.Statement{DB: db}
+	if err = stmt.Parse(new(T)); err != nil {
+		log.Errorf(ctx, err, "Failed to parse entity schema, type=%T", new(T))
+		return nil, nil, err
+	}
+	var field *schema.Field
+	if cursorRequest.Column == str.Empty {
+		field = stmt.Schema.PrioritizedPrimaryField
+	} else {
+		field = stmt.Schema.LookUpField(cursorRequest.Column)
+	}
+	if field == nil || field.DBName == str.Empty {
+		log.Errorf(ctx, response.InvalidArgument, "Unknown cursor column, type=%T, column=%s", new(T), cursorRequest.Column)
+		return nil, nil, response.InvalidArgument
+	}
+	column := clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+
+	// Keyset order is put before the ones from specifications, so it always becomes the primary sort
+	tx := db.Order(clause.OrderByColumn{Column: column, Desc: cursorRequest.Desc})
+	if cursorRequest.Cursor != str.Empty {
+		value, err := decodeCursor(cursorRequest.Cursor, field)
+		if err != nil {
+			log.Errorf(ctx, err, "Failed to decode cursor, cursor=%s", cursorRequest.Cursor)
+			return nil, nil, response.InvalidArgument
+		}
+		if cursorRequest.Desc {
+			tx = tx.Where(clause.Lt{Column: column, Value: value})
+		} else {
+			tx = tx.Where(clause.Gt{Column: column, Value: value})
+		}
+	}
+
+	// Fetch one more record to know whether next page exists
+	entities := make([]*T, integer.Zero)
+	err = tx.Scopes(toScopes(specs)...).Limit(size + integer.One).Find(&entities).Error
+	if err != nil {
+		log.Errorf(ctx, err, "Failed to find entities by cursor, type=%T, cursor=%s", new(T), cursorRequest.Cursor)
+		return nil, nil, err
+	}
+
+	cursorResponse := &CursorResponse{}
+	if len(entities) > size {
+		entities = entities[:size]
+		value, _ := field.ValueOf(ctx, reflect.ValueOf(entities[size-integer.One]))
+		cursorResponse.NextCursor, err = encodeCursor(value)
+		if err != nil {
+			log.Errorf(ctx, err, "Failed to encode cursor, type=%T, column=%s", new(T), field.DBName)
+			return nil, nil, err
+		}
+		cursorResponse.HasNext = true
+	}
+	return entities, cursorResponse, nil
+}
+
+func (r *repositoryImpl[T]) Count(ctx context.Context, specs ...Specification) (int64, error) {
+	ctx, span := otel.Trace(ctx, spanCount)
+	defer span.End()
+
+	db, err := GetDB(ctx, r.connectionId)
+	if err != nil {
+		return integer.Zero, err
+	}
+
+	var total int64
+	err = db.Model(new(T)).Scopes(toScopes(specs)...).Count(&total).Error
+	if err != nil {
+		log.Errorf(ctx, err, "Failed to count entities, type=%T", new(T))
+		return integer.Zero, err
+	}
+	return total, nil
+}
+
+func (r *repositoryImpl[T]) Exists(ctx context.Context, specs ...Specification) (bool, error) {
+	total, err := r.Count(ctx, specs...)
+	if err != nil {
+		return false, err
+	}
+	return total > integer.Zero, nil
+}
+
+func (r *repositoryImpl[T]) Create(ctx context.Context, entities ...*T) error {
+	ctx, span := otel.Trace(ctx, spanCreate)
+	defer span.End()
+
+	if len(entities) == integer.Zero {
+		return nil
+	}
+
+	db, err := GetDB(ctx, r.connectionId)
+	if err != nil {
+		return err
+	}
+
+	if len(entities) == integer.One {
+		err = db.Create(entities[integer.Zero]).Error
+	} else {
+		err = db.Create(entities).Error
+	}
+	if err != nil {
+		log.Errorf(ctx, err, "Failed to create entities, type=%T, count=%d", new(T), len(entities))
+		return err
+	}
+	return nil
+}
+
+func (r *repositoryImpl[T]) Update(ctx context.Context, entity *T) error {
+	ctx, span := otel.Trace(ctx, spanUpdate)
+	defer span.End()
+
+	db, err := GetDB(ctx, r.connectionId)
+	if err != nil {
+		return err
+	}
+
+	err = db.Save(entity).Error
+	if err != nil {
+		log.Errorf(ctx, err, "Failed to update entity, type=%T", entity)
+		return err
+	}
+	return nil
+}
+
+func (r *repositoryImpl[T]) Delete(ctx context.Context, entity *T) error {
+	ctx, span := otel.Trace(ctx, spanDelete)
+	defer span.End()
+
+	db, err := GetDB(ctx, r.connectionId)
+	if err != nil {
+		return err
+	}
+
+	err = db.Delete(entity).Error
+	if err != nil {
+		log.Errorf(ctx, err, "Failed to delete entity, type=%T", entity)
+		return err
+	}
+	return nil
+}
+
+func (r *repositoryImpl[T]) DeleteByID(ctx context.Context, id interface{}) error {
+	ctx, span := otel.Trace(ctx, spanDeleteByID)
+	defer span.End()
+
+	db, err := GetDB(ctx, r.connectionId)
+	if err != nil {
+		return err
+	}
+
+	result := db.Where(clause.Eq{Column: primaryKeyColumn, Value: id}).Delete(new(T))
+	if result.Error != nil {
+		log.Errorf(ctx, result.Error, "Failed to delete entity by ID, type=%T, id=%v", new(T), id)
+		return result.Error
+	}
+	if result.RowsAffected == integer.Zero {
+		return response.DataNotFound
+	}
+	return nil
+}
+
+func (r *repositoryImpl[T]) HardDelete(ctx context.Context, entity *T) error {
+	ctx, span := otel.Trace(ctx, spanHardDelete)
+	defer span.End()
+
+	db, err := GetDB(ctx, r.connectionId)
+	if err != nil {
+		return err
+	}
+
+	err = db.Unscoped().Delete(entity).Error
+	if err != nil {
+		log.Errorf(ctx, err, "Failed to hard delete entity, type=%T", entity)
+		return err
+	}
+	return nil
+}
+
+func toScopes(specs []Specification) []func(*gorm.DB) *gorm.DB {
+	scopes := make([]func(*gorm.DB) *gorm.DB, len(specs))
+	for i, spec := range specs {
+		scopes[i] = spec
+	}
+	return scopes
+}
+
+func encodeCursor(value interface{}) (string, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return str.Empty, err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeCursor decodes cursor into the keyset field type, so it is sent to database with the right type
+func decodeCursor(cursor string, field *schema.Field) (interface{}, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	value := reflect.New(field.FieldType)
+	if err = json.Unmarshal(b, value.Interface()); err != nil {
+		return nil, err
+	}
+	return value.Elem().Interface(), nil
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rosaekapratama/go-starter/page"
+	"github.com/rosaekapratama/go-starter/response"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"regexp"
+	"testing"
+)
+
+type testEntity struct {
+	ID   int64
+	Name string
+}
+
+type RepositoryTestSuite struct {
+	suite.Suite
+	oriManager IManager
+	mockSqlDB  sqlmock.Sqlmock
+	repository Repository[testEntity]
+}
+
+func (s *RepositoryTestSuite) SetupTest() {
+	var sqlDB *sql.DB
+	var err error
+	sqlDB, s.mockSqlDB, err = sqlmock.New()
+	s.Require().NoError(err)
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	s.Require().NoError(err)
+
+	s.oriManager = Manager
+	Manager = &managerImpl{
+		gormDBMap: map[string]*gorm.DB{"test": gormDB},
+		sqlDBMap:  map[string]*sql.DB{"test": sqlDB},
+	}
+	ctx = context.Background()
+	s.repository = NewRepository[testEntity]("test")
+}
+
+func (s *RepositoryTestSuite) TearDownTest() {
+	Manager = s.oriManager
+	s.Require().NoError(s.mockSqlDB.ExpectationsWereMet())
+}
+
+func TestRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(RepositoryTestSuite))
+}
+
+func (s *RepositoryTestSuite) TestFindByIDNotFound() {
+	s.mockSqlDB.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "test_entities" WHERE "test_entities"."id" = $1 LIMIT $2`)).
+		WithArgs(int64(1), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	entity, err := s.repository.FindByID(ctx, int64(1))
+	s.Nil(entity)
+	s.ErrorIs(err, response.DataNotFound)
+}
+
+func (s *RepositoryTestSuite) TestFindPage() {
+	s.mockSqlDB.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "test_entities" WHERE "name" = $1`)).
+		WithArgs("john").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	s.mockSqlDB.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "test_entities" WHERE "name" = $1 ORDER BY "id" DESC LIMIT $2 OFFSET $3`)).
+		WithArgs("john", 2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "john"))
+
+	entities, pageResponse, err := s.repository.FindPage(ctx, page.NewPageRequest(2, 2), Equal("name", "john"), Sort("-id"))
+	s.NoError(err)
+	s.Len(entities, 1)
+	s.Equal(&page.PageResponse{PrevPage: 1, NextPage: 0, TotalPage: 2, TotalItem: 3}, pageResponse)
+}
+
+func (s *RepositoryTestSuite) TestFindByCursor() {
+	s.mockSqlDB.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "test_entities" ORDER BY "test_entities"."id" LIMIT $1`)).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a").AddRow(2, "b").AddRow(3, "c"))
+
+	entities, cursorResponse, err := s.repository.FindByCursor(ctx, &CursorRequest{Size: 2})
+	s.NoError(err)
+	s.Len(entities, 2)
+	s.True(cursorResponse.HasNext)
+
+	s.mockSqlDB.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "test_entities" WHERE "test_entities"."id" > $1 ORDER BY "test_entities"."id" LIMIT $2`)).
+		WithArgs(int64(2), 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "c"))
+
+	entities, cursorResponse, err = s.repository.FindByCursor(ctx, &CursorRequest{Cursor: cursorResponse.NextCursor, Size: 2})
+	s.NoError(err)
+	s.Len(entities, 1)
+	s.False(cursorResponse.HasNext)
+	s.Empty(cursorResponse.NextCursor)
+}
+
+func (s *RepositoryTestSuite) TestTransactionRollback() {
+	s.mockSqlDB.ExpectBegin()
+	s.mockSqlDB.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "test_entities" ("name") VALUES ($1) RETURNING "id"`)).
+		WithArgs("john").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mockSqlDB.ExpectRollback()
+
+	err := Transaction(ctx, "test", func(ctx context.Context) error {
+		_, exists := TxFromContext(ctx, "test")
+		s.True(exists)
+		s.NoError(s.repository.Create(ctx, &testEntity{Name: "john"}))
+		return response.GeneralError
+	})
+	s.ErrorIs(err, response.GeneralError)
+}
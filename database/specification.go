@@ -0,0 +1,104 @@
+package database
+
+import (
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/constant/sym"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+)
+
+// Where filters query with raw condition, same as gorm.DB Where
+func Where(query interface{}, args ...interface{}) Specification {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(query, args...)
+	}
+}
+
+// Equal filters column equals to value, nil value is translated to IS NULL
+func Equal(column string, value interface{}) Specification {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Eq{Column: clause.Column{Name: column}, Value: value})
+	}
+}
+
+// NotEqual filters column not equals to value, nil value is translated to IS NOT NULL
+func NotEqual(column string, value interface{}) Specification {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Neq{Column: clause.Column{Name: column}, Value: value})
+	}
+}
+
+func GreaterThan(column string, value interface{}) Specification {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Gt{Column: clause.Column{Name: column}, Value: value})
+	}
+}
+
+func GreaterThanOrEqual(column string, value interface{}) Specification {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Gte{Column: clause.Column{Name: column}, Value: value})
+	}
+}
+
+func LessThan(column string, value interface{}) Specification {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Lt{Column: clause.Column{Name: column}, Value: value})
+	}
+}
+
+func LessThanOrEqual(column string, value interface{}) Specification {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Lte{Column: clause.Column{Name: column}, Value: value})
+	}
+}
+
+// Like filters column with LIKE pattern, ex: Like("name", "%john%")
+func Like(column string, pattern string) Specification {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Like{Column: clause.Column{Name: column}, Value: pattern})
+	}
+}
+
+func In(column string, values ...interface{}) Specification {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.IN{Column: clause.Column{Name: column}, Values: values})
+	}
+}
+
+// OrderBy sorts query result by column
+func OrderBy(column string, desc bool) Specification {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: desc})
+	}
+}
+
+// Sort sorts query result by list of column, column with hyphen prefix is sorted descending,
+// ex: Sort("-created_at", "name")
+func Sort(columns ...string) Specification {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, column := range columns {
+			column = strings.TrimSpace(column)
+			if column == str.Empty {
+				continue
+			}
+			desc := strings.HasPrefix(column, sym.Hyphen)
+			db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: strings.TrimPrefix(column, sym.Hyphen)}, Desc: desc})
+		}
+		return db
+	}
+}
+
+// Preload eager loads the given association, same as gorm.DB Preload
+func Preload(query string, args ...interface{}) Specification {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Preload(query, args...)
+	}
+}
+
+// WithDeleted includes soft deleted records into query result
+func WithDeleted() Specification {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}
+}
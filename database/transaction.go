@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/response"
+	"gorm.io/gorm"
+)
+
+const txKeyFormat = "gormTx:%s"
+
+// ContextWithTx returns a copy of parent context which carries running transaction of the given connection ID
+func ContextWithTx(parentContext context.Context, connectionId string, tx *gorm.DB) context.Context {
+	return context.WithValue(parentContext, fmt.Sprintf(txKeyFormat, connectionId), tx)
+}
+
+// TxFromContext returns running transaction of the given connection ID if exists
+func TxFromContext(ctx context.Context, connectionId string) (tx *gorm.DB, exists bool) {
+	tx, exists = ctx.Value(fmt.Sprintf(txKeyFormat, connectionId)).(*gorm.DB)
+	return
+}
+
+// GetDB returns running transaction from context if exists,
+// otherwise returns *gorm.DB of the given connection ID, both are bound to ctx
+func GetDB(ctx context.Context, connectionId string) (*gorm.DB, error) {
+	if tx, exists := TxFromContext(ctx, connectionId); exists {
+		return tx.WithContext(ctx), nil
+	}
+
+	if Manager == nil {
+		log.Error(ctx, response.DBConnIdNotFound, errDatabaseManagerIsDisabled)
+		return nil, response.DBConnIdNotFound
+	}
+
+	gormDB, _, err := Manager.DB(ctx, connectionId)
+	if err != nil {
+		return nil, err
+	}
+	return gormDB.WithContext(ctx), nil
+}
+
+// Transaction runs fn inside a transaction of the given connection ID,
+// the transaction is committed if fn returns nil and rolled back otherwise.
+// Context passed to fn carries the transaction, so every repository call using it joins the same transaction,
+// nested call creates a savepoint inside the running transaction.
+func Transaction(ctx context.Context, connectionId string, fn func(ctx context.Context) error) error {
+	db, err := GetDB(ctx, connectionId)
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		return fn(ContextWithTx(ctx, connectionId, tx))
+	})
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"github.com/rosaekapratama/go-starter/page"
 	"gorm.io/gorm"
 )
 
@@ -16,3 +17,42 @@ type managerImpl struct {
 	gormDBMap map[string]*gorm.DB
 	sqlDBMap  map[string]*sql.DB
 }
+
+// Specification is a GORM scope used to filter, sort or preload repository queries
+type Specification func(db *gorm.DB) *gorm.DB
+
+type Repository[T any] interface {
+	// FindByID returns entity with the given primary key, or response.DataNotFound if not exists
+	FindByID(ctx context.Context, id interface{}, specs ...Specification) (*T, error)
+	// FindAll returns all entities matched the given specifications
+	FindAll(ctx context.Context, specs ...Specification) ([]*T, error)
+	// FindPage returns entities of the requested page with its pagination info
+	FindPage(ctx context.Context, pageRequest *page.PageRequest, specs ...Specification) ([]*T, *page.PageResponse, error)
+	// FindByCursor returns entities after the given cursor using keyset pagination
+	FindByCursor(ctx context.Context, cursorRequest *CursorRequest, specs ...Specification) ([]*T, *CursorResponse, error)
+	Count(ctx context.Context, specs ...Specification) (int64, error)
+	Exists(ctx context.Context, specs ...Specification) (bool, error)
+	Create(ctx context.Context, entities ...*T) error
+	Update(ctx context.Context, entity *T) error
+	// Delete soft deletes entity if it has gorm.DeletedAt field, otherwise it is permanently deleted
+	Delete(ctx context.Context, entity *T) error
+	DeleteByID(ctx context.Context, id interface{}) error
+	// HardDelete permanently deletes entity even if it has gorm.DeletedAt field
+	HardDelete(ctx context.Context, entity *T) error
+}
+
+type repositoryImpl[T any] struct {
+	connectionId string
+}
+
+type CursorRequest struct {
+	Cursor string // Cursor returned by previous page, empty for the first page
+	Size   int    // Indicates item count in a single page
+	Column string // Unique and sortable column used as the keyset, default is primary key
+	Desc   bool   // Iterate from the greatest key value if true
+}
+
+type CursorResponse struct {
+	NextCursor string `json:"nextCursor,omitempty"` // Empty if there is no next page
+	HasNext    bool   `json:"hasNext"`
+}
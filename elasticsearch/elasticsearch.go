@@ -2,11 +2,13 @@ package elasticsearch
 
 import (
 	"context"
+	"fmt"
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/inhies/go-bytesize"
 	"github.com/rosaekapratama/go-starter/config"
 	"github.com/rosaekapratama/go-starter/constant/integer"
 	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/healthcheck"
 	"github.com/rosaekapratama/go-starter/log"
 	"github.com/rosaekapratama/go-starter/response"
 )
@@ -16,6 +18,7 @@ var (
 	defaultPayloadLogSizeLimit = 2 * bytesize.KB
 
 	errMissingElasticSearchAddressConfig = "missing elastic search addresses config"
+	healthCheckNameFormat                = "elasticsearch:%s"
 
 	Manager IManager
 )
@@ -65,6 +68,19 @@ func Init(ctx context.Context, config config.Config) {
 			return
 		}
 		clients[clientId] = client
+
+		// Add health checker
+		healthcheck.AddCheckerWithConfig(fmt.Sprintf(healthCheckNameFormat, clientId), cfg.HealthCheck, func(ctx context.Context) error {
+			res, err := client.Ping(client.Ping.WithContext(ctx))
+			if err != nil {
+				return err
+			}
+			defer res.Body.Close()
+			if res.IsError() {
+				return fmt.Errorf("ping %s", res.Status())
+			}
+			return nil
+		})
 		log.Infof(ctx, "Elastic search is initiated, instanceId=%s, address=%v", clientId, cfg.Addresses)
 	}
 	Manager = &managerImpl{clients: clients}
@@ -3,11 +3,17 @@ package pubsub
 import (
 	"cloud.google.com/go/pubsub"
 	"context"
+	"errors"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/healthcheck"
 	"github.com/rosaekapratama/go-starter/log"
 	"golang.org/x/oauth2/google"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
+const healthCheckName = "pubsub"
+
 func NewClient(ctx context.Context, credentials *google.Credentials) (client *pubsub.Client) {
 	var err error
 	client, err = pubsub.NewClient(ctx, credentials.ProjectID, option.WithCredentials(credentials))
@@ -15,5 +21,18 @@ func NewClient(ctx context.Context, credentials *google.Credentials) (client *pu
 		log.Fatal(ctx, err, "Failed to create google pubsub client")
 	}
 	log.Info(ctx, "Google pub/sub client service is initiated")
+
+	// Add health checker
+	var healthCheckConfig *config.HealthCheckConfig
+	if cfg := config.Instance.GetObject().Google; cfg != nil && cfg.Cloud != nil && cfg.Cloud.Pubsub != nil {
+		healthCheckConfig = cfg.Cloud.Pubsub.HealthCheck
+	}
+	healthcheck.AddCheckerWithConfig(healthCheckName, healthCheckConfig, func(ctx context.Context) error {
+		_, err := client.Topics(ctx).Next()
+		if err != nil && !errors.Is(err, iterator.Done) {
+			return err
+		}
+		return nil
+	})
 	return
 }
@@ -0,0 +1,439 @@
+package outbox
+
+import (
+	"cloud.google.com/go/pubsub"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	myContext "github.com/rosaekapratama/go-starter/context"
+	"github.com/rosaekapratama/go-starter/database"
+	myPubsub "github.com/rosaekapratama/go-starter/google/cloud/pubsub"
+	"github.com/rosaekapratama/go-starter/google/cloud/pubsub/publisher"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/otel"
+	"github.com/rosaekapratama/go-starter/response"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	spanStore   = "common.google.cloud.pubsub.outbox.Store %s"
+	spanRelay   = "common.google.cloud.pubsub.outbox.Relay"
+	spanCleanup = "common.google.cloud.pubsub.outbox.Cleanup"
+
+	defaultTable           = "outbox_messages"
+	defaultBatchSize       = 100
+	defaultPollInterval    = time.Second
+	defaultMaxAttempts     = 10
+	defaultMinRetryBackoff = time.Second
+	defaultMaxRetryBackoff = 5 * time.Minute
+	defaultRetention       = 7 * 24 * time.Hour
+	defaultLease           = time.Minute
+	cleanupInterval        = time.Hour
+
+	// Message is only picked if no older pending or in progress message has the same ordering key,
+	// so messages of one ordering key are never published out of order even by concurrent relays
+	orderingKeyCondition = "ordering_key = '' OR NOT EXISTS (SELECT 1 FROM %[1]s o WHERE o.ordering_key = %[1]s.ordering_key AND o.status IN ? AND o.created_dt < %[1]s.created_dt)"
+)
+
+var (
+	Manager IManager
+
+	// Table name is put into raw SQL, so only plain or schema qualified identifier is allowed
+	tableNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+	// unsettledStatuses are statuses of messages which are not published yet and may be picked by relay
+	unsettledStatuses = []Status{StatusPending, StatusInProgress}
+)
+
+// Init Initiate outbox manager, it requires database package to be initiated first
+func Init(ctx context.Context, config config.Config, client *pubsub.Client) {
+	cfg := config.GetObject().Google
+	if cfg == nil || cfg.Cloud == nil || cfg.Cloud.Pubsub == nil || cfg.Cloud.Pubsub.Outbox == nil ||
+		cfg.Cloud.Pubsub.Outbox.Disabled || cfg.Cloud.Pubsub.Outbox.Database == str.Empty {
+		log.Warn(ctx, "Pub/Sub outbox is disabled")
+		return
+	}
+	outboxCfg := cfg.Cloud.Pubsub.Outbox
+
+	if client == nil {
+		log.Fatal(ctx, response.InitFailed, "Pub/Sub outbox requires google pub/sub client")
+		return
+	}
+
+	if outboxCfg.Table != str.Empty && !tableNameRegex.MatchString(outboxCfg.Table) {
+		log.Fatalf(ctx, response.InitFailed, "Invalid outbox table name '%s'", outboxCfg.Table)
+		return
+	}
+
+	if _, err := database.GetDB(ctx, outboxCfg.Database); err != nil {
+		log.Fatalf(ctx, err, "Failed to find outbox database ID '%s'", outboxCfg.Database)
+		return
+	}
+
+	m := newManager(client, outboxCfg)
+	Manager = m
+	log.Infof(ctx, "Pub/Sub outbox is initiated, database=%s, table=%s", m.connectionId, m.table)
+}
+
+func newManager(client *pubsub.Client, cfg *config.GoogleCloudPubsubOutboxConfig) *managerImpl {
+	m := &managerImpl{
+		client:          client,
+		connectionId:    cfg.Database,
+		table:           defaultTable,
+		batchSize:       defaultBatchSize,
+		pollInterval:    defaultPollInterval,
+		maxAttempts:     defaultMaxAttempts,
+		minRetryBackoff: defaultMinRetryBackoff,
+		maxRetryBackoff: defaultMaxRetryBackoff,
+		retention:       defaultRetention,
+		lease:           defaultLease,
+		publishers:      make(map[string]publisher.Publisher),
+	}
+	if cfg.Table != str.Empty {
+		m.table = cfg.Table
+	}
+	if cfg.BatchSize > integer.Zero {
+		m.batchSize = cfg.BatchSize
+	}
+	if cfg.PollInterval != nil && cfg.PollInterval.Duration > integer.Zero {
+		m.pollInterval = cfg.PollInterval.Duration
+	}
+	if cfg.MaxAttempts > integer.Zero {
+		m.maxAttempts = cfg.MaxAttempts
+	}
+	if cfg.MinRetryBackoff != nil && cfg.MinRetryBackoff.Duration > integer.Zero {
+		m.minRetryBackoff = cfg.MinRetryBackoff.Duration
+	}
+	if cfg.MaxRetryBackoff != nil && cfg.MaxRetryBackoff.Duration > integer.Zero {
+		m.maxRetryBackoff = cfg.MaxRetryBackoff.Duration
+	}
+	if cfg.Retention != nil && cfg.Retention.Duration > integer.Zero {
+		m.retention = cfg.Retention.Duration
+	}
+	if cfg.Lease != nil && cfg.Lease.Duration > integer.Zero {
+		m.lease = cfg.Lease.Duration
+	}
+	m.newPublisher = func(topicId string) publisher.Publisher {
+		return publisher.NewPublisher(m.client, topicId, publisher.WithMessageOrdering())
+	}
+	return m
+}
+
+// Store saves message into outbox table, data type of []byte, string, int and bool is stored as is,
+// other types are encoded as JSON, encode AVRO or protobuf message beforehand and pass it as []byte.
+// Example to use
+//
+//	err := database.Transaction(ctx, "pgsql1", func(ctx context.Context) error {
+//		if err := orderRepository.Create(ctx, order); err != nil {
+//			return err
+//		}
+//		return outbox.Manager.Store(ctx, "order-created", order, publisher.WithOrderingKey(order.CustomerId))
+//	})
+func (m *managerImpl) Store(ctx context.Context, topicId string, data interface{}, opts ...publisher.PublishOption) error {
+	ctx, span := otel.Trace(ctx, fmt.Sprintf(spanStore, topicId))
+	defer span.End()
+
+	bytes, err := encode(data)
+	if err != nil {
+		log.Errorf(ctx, err, "[Pub/Sub] Failed to encode outbox message, topicId=%s, type=%T", topicId, data)
+		return err
+	}
+
+	// Apply options to a draft message, to capture its attributes and ordering key
+	draft := &pubsub.Message{
+		Attributes: map[string]string{
+			myPubsub.TraceparentAttrKey:       myContext.TraceParentFromContext(ctx),
+			myPubsub.OriginPublishTimeAttrKey: time.Now().Format(time.RFC3339),
+		},
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.Apply(draft)
+		}
+	}
+	attributes, err := json.Marshal(draft.Attributes)
+	if err != nil {
+		log.Errorf(ctx, err, "[Pub/Sub] Failed to marshal outbox message attributes, topicId=%s", topicId)
+		return err
+	}
+
+	db, err := database.GetDB(ctx, m.connectionId)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	message := &Message{
+		ID:            uuid.New(),
+		TopicId:       topicId,
+		OrderingKey:   draft.OrderingKey,
+		Data:          bytes,
+		Attributes:    attributes,
+		Status:        StatusPending,
+		NextAttemptDT: now,
+		CreatedDT:     now,
+	}
+	err = db.Table(m.table).Create(message).Error
+	if err != nil {
+		log.Errorf(ctx, err, "[Pub/Sub] Failed to store outbox message, topicId=%s", topicId)
+		return err
+	}
+	return nil
+}
+
+// Relay claims due messages, publishes them in creation order outside of database transaction,
+// then updates their status, so row locks are never held while waiting for Pub/Sub
+func (m *managerImpl) Relay(ctx context.Context) (processed int, err error) {
+	ctx, span := otel.Trace(ctx, spanRelay)
+	defer span.End()
+
+	db, err := database.GetDB(ctx, m.connectionId)
+	if err != nil {
+		return integer.Zero, err
+	}
+
+	messages, err := m.claim(ctx, db)
+	if err != nil || len(messages) == integer.Zero {
+		return integer.Zero, err
+	}
+
+	updates := make([]map[string]interface{}, len(messages))
+	for i, message := range messages {
+		updates[i] = m.publish(ctx, message)
+	}
+
+	// Message whose update fails is claimed and published again once its lease expires
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for i, message := range messages {
+			if err := tx.Table(m.table).Where("id = ?", message.ID).Updates(updates[i]).Error; err != nil {
+				log.Errorf(ctx, err, "[Pub/Sub] Failed to update outbox message, id=%s", message.ID)
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return integer.Zero, err
+	}
+	return len(messages), nil
+}
+
+// claim locks due messages with SELECT ... FOR UPDATE SKIP LOCKED and marks them as in progress until lease expires,
+// so relays on multiple instances never pick the same message, message of crashed relay is picked again after its lease
+func (m *managerImpl) claim(ctx context.Context, db *gorm.DB) ([]*Message, error) {
+	messages := make([]*Message, integer.Zero)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Table(m.table).
+			Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked}).
+			Where("status IN ? AND next_attempt_dt <= ?", unsettledStatuses, now).
+			Where(fmt.Sprintf(orderingKeyCondition, m.table), unsettledStatuses).
+			Order("created_dt").
+			Limit(m.batchSize).
+			Find(&messages).Error
+		if err != nil {
+			log.Error(ctx, err, "[Pub/Sub] Failed to find due outbox messages")
+			return err
+		}
+		if len(messages) == integer.Zero {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(messages))
+		for i, message := range messages {
+			ids[i] = message.ID
+		}
+		err = tx.Table(m.table).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":          StatusInProgress,
+			"next_attempt_dt": now.Add(m.lease),
+		}).Error
+		if err != nil {
+			log.Error(ctx, err, "[Pub/Sub] Failed to claim outbox messages")
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// publish publishes message and returns the outbox columns to update based on its result
+func (m *managerImpl) publish(ctx context.Context, message *Message) map[string]interface{} {
+	attributes := make(map[string]string)
+	if len(message.Attributes) > integer.Zero {
+		if err := json.Unmarshal(message.Attributes, &attributes); err != nil {
+			log.Warnf(ctx, "[Pub/Sub] Failed to unmarshal outbox message attributes, id=%s, error=%v", message.ID, err)
+		}
+	}
+
+	// Continue the trace of the one which stored the message,
+	// traceparent attribute itself is regenerated by publisher from this context
+	publishCtx := ctx
+	if traceparent, ok := attributes[myPubsub.TraceparentAttrKey]; ok && traceparent != str.Empty {
+		publishCtx = myContext.ContextWithTraceParent(ctx, traceparent)
+	}
+	opts := make([]publisher.PublishOption, integer.Zero, len(attributes)+integer.One)
+	for key, value := range attributes {
+		if key != myPubsub.TraceparentAttrKey {
+			opts = append(opts, publisher.WithAttribute(key, value))
+		}
+	}
+	if message.OrderingKey != str.Empty {
+		opts = append(opts, publisher.WithOrderingKey(message.OrderingKey))
+	}
+
+	attempts := message.Attempts + integer.One
+	_, err := m.getPublisher(message.TopicId).Publish(publishCtx, message.Data, opts...)
+	if err == nil {
+		return map[string]interface{}{
+			"status":       StatusPublished,
+			"attempts":     attempts,
+			"last_error":   nil,
+			"published_dt": time.Now(),
+		}
+	}
+
+	updates := map[string]interface{}{
+		"status":     StatusPending,
+		"attempts":   attempts,
+		"last_error": err.Error(),
+	}
+	if attempts >= m.maxAttempts {
+		log.Errorf(ctx, err, "[Pub/Sub] Outbox message exceeds max attempts and is marked as failed, id=%s, topicId=%s, attempts=%d", message.ID, message.TopicId, attempts)
+		updates["status"] = StatusFailed
+	} else {
+		log.Warnf(ctx, "[Pub/Sub] Failed to publish outbox message, will be retried, id=%s, topicId=%s, attempts=%d, error=%v", message.ID, message.TopicId, attempts, err)
+		updates["next_attempt_dt"] = time.Now().Add(m.backoff(attempts))
+	}
+	return updates
+}
+
+// backoff returns exponential backoff of the given attempt, capped by max retry backoff
+func (m *managerImpl) backoff(attempts int) time.Duration {
+	backoff := m.minRetryBackoff
+	for i := integer.One; i < attempts && backoff < m.maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > m.maxRetryBackoff {
+		backoff = m.maxRetryBackoff
+	}
+	return backoff
+}
+
+func (m *managerImpl) getPublisher(topicId string) publisher.Publisher {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.publishers[topicId]
+	if !ok {
+		p = m.newPublisher(topicId)
+		m.publishers[topicId] = p
+	}
+	return p
+}
+
+func (m *managerImpl) Cleanup(ctx context.Context) error {
+	ctx, span := otel.Trace(ctx, spanCleanup)
+	defer span.End()
+
+	db, err := database.GetDB(ctx, m.connectionId)
+	if err != nil {
+		return err
+	}
+
+	result := db.Table(m.table).
+		Where("status = ? AND published_dt < ?", StatusPublished, time.Now().Add(-m.retention)).
+		Delete(&Message{})
+	if result.Error != nil {
+		log.Error(ctx, result.Error, "[Pub/Sub] Failed to clean up outbox messages")
+		return result.Error
+	}
+	if result.RowsAffected > integer.Zero {
+		log.Debugf(ctx, "[Pub/Sub] Outbox messages are cleaned up, count=%d", result.RowsAffected)
+	}
+	return nil
+}
+
+func (m *managerImpl) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stop != nil {
+		return
+	}
+	m.stop = make(chan struct{})
+
+	m.wg.Add(integer.One)
+	go m.run(m.stop)
+	log.Infof(context.Background(), "Pub/Sub outbox relay is started, pollInterval=%s", m.pollInterval)
+}
+
+func (m *managerImpl) run(stop chan struct{}) {
+	defer m.wg.Done()
+
+	pollTicker := time.NewTicker(m.pollInterval)
+	defer pollTicker.Stop()
+	cleanupTicker := time.NewTicker(cleanupInterval)
+	defer cleanupTicker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-cleanupTicker.C:
+			_ = m.Cleanup(context.Background())
+		case <-pollTicker.C:
+			// Keep relaying while there are messages, next message of the same ordering key
+			// is only picked after the previous one is published
+			for {
+				processed, err := m.Relay(context.Background())
+				if err != nil || processed == integer.Zero {
+					break
+				}
+				select {
+				case <-stop:
+					return
+				default:
+				}
+			}
+		}
+	}
+}
+
+func (m *managerImpl) Stop() {
+	m.mu.Lock()
+	stop := m.stop
+	m.stop = nil
+	m.mu.Unlock()
+	if stop == nil {
+		return
+	}
+
+	close(stop)
+	m.wg.Wait()
+	log.Info(context.Background(), "Pub/Sub outbox relay is stopped")
+}
+
+func encode(data interface{}) ([]byte, error) {
+	switch data := data.(type) {
+	case nil:
+		return nil, response.InvalidArgument
+	case []byte:
+		return data, nil
+	case string:
+		return []byte(data), nil
+	case int:
+		return []byte(strconv.Itoa(data)), nil
+	case bool:
+		return []byte(strconv.FormatBool(data)), nil
+	default:
+		return json.Marshal(data)
+	}
+}
@@ -0,0 +1,129 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/database"
+	"github.com/rosaekapratama/go-starter/google/cloud/pubsub/publisher"
+	mocksDatabase "github.com/rosaekapratama/go-starter/mocks/database"
+	mocksPublisher "github.com/rosaekapratama/go-starter/mocks/google/cloud/pubsub/publisher"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"regexp"
+	"testing"
+)
+
+var ctx context.Context
+
+type OutboxTestSuite struct {
+	suite.Suite
+	oriManager    database.IManager
+	mockSqlDB     sqlmock.Sqlmock
+	mockPublisher *mocksPublisher.MockPublisher
+	manager       *managerImpl
+}
+
+func (s *OutboxTestSuite) SetupTest() {
+	var sqlDB *sql.DB
+	var err error
+	sqlDB, s.mockSqlDB, err = sqlmock.New()
+	s.Require().NoError(err)
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	s.Require().NoError(err)
+
+	s.oriManager = database.Manager
+	mockManager := &mocksDatabase.MockIManager{}
+	mockManager.On("DB", mock.Anything, "test").Return(gormDB, sqlDB, nil)
+	database.Manager = mockManager
+
+	ctx = context.Background()
+	s.mockPublisher = &mocksPublisher.MockPublisher{}
+	s.manager = newManager(nil, &config.GoogleCloudPubsubOutboxConfig{Database: "test"})
+	s.manager.newPublisher = func(_ string) publisher.Publisher {
+		return s.mockPublisher
+	}
+}
+
+func (s *OutboxTestSuite) TearDownTest() {
+	database.Manager = s.oriManager
+	s.Require().NoError(s.mockSqlDB.ExpectationsWereMet())
+}
+
+func TestOutboxTestSuite(t *testing.T) {
+	suite.Run(t, new(OutboxTestSuite))
+}
+
+func (s *OutboxTestSuite) TestStore() {
+	s.mockSqlDB.ExpectBegin()
+	s.mockSqlDB.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox_messages"`)).
+		WithArgs(sqlmock.AnyArg(), "order-created", "customer-1", []byte(`{"id":1}`), sqlmock.AnyArg(), StatusPending, 0, nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"next_attempt_dt", "created_dt"}))
+	s.mockSqlDB.ExpectCommit()
+
+	err := s.manager.Store(ctx, "order-created", map[string]int{"id": 1}, publisher.WithOrderingKey("customer-1"))
+	s.NoError(err)
+}
+
+func (s *OutboxTestSuite) TestRelay() {
+	rows := sqlmock.NewRows([]string{"id", "topic_id", "ordering_key", "data", "attributes", "status", "attempts"}).
+		AddRow("6f1c1a52-3f0e-4d35-8a54-5b0a4c8f3c11", "order-created", "customer-1", []byte("1"), []byte(`{"state":"created"}`), StatusPending, 0).
+		AddRow("0b8e5a43-9d8c-4a3b-9b0e-3c7a2f1e6d22", "order-created", "", []byte("2"), nil, StatusPending, 2)
+
+	// Messages are claimed and committed before publishing
+	s.mockSqlDB.ExpectBegin()
+	s.mockSqlDB.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "outbox_messages" WHERE (status IN ($1,$2) AND next_attempt_dt <= $3) AND (ordering_key = '' OR NOT EXISTS`)).
+		WillReturnRows(rows)
+	s.mockSqlDB.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox_messages" SET "next_attempt_dt"=$1,"status"=$2 WHERE id IN ($3,$4)`)).
+		WithArgs(sqlmock.AnyArg(), StatusInProgress, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mockSqlDB.ExpectCommit()
+
+	s.mockPublisher.EXPECT().Publish(mock.Anything, []byte("1"), mock.Anything, mock.Anything).Return("server-1", nil).Once()
+	s.mockPublisher.EXPECT().Publish(mock.Anything, []byte("2")).Return("", context.DeadlineExceeded).Once()
+
+	// Results are updated in a separate transaction
+	s.mockSqlDB.ExpectBegin()
+	s.mockSqlDB.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox_messages" SET "attempts"=$1,"last_error"=$2,"published_dt"=$3,"status"=$4 WHERE id = $5`)).
+		WithArgs(1, nil, sqlmock.AnyArg(), StatusPublished, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockSqlDB.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox_messages" SET "attempts"=$1,"last_error"=$2,"next_attempt_dt"=$3,"status"=$4 WHERE id = $5`)).
+		WithArgs(3, context.DeadlineExceeded.Error(), sqlmock.AnyArg(), StatusPending, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockSqlDB.ExpectCommit()
+
+	processed, err := s.manager.Relay(ctx)
+	s.NoError(err)
+	s.Equal(2, processed)
+	s.mockPublisher.AssertExpectations(s.T())
+}
+
+func (s *OutboxTestSuite) TestRelayWithoutDueMessage() {
+	s.mockSqlDB.ExpectBegin()
+	s.mockSqlDB.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "outbox_messages"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mockSqlDB.ExpectCommit()
+
+	processed, err := s.manager.Relay(ctx)
+	s.NoError(err)
+	s.Zero(processed)
+}
+
+func (s *OutboxTestSuite) TestTableName() {
+	for _, table := range []string{"outbox_messages", "events.Outbox1"} {
+		s.True(tableNameRegex.MatchString(table), table)
+	}
+	for _, table := range []string{"outbox messages", "outbox;DROP TABLE users", `"outbox"`, "a.b.c", "1outbox"} {
+		s.False(tableNameRegex.MatchString(table), table)
+	}
+}
+
+func (s *OutboxTestSuite) TestBackoff() {
+	s.Equal(defaultMinRetryBackoff, s.manager.backoff(1))
+	s.Equal(4*defaultMinRetryBackoff, s.manager.backoff(3))
+	s.Equal(defaultMaxRetryBackoff, s.manager.backoff(20))
+}
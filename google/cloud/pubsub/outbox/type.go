@@ -0,0 +1,77 @@
+package outbox
+
+import (
+	"cloud.google.com/go/pubsub"
+	"context"
+	"github.com/google/uuid"
+	"github.com/rosaekapratama/go-starter/google/cloud/pubsub/publisher"
+	"gorm.io/datatypes"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusPending    Status = "PENDING"
+	StatusInProgress Status = "IN_PROGRESS" // Claimed by relay, it is picked again if its lease expires
+	StatusPublished  Status = "PUBLISHED"
+	StatusFailed     Status = "FAILED"
+)
+
+type IManager interface {
+	// Store saves message into outbox table, it joins transaction carried by ctx if any,
+	// so the message is only published when the transaction is committed
+	Store(ctx context.Context, topicId string, data interface{}, opts ...publisher.PublishOption) error
+
+	// Relay publishes one batch of due messages and returns number of processed messages,
+	// message may be published more than once if relay crashes before its status is updated
+	Relay(ctx context.Context) (processed int, err error)
+
+	// Cleanup deletes published messages older than retention
+	Cleanup(ctx context.Context) error
+
+	// Start runs relay and cleanup in background until Stop is called
+	Start()
+
+	// Stop stops background relay and waits for the running one to finish
+	Stop()
+}
+
+type managerImpl struct {
+	client          *pubsub.Client
+	connectionId    string
+	table           string
+	batchSize       int
+	pollInterval    time.Duration
+	maxAttempts     int
+	minRetryBackoff time.Duration
+	maxRetryBackoff time.Duration
+	retention       time.Duration
+	lease           time.Duration
+
+	newPublisher func(topicId string) publisher.Publisher
+	publishers   map[string]publisher.Publisher
+	mu           sync.Mutex
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+type Message struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey"`
+	TopicId       string    `gorm:"type:varchar(255);not null"`
+	OrderingKey   string    `gorm:"type:varchar(255);not null;default:''"`
+	Data          []byte
+	Attributes    datatypes.JSON
+	Status        Status     `gorm:"type:varchar(16);not null;index"`
+	Attempts      int        `gorm:"not null;default:0"`
+	LastError     *string    `gorm:"type:text"`
+	NextAttemptDT time.Time  `gorm:"type:timestamptz;not null;default:now()"`
+	CreatedDT     time.Time  `gorm:"type:timestamptz;not null;default:now()"`
+	PublishedDT   *time.Time `gorm:"type:timestamptz"`
+}
+
+func (Message) TableName() string {
+	return defaultTable
+}
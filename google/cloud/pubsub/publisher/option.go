@@ -91,6 +91,10 @@ type stateOption struct {
 	state myPubsub.State
 }
 
+type orderingKeyOption struct {
+	orderingKey string
+}
+
 func (o *attrOption) Apply(message *pubsub.Message) {
 	message.Attributes[o.key] = o.value
 }
@@ -99,6 +103,10 @@ func (o *stateOption) Apply(message *pubsub.Message) {
 	message.Attributes[myPubsub.StateAttrKey] = string(o.state)
 }
 
+func (o *orderingKeyOption) Apply(message *pubsub.Message) {
+	message.OrderingKey = o.orderingKey
+}
+
 func WithAttribute(key string, value string) PublishOption {
 	return &attrOption{
 		key:   key,
@@ -112,6 +120,14 @@ func WithState(state myPubsub.State) PublishOption {
 	}
 }
 
+// WithOrderingKey set message ordering key, messages with the same ordering key are delivered in publish order,
+// topic must be created with WithMessageOrdering option
+func WithOrderingKey(orderingKey string) PublishOption {
+	return &orderingKeyOption{
+		orderingKey: orderingKey,
+	}
+}
+
 type TopicOption interface {
 	Apply(*pubsub.Topic)
 }
@@ -132,6 +148,9 @@ type timeoutOption struct {
 	timeout time.Duration
 }
 
+type messageOrderingOption struct {
+}
+
 func (o *byteThresholdOption) Apply(topic *pubsub.Topic) {
 	topic.PublishSettings.ByteThreshold = o.byteThreshold
 }
@@ -148,6 +167,10 @@ func (o *timeoutOption) Apply(topic *pubsub.Topic) {
 	topic.PublishSettings.Timeout = o.timeout
 }
 
+func (o *messageOrderingOption) Apply(topic *pubsub.Topic) {
+	topic.EnableMessageOrdering = true
+}
+
 func WithByteThreshold(byteThreshold int) TopicOption {
 	return &byteThresholdOption{byteThreshold: byteThreshold}
 }
@@ -163,3 +186,8 @@ func WithDelayThreshold(delayThreshold time.Duration) TopicOption {
 func WithTimeout(timeout time.Duration) TopicOption {
 	return &timeoutOption{timeout: timeout}
 }
+
+// WithMessageOrdering enable message ordering on topic, required to publish message with ordering key
+func WithMessageOrdering() TopicOption {
+	return &messageOrderingOption{}
+}
@@ -98,6 +98,8 @@ func (p *publisherImpl) initMessage(ctx context.Context, data interface{}, opts
 		message.Data = bytes
 	} else {
 		switch data := data.(type) {
+		case []byte:
+			message.Data = data
 		case string:
 			message.Data = []byte(data)
 		case int:
@@ -128,6 +130,11 @@ func (p *publisherImpl) Publish(ctx context.Context, data interface{}, opts ...P
 	// a server-generated ID is returned for the published message.
 	serverId, err = result.Get(ctx)
 
+	// Publishing on an ordering key is paused after a failure until it is resumed
+	if err != nil && message.OrderingKey != str.Empty {
+		p.topic.ResumePublish(message.OrderingKey)
+	}
+
 	if p.logging.Stdout {
 		_payloadLogSizeLimit, err := bytesize.Parse(config.Instance.GetObject().Google.Cloud.Pubsub.Publisher.Logging.PayloadLogSizeLimit)
 		if err != nil {
@@ -3,16 +3,31 @@ package healthcheck
 import (
 	"context"
 	"github.com/etherlabsio/healthcheck/v2"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/constant/integer"
 	"github.com/rosaekapratama/go-starter/constant/sym"
 	"net/http"
+	"sync"
 	"time"
 )
 
-const URLPathRegex = sym.Circumflex + "/v./health" + sym.Dollars
+const (
+	URLPathRegex = sym.Circumflex + "/v./health" + sym.Dollars
 
-var options []healthcheck.Option
+	defaultCheckerTimeout = 3 * time.Second
+	handlerTimeout        = 5 * time.Second
+)
+
+var (
+	mutex   sync.RWMutex
+	names   []string
+	options []healthcheck.Option
+)
 
 func AddChecker(name string, f func(ctx context.Context) error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	names = append(names, name)
 	options = append(
 		options,
 		healthcheck.WithChecker(
@@ -21,8 +36,47 @@ func AddChecker(name string, f func(ctx context.Context) error) {
 		))
 }
 
+// RemoveChecker unregisters every checker of the given name
+func RemoveChecker(name string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	for i := len(names) - integer.One; i >= integer.Zero; i-- {
+		if names[i] == name {
+			names = append(names[:i], names[i+integer.One:]...)
+			options = append(options[:i], options[i+integer.One:]...)
+		}
+	}
+}
+
+// AddCheckerWithConfig register checker f under the given name unless it is disabled by cfg,
+// every check call is bounded by the configured timeout
+func AddCheckerWithConfig(name string, cfg *config.HealthCheckConfig, f func(ctx context.Context) error) {
+	if cfg != nil && cfg.Disabled {
+		return
+	}
+
+	timeout := defaultCheckerTimeout
+	if cfg != nil && cfg.Timeout > integer.Zero {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+	AddChecker(name, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return f(ctx)
+	})
+}
+
+// HandlerV1 returns health check handler, checkers are resolved on every request
+// so the ones registered after the handler is mounted are still included
 func HandlerV1() http.Handler {
-	// WithTimeout allows you to set a max overall timeout.
-	options = append(options, healthcheck.WithTimeout(5*time.Second))
-	return healthcheck.Handler(options...)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.RLock()
+		opts := make([]healthcheck.Option, integer.Zero, len(options)+integer.One)
+		opts = append(opts, options...)
+		mutex.RUnlock()
+
+		// WithTimeout allows you to set a max overall timeout.
+		opts = append(opts, healthcheck.WithTimeout(handlerTimeout))
+		healthcheck.Handler(opts...).ServeHTTP(w, r)
+	})
 }
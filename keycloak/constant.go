@@ -4,11 +4,14 @@ const (
 	// keycloak token claim
 	ClaimSub               = "sub"
 	ClaimIss               = "iss"
+	ClaimAud               = "aud"
 	ClaimEmail             = "email"
 	ClaimPreferredUsername = "preferred_username"
 	ClaimName              = "name"
 	ClaimRealmAccess       = "realm_access"
 	ClaimRealmAccessRoles  = "roles"
+	ClaimResourceAccess    = "resource_access"
+	ClaimScope             = "scope"
 	ClaimTerminalId        = "terminal_id"
 	ClaimProviderId        = "provider_id"
 	ClaimPolicyGroupId     = "policy_group_id"
@@ -0,0 +1,159 @@
+package keycloak
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/constant/sym"
+	commonContext "github.com/rosaekapratama/go-starter/context"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/response"
+	commonStrings "github.com/rosaekapratama/go-starter/strings"
+	"strings"
+)
+
+const jwtParts = 3
+
+// DecodeClaims decodes claims of the token payload without verifying it,
+// it returns response.UnauthorizedAccess if token is not a JWT
+func DecodeClaims(token string) (map[string]interface{}, error) {
+	// Split JWT into header, payload, and signature
+	parts := strings.Split(token, sym.Dot)
+	if len(parts) != jwtParts {
+		return nil, response.UnauthorizedAccess
+	}
+
+	// Decode payload from base64
+	payloadBytes, err := base64.RawURLEncoding.DecodeString(parts[integer.One])
+	if err != nil {
+		return nil, fmt.Errorf("error decoding token payload: %w", err)
+	}
+
+	// Parse JSON payload to access claims
+	claims := make(map[string]interface{})
+	if err := json.Unmarshal(payloadBytes, &claims); err != nil {
+		return nil, fmt.Errorf("error parsing token JSON payload: %w", err)
+	}
+	return claims, nil
+}
+
+// ContextWithClaims sets user ID, realm, username, full name, email, realm roles, client roles and scopes
+// of the token claims to context, additional claims are set under their camel case key
+func ContextWithClaims(ctx context.Context, claims map[string]interface{}, additionalClaims ...string) context.Context {
+	// set common token claim to context, claims of unexpected type are treated as missing
+	// set sub claim to context if exists
+	if sc, ok := claims[ClaimSub].(string); ok {
+		if sc == str.Empty {
+			log.Warn(ctx, "Unable to set context, sub claim is empty")
+		} else {
+			ctx = commonContext.ContextWithUserId(ctx, sc)
+		}
+	} else {
+		log.Warn(ctx, "Unable to set context, missing sub claim")
+	}
+
+	// set realm from issuer claim to context if exists
+	if iss, ok := claims[ClaimIss].(string); ok {
+		if iss == str.Empty {
+			log.Warn(ctx, "Unable to set context, iss claim is empty")
+		} else {
+			ctx = commonContext.ContextWithRealm(ctx, RealmFromIssuer(iss))
+		}
+	} else {
+		log.Warn(ctx, "Unable to set context, missing iss claim")
+	}
+
+	// set preferred_username claim to context if exists
+	puc, ok := claims[ClaimPreferredUsername].(string)
+	if ok {
+		if puc == str.Empty {
+			log.Warn(ctx, "Unable to set context, preferred_username claim is empty")
+		} else {
+			ctx = commonContext.ContextWithUsername(ctx, puc)
+		}
+	} else {
+		log.Warn(ctx, "Unable to set context, missing preferred_username claim")
+	}
+
+	// set name claim to context if exists, or set with username if empty
+	nc, ok := claims[ClaimName].(string)
+	if !ok {
+		log.Trace(ctx, "Missing name claim, set context using preferred_username claim value instead")
+		nc = puc
+	} else if nc == str.Empty {
+		log.Trace(ctx, "Name claim is empty, set context using preferred_username claim value instead")
+		nc = puc
+	}
+	ctx = commonContext.ContextWithFullName(ctx, nc)
+
+	// set email claim to context if exists
+	if ec, ok := claims[ClaimEmail].(string); ok {
+		if ec == str.Empty {
+			log.Trace(ctx, "Unable to set context, email claim is empty")
+		} else {
+			ctx = commonContext.ContextWithEmail(ctx, ec)
+		}
+	} else {
+		log.Trace(ctx, "Unable to set context, missing email claim")
+	}
+
+	// set realm access claim to context if exists
+	if rac, ok := claims[ClaimRealmAccess].(map[string]interface{}); ok {
+		if rc, ok := rac[ClaimRealmAccessRoles].([]interface{}); ok && len(rc) > integer.Zero {
+			roles := make([]string, 0)
+			for _, role := range rc {
+				if r, ok := role.(string); ok {
+					roles = append(roles, r)
+				}
+			}
+			ctx = commonContext.ContextWithRoles(ctx, roles)
+		} else {
+			log.Trace(ctx, "Unable to set context, roles of realm access claim is empty")
+		}
+	} else {
+		log.Trace(ctx, "Unable to set context, missing realm access claim")
+	}
+
+	// set client roles from resource access claim to context if exists
+	if v, ok := claims[ClaimResourceAccess].(map[string]interface{}); ok {
+		clientRoles := make(map[string][]string)
+		for clientId, access := range v {
+			if a, ok := access.(map[string]interface{}); ok {
+				if rc, ok := a[ClaimRealmAccessRoles].([]interface{}); ok {
+					roles := make([]string, 0)
+					for _, role := range rc {
+						if r, ok := role.(string); ok {
+							roles = append(roles, r)
+						}
+					}
+					clientRoles[clientId] = roles
+				}
+			}
+		}
+		ctx = commonContext.ContextWithClientRoles(ctx, clientRoles)
+	} else {
+		log.Trace(ctx, "Unable to set context, missing resource access claim")
+	}
+
+	// set scope claim to context if exists, scopes are space separated
+	if v, ok := claims[ClaimScope].(string); ok {
+		ctx = commonContext.ContextWithScopes(ctx, strings.Fields(v))
+	} else {
+		log.Trace(ctx, "Unable to set context, missing scope claim")
+	}
+
+	// set custom provided claim to context if exists
+	for _, additionalClaim := range additionalClaims {
+		k := commonStrings.SnakeToCamel(additionalClaim)
+		if v, ok := claims[additionalClaim]; ok {
+			ctx = context.WithValue(ctx, k, v)
+			commonContext.AddManagedKey(k)
+		} else {
+			log.Tracef(ctx, "Unable to set context, missing %s claim", additionalClaim)
+		}
+	}
+	return ctx
+}
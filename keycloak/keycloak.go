@@ -0,0 +1,215 @@
+package keycloak
+
+import (
+	"context"
+	"github.com/MicahParks/keyfunc"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/constant/sym"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/response"
+	"github.com/rosaekapratama/go-starter/slices"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	realmsPath                  = "realms/"
+	jwksPath                    = "/protocol/openid-connect/certs"
+	jwksTimeout                 = 10 * time.Second
+	jwksFailureTTL              = 10 * time.Second
+	defaultClockSkew            = 30 * time.Second
+	defaultJwksRefreshInterval  = time.Hour
+	defaultJwksRefreshRateLimit = time.Minute
+)
+
+var (
+	// Verifier verifies keycloak token, it is nil if keycloak config is missing or disabled
+	Verifier IVerifier
+
+	// Disabled is true only if verification is explicitly disabled by keycloak.disabled config,
+	// token claims are then decoded without verification, otherwise token without Verifier is refused
+	Disabled bool
+
+	validMethods = []string{
+		jwt.SigningMethodRS256.Alg(), jwt.SigningMethodRS384.Alg(), jwt.SigningMethodRS512.Alg(),
+		jwt.SigningMethodPS256.Alg(), jwt.SigningMethodPS384.Alg(), jwt.SigningMethodPS512.Alg(),
+		jwt.SigningMethodES256.Alg(), jwt.SigningMethodES384.Alg(), jwt.SigningMethodES512.Alg(),
+	}
+)
+
+// Init Initiate keycloak token verifier with given configuration
+func Init(ctx context.Context, config config.Config) {
+	cfg := config.GetObject().Keycloak
+	if cfg == nil {
+		log.Warn(ctx, "Missing keycloak config, keycloak token is refused")
+		return
+	}
+	if cfg.Disabled {
+		Disabled = true
+		log.Warn(ctx, "Keycloak token verification is disabled, token claims are trusted without verification")
+		return
+	}
+
+	if cfg.BaseUrl == str.Empty {
+		log.Fatal(ctx, response.ConfigNotFound, "Missing keycloak base URL")
+		return
+	}
+
+	Verifier = NewVerifier(cfg)
+	log.Infof(ctx, "Keycloak token verifier is initiated, baseUrl=%s, realms=%v", cfg.BaseUrl, cfg.Realms)
+}
+
+// NewVerifier returns keycloak token verifier of the given configuration
+func NewVerifier(cfg *config.KeycloakConfig) IVerifier {
+	v := &verifierImpl{
+		baseUrl:          strings.TrimSuffix(cfg.BaseUrl, sym.ForwardSlash),
+		realms:           cfg.Realms,
+		audiences:        cfg.Audiences,
+		clockSkew:        defaultClockSkew,
+		refreshInterval:  defaultJwksRefreshInterval,
+		refreshRateLimit: defaultJwksRefreshRateLimit,
+		httpClient:       &http.Client{Timeout: jwksTimeout},
+		jwksMap:          make(map[string]*keyfunc.JWKS),
+		failures:         make(map[string]*jwksFailure),
+	}
+	if cfg.ClockSkew != nil && cfg.ClockSkew.Duration >= integer.Zero {
+		v.clockSkew = cfg.ClockSkew.Duration
+	}
+	if cfg.JwksRefreshInterval != nil && cfg.JwksRefreshInterval.Duration > integer.Zero {
+		v.refreshInterval = cfg.JwksRefreshInterval.Duration
+	}
+	if cfg.JwksRefreshRateLimit != nil && cfg.JwksRefreshRateLimit.Duration > integer.Zero {
+		v.refreshRateLimit = cfg.JwksRefreshRateLimit.Duration
+	}
+	return v
+}
+
+// RealmFromIssuer returns realm name of keycloak issuer URL, ex: https://sso.example.com/realms/myrealm returns myrealm
+func RealmFromIssuer(iss string) string {
+	idx := strings.LastIndex(iss, realmsPath)
+	if idx < integer.Zero {
+		return str.Empty
+	}
+	return iss[idx+len(realmsPath):]
+}
+
+func (v *verifierImpl) Verify(ctx context.Context, token string) (map[string]interface{}, error) {
+	// Issuer is read before verification to pick the JWKS, it is trusted only if it belongs to base URL
+	unverified := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, unverified); err != nil {
+		log.Debugf(ctx, "Malformed token, error=%v", err)
+		return nil, response.UnauthorizedAccess
+	}
+	iss, _ := unverified[ClaimIss].(string)
+	realm := RealmFromIssuer(iss)
+	if realm == str.Empty || iss != v.baseUrl+sym.ForwardSlash+realmsPath+realm {
+		log.Debugf(ctx, "Token issuer doesn't belong to keycloak base URL, iss=%s", iss)
+		return nil, response.UnauthorizedAccess
+	}
+	if len(v.realms) > integer.Zero && !slices.ContainStringCaseSensitive(v.realms, realm) {
+		log.Debugf(ctx, "Token realm is not allowed, realm=%s", realm)
+		return nil, response.UnauthorizedAccess
+	}
+
+	jwks, err := v.getJWKS(ctx, iss)
+	if err != nil {
+		return nil, response.UnauthorizedAccess
+	}
+
+	// Claims are validated below with clock skew, jwt v4 parser doesn't support leeway
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(validMethods), jwt.WithoutClaimsValidation())
+	if _, err = parser.ParseWithClaims(token, claims, jwks.Keyfunc); err != nil {
+		log.Debugf(ctx, "Invalid token signature, error=%v", err)
+		return nil, response.UnauthorizedAccess
+	}
+
+	now := time.Now()
+	skew := int64(v.clockSkew.Seconds())
+	if !claims.VerifyExpiresAt(now.Unix()-skew, true) {
+		log.Debug(ctx, "Token is expired or missing exp claim")
+		return nil, response.UnauthorizedAccess
+	}
+	if !claims.VerifyNotBefore(now.Unix()+skew, false) {
+		log.Debug(ctx, "Token is not valid yet")
+		return nil, response.UnauthorizedAccess
+	}
+	if !claims.VerifyIssuedAt(now.Unix()+skew, false) {
+		log.Debug(ctx, "Token is issued in the future")
+		return nil, response.UnauthorizedAccess
+	}
+	if len(v.audiences) > integer.Zero && !v.verifyAudience(claims) {
+		log.Debugf(ctx, "Token audience is not accepted, aud=%v", claims[ClaimAud])
+		return nil, response.UnauthorizedAccess
+	}
+	return claims, nil
+}
+
+func (v *verifierImpl) verifyAudience(claims jwt.MapClaims) bool {
+	for _, audience := range v.audiences {
+		if claims.VerifyAudience(audience, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// getJWKS returns JWKS of the issuer, it is refreshed in background and on unknown key ID.
+// JWKS is fetched once per issuer without holding the lock, and failure is cached briefly,
+// so keycloak outage doesn't block tokens of other issuers nor get hit by every request
+func (v *verifierImpl) getJWKS(ctx context.Context, iss string) (*keyfunc.JWKS, error) {
+	if jwks, err := v.cachedJWKS(iss); jwks != nil || err != nil {
+		return jwks, err
+	}
+
+	jwks, err, _ := v.group.Do(iss, func() (interface{}, error) {
+		if jwks, err := v.cachedJWKS(iss); jwks != nil || err != nil {
+			return jwks, err
+		}
+
+		jwksUrl := iss + jwksPath
+		jwks, err := keyfunc.Get(jwksUrl, keyfunc.Options{
+			Client:            v.httpClient,
+			RefreshInterval:   v.refreshInterval,
+			RefreshRateLimit:  v.refreshRateLimit,
+			RefreshTimeout:    jwksTimeout,
+			RefreshUnknownKID: true,
+			RefreshErrorHandler: func(err error) {
+				log.Warnf(context.Background(), "Failed to refresh keycloak JWKS, url=%s, error=%v", jwksUrl, err)
+			},
+		})
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		if err != nil {
+			log.Errorf(ctx, err, "Failed to get keycloak JWKS, url=%s", jwksUrl)
+			v.failures[iss] = &jwksFailure{err: err, expireAt: time.Now().Add(jwksFailureTTL)}
+			return nil, err
+		}
+		delete(v.failures, iss)
+		v.jwksMap[iss] = jwks
+		return jwks, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jwks.(*keyfunc.JWKS), nil
+}
+
+// cachedJWKS returns fetched JWKS of the issuer, or error of its last fetch if it failed recently
+func (v *verifierImpl) cachedJWKS(iss string) (*keyfunc.JWKS, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if jwks, ok := v.jwksMap[iss]; ok {
+		return jwks, nil
+	}
+	if failure, ok := v.failures[iss]; ok && time.Now().Before(failure.expireAt) {
+		return nil, failure.err
+	}
+	return nil, nil
+}
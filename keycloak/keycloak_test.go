@@ -0,0 +1,136 @@
+package keycloak
+
+import (
+	"context"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/keycloak/keycloaktest"
+	"github.com/rosaekapratama/go-starter/response"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var ctx context.Context
+
+type VerifierTestSuite struct {
+	suite.Suite
+	server   *keycloaktest.Server
+	verifier IVerifier
+}
+
+func (s *VerifierTestSuite) SetupTest() {
+	ctx = context.Background()
+	s.server = keycloaktest.NewServer()
+	s.verifier = NewVerifier(&config.KeycloakConfig{
+		BaseUrl:   s.server.URL(),
+		Realms:    []string{"myrealm"},
+		Audiences: []string{"account"},
+	})
+}
+
+func (s *VerifierTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func TestVerifierTestSuite(t *testing.T) {
+	suite.Run(t, new(VerifierTestSuite))
+}
+
+func (s *VerifierTestSuite) TestValidToken() {
+	token := s.server.Token("myrealm", map[string]interface{}{"aud": "account", ClaimPreferredUsername: "john"})
+	claims, err := s.verifier.Verify(ctx, token)
+	s.Require().NoError(err)
+	s.Equal("john", claims[ClaimPreferredUsername])
+}
+
+func (s *VerifierTestSuite) TestClockSkew() {
+	// Expired 10 seconds ago, still within default 30 seconds clock skew
+	token := s.server.Token("myrealm", map[string]interface{}{"aud": "account", "exp": time.Now().Add(-10 * time.Second).Unix()})
+	_, err := s.verifier.Verify(ctx, token)
+	s.NoError(err)
+
+	token = s.server.Token("myrealm", map[string]interface{}{"aud": "account", "exp": time.Now().Add(-time.Minute).Unix()})
+	_, err = s.verifier.Verify(ctx, token)
+	s.ErrorIs(err, response.UnauthorizedAccess)
+
+	token = s.server.Token("myrealm", map[string]interface{}{"aud": "account", "nbf": time.Now().Add(time.Minute).Unix()})
+	_, err = s.verifier.Verify(ctx, token)
+	s.ErrorIs(err, response.UnauthorizedAccess)
+}
+
+func (s *VerifierTestSuite) TestInvalidClaims() {
+	token := s.server.Token("otherrealm", map[string]interface{}{"aud": "account"})
+	_, err := s.verifier.Verify(ctx, token)
+	s.ErrorIs(err, response.UnauthorizedAccess)
+
+	token = s.server.Token("myrealm", map[string]interface{}{"aud": "other"})
+	_, err = s.verifier.Verify(ctx, token)
+	s.ErrorIs(err, response.UnauthorizedAccess)
+
+	token = s.server.Token("myrealm", map[string]interface{}{"aud": "account", ClaimIss: "https://evil.example.com/realms/myrealm"})
+	_, err = s.verifier.Verify(ctx, token)
+	s.ErrorIs(err, response.UnauthorizedAccess)
+
+	_, err = s.verifier.Verify(ctx, "not.a.token")
+	s.ErrorIs(err, response.UnauthorizedAccess)
+}
+
+func (s *VerifierTestSuite) TestKeyRotation() {
+	token := s.server.Token("myrealm", map[string]interface{}{"aud": "account"})
+	_, err := s.verifier.Verify(ctx, token)
+	s.Require().NoError(err)
+
+	// Token signed by new key is accepted after JWKS is refreshed on unknown key ID
+	s.server.RotateKey()
+	token = s.server.Token("myrealm", map[string]interface{}{"aud": "account"})
+	_, err = s.verifier.Verify(ctx, token)
+	s.NoError(err)
+
+	// Tampered signature
+	_, err = s.verifier.Verify(ctx, token[:len(token)-4]+"AAAA")
+	s.ErrorIs(err, response.UnauthorizedAccess)
+}
+
+func (s *VerifierTestSuite) TestJWKSFailure() {
+	var requests atomic.Int32
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	verifier := NewVerifier(&config.KeycloakConfig{BaseUrl: failing.URL})
+	token := s.server.Sign(map[string]interface{}{ClaimIss: failing.URL + "/realms/myrealm", "exp": time.Now().Add(time.Minute).Unix()})
+
+	// Concurrent tokens of the issuer share one fetch, and its failure is cached
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := verifier.Verify(ctx, token)
+			s.ErrorIs(err, response.UnauthorizedAccess)
+		}()
+	}
+	wg.Wait()
+	_, err := verifier.Verify(ctx, token)
+	s.ErrorIs(err, response.UnauthorizedAccess)
+	s.Equal(int32(1), requests.Load())
+
+	// JWKS is fetched again once the failure expires
+	impl := verifier.(*verifierImpl)
+	impl.mu.Lock()
+	impl.failures[failing.URL+"/realms/myrealm"].expireAt = time.Now()
+	impl.mu.Unlock()
+	_, err = verifier.Verify(ctx, token)
+	s.ErrorIs(err, response.UnauthorizedAccess)
+	s.Equal(int32(2), requests.Load())
+
+	// Other issuer is not blocked by the failing one
+	_, err = s.verifier.Verify(ctx, s.server.Token("myrealm", map[string]interface{}{"aud": "account"}))
+	s.NoError(err)
+}
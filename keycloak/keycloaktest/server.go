@@ -0,0 +1,122 @@
+// Package keycloaktest provides a stand-in keycloak server which serves realm JWKS and signs tokens, for tests only
+package keycloaktest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	realmsPath = "/realms/"
+	jwksPath   = "/protocol/openid-connect/certs"
+	keySize    = 2048
+)
+
+// Server serves JWKS of every realm at /realms/{realm}/protocol/openid-connect/certs
+type Server struct {
+	server *httptest.Server
+
+	mu  sync.RWMutex
+	kid string
+	key *rsa.PrivateKey
+}
+
+// NewServer starts stand-in keycloak server with a fresh RSA signing key, call Close when done
+func NewServer() *Server {
+	s := &Server{}
+	s.RotateKey()
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveJWKS))
+	return s
+}
+
+// URL returns base URL of the server, to be used as keycloak base URL config
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// Issuer returns iss claim value of the realm
+func (s *Server) Issuer(realm string) string {
+	return s.server.URL + realmsPath + realm
+}
+
+// RotateKey replaces signing key with a new one, tokens signed by the old key are no longer valid
+func (s *Server) RotateKey() {
+	key, err := rsa.GenerateKey(rand.Reader, keySize)
+	if err != nil {
+		panic(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kid = uuid.NewString()
+	s.key = key
+}
+
+// Sign signs the claims as is with current signing key using RS256
+func (s *Server) Sign(claims map[string]interface{}) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims(claims))
+	token.Header["kid"] = s.kid
+	signed, err := token.SignedString(s.key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+// Token signs access token of the realm, iss, sub, iat and exp claims are set unless provided in claims
+func (s *Server) Token(realm string, claims map[string]interface{}) string {
+	now := time.Now()
+	merged := map[string]interface{}{
+		"iss": s.Issuer(realm),
+		"sub": uuid.NewString(),
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	for k, v := range claims {
+		merged[k] = v
+	}
+	return s.Sign(merged)
+}
+
+// Close shuts down the server
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+func (s *Server) serveJWKS(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, realmsPath) || !strings.HasSuffix(r.URL.Path, jwksPath) {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	jwks := map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": s.kid,
+			"kty": "RSA",
+			"alg": jwt.SigningMethodRS256.Alg(),
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(s.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.key.E)).Bytes()),
+		}},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(jwks); err != nil {
+		http.Error(w, fmt.Sprintf("failed to encode JWKS: %v", err), http.StatusInternalServerError)
+	}
+}
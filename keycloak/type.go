@@ -0,0 +1,38 @@
+package keycloak
+
+import (
+	"context"
+	"github.com/MicahParks/keyfunc"
+	"golang.org/x/sync/singleflight"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type IVerifier interface {
+	// Verify verifies token signature against JWKS of its realm and validates its claims,
+	// it returns the token claims, or response.UnauthorizedAccess if token is invalid
+	Verify(ctx context.Context, token string) (claims map[string]interface{}, err error)
+}
+
+type verifierImpl struct {
+	baseUrl          string
+	realms           []string
+	audiences        []string
+	clockSkew        time.Duration
+	refreshInterval  time.Duration
+	refreshRateLimit time.Duration
+	httpClient       *http.Client
+
+	// JWKS of each issuer, fetched on first token of the issuer
+	jwksMap map[string]*keyfunc.JWKS
+	// failures are recent JWKS fetch errors of each issuer, token of the issuer is refused until it expires
+	failures map[string]*jwksFailure
+	mu       sync.Mutex
+	group    singleflight.Group
+}
+
+type jwksFailure struct {
+	err      error
+	expireAt time.Time
+}
@@ -12,6 +12,8 @@ const (
 	LogTypeRest        = "rest"
 	LogTypeSoap        = "soap"
 	LogTypeGrpc        = "grpc"
+	LogTypeSse         = "sse"
+	LogTypeWebSocket   = "websocket"
 	IsServerLogKey     = "isServer"
 	IsRequestLogKey    = "isRequest"
 	UrlLogKey          = "url"
@@ -33,4 +35,6 @@ const (
 	MetadataLogKey     = "metadata"
 	TrailersLogKey     = "trailers"
 	MessageLogKey      = "message"
+	FrameTypeLogKey    = "frameType"
+	SizeLogKey         = "size"
 )
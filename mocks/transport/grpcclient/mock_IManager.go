@@ -5,6 +5,8 @@ package grpcclient
 import (
 	context "context"
 
+	config "github.com/rosaekapratama/go-starter/config"
+
 	grpc "google.golang.org/grpc"
 
 	mock "github.com/stretchr/testify/mock"
@@ -23,95 +25,170 @@ func (_m *MockIManager) EXPECT() *MockIManager_Expecter {
 	return &MockIManager_Expecter{mock: &_m.Mock}
 }
 
-// GetConn provides a mock function with given fields: ctx, connId
-func (_m *MockIManager) GetConn(ctx context.Context, connId string) (*grpc.ClientConn, error) {
-	ret := _m.Called(ctx, connId)
+// AddConn provides a mock function with given fields: ctx, connId, connConfig
+func (_m *MockIManager) AddConn(ctx context.Context, connId string, connConfig *config.GrpcClientConfig) error {
+	ret := _m.Called(ctx, connId, connConfig)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetConn")
+		panic("no return value specified for AddConn")
 	}
 
-	var r0 *grpc.ClientConn
-	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*grpc.ClientConn, error)); ok {
-		return rf(ctx, connId)
-	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *grpc.ClientConn); ok {
-		r0 = rf(ctx, connId)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *config.GrpcClientConfig) error); ok {
+		r0 = rf(ctx, connId, connConfig)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*grpc.ClientConn)
-		}
+		r0 = ret.Error(0)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, connId)
+	return r0
+}
+
+// MockIManager_AddConn_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddConn'
+type MockIManager_AddConn_Call struct {
+	*mock.Call
+}
+
+// AddConn is a helper method to define mock.On call
+//   - ctx context.Context
+//   - connId string
+//   - connConfig *config.GrpcClientConfig
+func (_e *MockIManager_Expecter) AddConn(ctx interface{}, connId interface{}, connConfig interface{}) *MockIManager_AddConn_Call {
+	return &MockIManager_AddConn_Call{Call: _e.mock.On("AddConn", ctx, connId, connConfig)}
+}
+
+func (_c *MockIManager_AddConn_Call) Run(run func(ctx context.Context, connId string, connConfig *config.GrpcClientConfig)) *MockIManager_AddConn_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(*config.GrpcClientConfig))
+	})
+	return _c
+}
+
+func (_c *MockIManager_AddConn_Call) Return(err error) *MockIManager_AddConn_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockIManager_AddConn_Call) RunAndReturn(run func(context.Context, string, *config.GrpcClientConfig) error) *MockIManager_AddConn_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Close provides a mock function with given fields: ctx, connId
+func (_m *MockIManager) Close(ctx context.Context, connId string) error {
+	ret := _m.Called(ctx, connId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Close")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, connId)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// MockIManager_GetConn_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetConn'
-type MockIManager_GetConn_Call struct {
+// MockIManager_Close_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Close'
+type MockIManager_Close_Call struct {
 	*mock.Call
 }
 
-// GetConn is a helper method to define mock.On call
+// Close is a helper method to define mock.On call
 //   - ctx context.Context
 //   - connId string
-func (_e *MockIManager_Expecter) GetConn(ctx interface{}, connId interface{}) *MockIManager_GetConn_Call {
-	return &MockIManager_GetConn_Call{Call: _e.mock.On("GetConn", ctx, connId)}
+func (_e *MockIManager_Expecter) Close(ctx interface{}, connId interface{}) *MockIManager_Close_Call {
+	return &MockIManager_Close_Call{Call: _e.mock.On("Close", ctx, connId)}
 }
 
-func (_c *MockIManager_GetConn_Call) Run(run func(ctx context.Context, connId string)) *MockIManager_GetConn_Call {
+func (_c *MockIManager_Close_Call) Run(run func(ctx context.Context, connId string)) *MockIManager_Close_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *MockIManager_GetConn_Call) Return(conn *grpc.ClientConn, err error) *MockIManager_GetConn_Call {
-	_c.Call.Return(conn, err)
+func (_c *MockIManager_Close_Call) Return(err error) *MockIManager_Close_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *MockIManager_GetConn_Call) RunAndReturn(run func(context.Context, string) (*grpc.ClientConn, error)) *MockIManager_GetConn_Call {
+func (_c *MockIManager_Close_Call) RunAndReturn(run func(context.Context, string) error) *MockIManager_Close_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// initConn provides a mock function with given fields: ctx, connId, address, stdoutLogging, databaseLogging, payloadLogSizeLimit, opts
-func (_m *MockIManager) initConn(ctx context.Context, connId string, address string, stdoutLogging bool, databaseLogging string, payloadLogSizeLimit uint64, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
-	_va := make([]interface{}, len(opts))
-	for _i := range opts {
-		_va[_i] = opts[_i]
+// CloseAll provides a mock function with given fields: ctx
+func (_m *MockIManager) CloseAll(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CloseAll")
 	}
-	var _ca []interface{}
-	_ca = append(_ca, ctx, connId, address, stdoutLogging, databaseLogging, payloadLogSizeLimit)
-	_ca = append(_ca, _va...)
-	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockIManager_CloseAll_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CloseAll'
+type MockIManager_CloseAll_Call struct {
+	*mock.Call
+}
+
+// CloseAll is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockIManager_Expecter) CloseAll(ctx interface{}) *MockIManager_CloseAll_Call {
+	return &MockIManager_CloseAll_Call{Call: _e.mock.On("CloseAll", ctx)}
+}
+
+func (_c *MockIManager_CloseAll_Call) Run(run func(ctx context.Context)) *MockIManager_CloseAll_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockIManager_CloseAll_Call) Return(err error) *MockIManager_CloseAll_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockIManager_CloseAll_Call) RunAndReturn(run func(context.Context) error) *MockIManager_CloseAll_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetConn provides a mock function with given fields: ctx, connId
+func (_m *MockIManager) GetConn(ctx context.Context, connId string) (*grpc.ClientConn, error) {
+	ret := _m.Called(ctx, connId)
 
 	if len(ret) == 0 {
-		panic("no return value specified for initConn")
+		panic("no return value specified for GetConn")
 	}
 
 	var r0 *grpc.ClientConn
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, bool, string, uint64, ...grpc.DialOption) (*grpc.ClientConn, error)); ok {
-		return rf(ctx, connId, address, stdoutLogging, databaseLogging, payloadLogSizeLimit, opts...)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*grpc.ClientConn, error)); ok {
+		return rf(ctx, connId)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, bool, string, uint64, ...grpc.DialOption) *grpc.ClientConn); ok {
-		r0 = rf(ctx, connId, address, stdoutLogging, databaseLogging, payloadLogSizeLimit, opts...)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *grpc.ClientConn); ok {
+		r0 = rf(ctx, connId)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*grpc.ClientConn)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, bool, string, uint64, ...grpc.DialOption) error); ok {
-		r1 = rf(ctx, connId, address, stdoutLogging, databaseLogging, payloadLogSizeLimit, opts...)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, connId)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -119,43 +196,31 @@ func (_m *MockIManager) initConn(ctx context.Context, connId string, address str
 	return r0, r1
 }
 
-// MockIManager_initConn_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'initConn'
-type MockIManager_initConn_Call struct {
+// MockIManager_GetConn_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetConn'
+type MockIManager_GetConn_Call struct {
 	*mock.Call
 }
 
-// initConn is a helper method to define mock.On call
+// GetConn is a helper method to define mock.On call
 //   - ctx context.Context
 //   - connId string
-//   - address string
-//   - stdoutLogging bool
-//   - databaseLogging string
-//   - payloadLogSizeLimit uint64
-//   - opts ...grpc.DialOption
-func (_e *MockIManager_Expecter) initConn(ctx interface{}, connId interface{}, address interface{}, stdoutLogging interface{}, databaseLogging interface{}, payloadLogSizeLimit interface{}, opts ...interface{}) *MockIManager_initConn_Call {
-	return &MockIManager_initConn_Call{Call: _e.mock.On("initConn",
-		append([]interface{}{ctx, connId, address, stdoutLogging, databaseLogging, payloadLogSizeLimit}, opts...)...)}
+func (_e *MockIManager_Expecter) GetConn(ctx interface{}, connId interface{}) *MockIManager_GetConn_Call {
+	return &MockIManager_GetConn_Call{Call: _e.mock.On("GetConn", ctx, connId)}
 }
 
-func (_c *MockIManager_initConn_Call) Run(run func(ctx context.Context, connId string, address string, stdoutLogging bool, databaseLogging string, payloadLogSizeLimit uint64, opts ...grpc.DialOption)) *MockIManager_initConn_Call {
+func (_c *MockIManager_GetConn_Call) Run(run func(ctx context.Context, connId string)) *MockIManager_GetConn_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		variadicArgs := make([]grpc.DialOption, len(args)-6)
-		for i, a := range args[6:] {
-			if a != nil {
-				variadicArgs[i] = a.(grpc.DialOption)
-			}
-		}
-		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(bool), args[4].(string), args[5].(uint64), variadicArgs...)
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *MockIManager_initConn_Call) Return(conn *grpc.ClientConn, err error) *MockIManager_initConn_Call {
+func (_c *MockIManager_GetConn_Call) Return(conn *grpc.ClientConn, err error) *MockIManager_GetConn_Call {
 	_c.Call.Return(conn, err)
 	return _c
 }
 
-func (_c *MockIManager_initConn_Call) RunAndReturn(run func(context.Context, string, string, bool, string, uint64, ...grpc.DialOption) (*grpc.ClientConn, error)) *MockIManager_initConn_Call {
+func (_c *MockIManager_GetConn_Call) RunAndReturn(run func(context.Context, string) (*grpc.ClientConn, error)) *MockIManager_GetConn_Call {
 	_c.Call.Return(run)
 	return _c
 }
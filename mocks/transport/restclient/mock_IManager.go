@@ -22,6 +22,64 @@ func (_m *MockIManager) EXPECT() *MockIManager_Expecter {
 	return &MockIManager_Expecter{mock: &_m.Mock}
 }
 
+// GetClient provides a mock function with given fields: name
+func (_m *MockIManager) GetClient(name string) (*restclient.Client, error) {
+	ret := _m.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetClient")
+	}
+
+	var r0 *restclient.Client
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*restclient.Client, error)); ok {
+		return rf(name)
+	}
+	if rf, ok := ret.Get(0).(func(string) *restclient.Client); ok {
+		r0 = rf(name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*restclient.Client)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockIManager_GetClient_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetClient'
+type MockIManager_GetClient_Call struct {
+	*mock.Call
+}
+
+// GetClient is a helper method to define mock.On call
+//   - name string
+func (_e *MockIManager_Expecter) GetClient(name interface{}) *MockIManager_GetClient_Call {
+	return &MockIManager_GetClient_Call{Call: _e.mock.On("GetClient", name)}
+}
+
+func (_c *MockIManager_GetClient_Call) Run(run func(name string)) *MockIManager_GetClient_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockIManager_GetClient_Call) Return(_a0 *restclient.Client, _a1 error) *MockIManager_GetClient_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockIManager_GetClient_Call) RunAndReturn(run func(string) (*restclient.Client, error)) *MockIManager_GetClient_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetDefaultClient provides a mock function with given fields:
 func (_m *MockIManager) GetDefaultClient() *restclient.Client {
 	ret := _m.Called()
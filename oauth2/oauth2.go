@@ -0,0 +1,209 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	commonContext "github.com/rosaekapratama/go-starter/context"
+	"github.com/rosaekapratama/go-starter/log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	GrantClientCredentials = "clientCredentials"
+	GrantTokenExchange     = "tokenExchange"
+	GrantForward           = "forward"
+
+	grantTypeClientCredentials = "client_credentials"
+	grantTypeTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeAccessToken       = "urn:ietf:params:oauth:token-type:access_token"
+
+	contentTypeForm    = "application/x-www-form-urlencoded"
+	contentTypeJson    = "application/json"
+	defaultExpiryDelta = 30 * time.Second
+	tokenTimeout       = 10 * time.Second
+)
+
+var (
+	errInvalidGrant         = errors.New("invalid OAuth2 grant")
+	errMissingTokenUrl      = errors.New("missing OAuth2 token URL")
+	errMissingClientId      = errors.New("missing OAuth2 client ID")
+	errSubjectTokenNotFound = errors.New("token not found in context")
+	errTokenRequestFailed   = errors.New("OAuth2 token request failed")
+)
+
+// NewTokenProvider returns token provider of the given configuration
+func NewTokenProvider(cfg *config.OAuth2ClientConfig) (ITokenProvider, error) {
+	grant := cfg.Grant
+	if grant == str.Empty {
+		grant = GrantClientCredentials
+	}
+
+	switch grant {
+	case GrantForward:
+		return NewForwardProvider(), nil
+	case GrantClientCredentials, GrantTokenExchange:
+	default:
+		return nil, fmt.Errorf("%w, grant=%s", errInvalidGrant, grant)
+	}
+
+	if cfg.TokenUrl == str.Empty {
+		return nil, errMissingTokenUrl
+	}
+	if cfg.ClientId == str.Empty {
+		return nil, errMissingClientId
+	}
+
+	p := &tokenProvider{
+		grant:        grant,
+		tokenUrl:     cfg.TokenUrl,
+		clientId:     cfg.ClientId,
+		clientSecret: cfg.ClientSecret,
+		scopes:       cfg.Scopes,
+		audience:     cfg.Audience,
+		expiryDelta:  defaultExpiryDelta,
+		httpClient:   &http.Client{Timeout: tokenTimeout},
+		now:          time.Now,
+		tokens:       make(map[string]*cachedToken),
+	}
+	if cfg.ExpiryDelta != nil && cfg.ExpiryDelta.Duration >= integer.Zero {
+		p.expiryDelta = cfg.ExpiryDelta.Duration
+	}
+	return p, nil
+}
+
+// NewForwardProvider returns token provider which sends token of the incoming request as is,
+// the token is put in context by REST and GRPC server
+func NewForwardProvider() ITokenProvider {
+	return &forwardProvider{}
+}
+
+func (p *forwardProvider) Token(ctx context.Context) (string, error) {
+	token, exists := commonContext.TokenFromContext(ctx)
+	if !exists || token == str.Empty {
+		return str.Empty, errSubjectTokenNotFound
+	}
+	return token, nil
+}
+
+// Token returns cached token, or gets a new one if it is missing or about to expire,
+// token exchange grant exchanges token of the incoming request in context
+func (p *tokenProvider) Token(ctx context.Context) (string, error) {
+	var subjectToken string
+	if p.grant == GrantTokenExchange {
+		token, exists := commonContext.TokenFromContext(ctx)
+		if !exists || token == str.Empty {
+			return str.Empty, errSubjectTokenNotFound
+		}
+		subjectToken = token
+	}
+
+	if token, ok := p.cached(subjectToken); ok {
+		return token, nil
+	}
+
+	// Concurrent requests of the same token share one token request
+	v, err, _ := p.group.Do(subjectToken, func() (interface{}, error) {
+		if token, ok := p.cached(subjectToken); ok {
+			return token, nil
+		}
+
+		// Token is shared, so it is not cancelled by the request which happens to get it
+		t, err := p.requestToken(context.WithoutCancel(ctx), subjectToken)
+		if err != nil {
+			log.Errorf(ctx, err, "Failed to get OAuth2 token, grant=%s, tokenUrl=%s, clientId=%s", p.grant, p.tokenUrl, p.clientId)
+			return nil, err
+		}
+		p.store(subjectToken, t)
+		return t.accessToken, nil
+	})
+	if err != nil {
+		return str.Empty, err
+	}
+	return v.(string), nil
+}
+
+func (p *tokenProvider) cached(key string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t, ok := p.tokens[key]
+	if !ok || !p.now().Add(p.expiryDelta).Before(t.expiry) {
+		return str.Empty, false
+	}
+	return t.accessToken, true
+}
+
+// store caches the token and removes expired ones, so exchanged token of ended request doesn't pile up
+func (p *tokenProvider) store(key string, t *cachedToken) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	for k, v := range p.tokens {
+		if !now.Before(v.expiry) {
+			delete(p.tokens, k)
+		}
+	}
+	p.tokens[key] = t
+}
+
+func (p *tokenProvider) requestToken(ctx context.Context, subjectToken string) (*cachedToken, error) {
+	form := url.Values{}
+	form.Set("client_id", p.clientId)
+	if p.clientSecret != str.Empty {
+		form.Set("client_secret", p.clientSecret)
+	}
+	if len(p.scopes) > integer.Zero {
+		form.Set("scope", strings.Join(p.scopes, str.Space))
+	}
+	if p.grant == GrantTokenExchange {
+		form.Set("grant_type", grantTypeTokenExchange)
+		form.Set("subject_token", subjectToken)
+		form.Set("subject_token_type", tokenTypeAccessToken)
+		form.Set("requested_token_type", tokenTypeAccessToken)
+		if p.audience != str.Empty {
+			form.Set("audience", p.audience)
+		}
+	} else {
+		form.Set("grant_type", grantTypeClientCredentials)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(headers.ContentType, contentTypeForm)
+	req.Header.Set(headers.Accept, contentTypeJson)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	tr := &tokenResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(tr); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK || tr.AccessToken == str.Empty {
+		return nil, fmt.Errorf("%w, status=%d, error=%s, description=%s", errTokenRequestFailed, resp.StatusCode, tr.Error, tr.ErrorDescription)
+	}
+
+	// Token without expires_in is used once, there is no telling when to refresh it
+	t := &cachedToken{accessToken: tr.AccessToken}
+	if tr.ExpiresIn > integer.Zero {
+		t.expiry = p.now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return t, nil
+}
@@ -0,0 +1,151 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/rosaekapratama/go-starter/config"
+	commonContext "github.com/rosaekapratama/go-starter/context"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var ctx context.Context
+
+type TokenProviderTestSuite struct {
+	suite.Suite
+	server *httptest.Server
+	calls  atomic.Int32
+	forms  chan map[string]string
+}
+
+func (s *TokenProviderTestSuite) SetupTest() {
+	ctx = context.Background()
+	s.calls.Store(0)
+	s.forms = make(chan map[string]string, 16)
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := s.calls.Add(1)
+		_ = r.ParseForm()
+		form := make(map[string]string)
+		for k := range r.PostForm {
+			form[k] = r.PostForm.Get(k)
+		}
+		s.forms <- form
+
+		w.Header().Set("Content-Type", "application/json")
+		if form["client_secret"] != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "unauthorized_client"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": form["grant_type"] + "-" + string(rune('0'+call)),
+			"token_type":   "Bearer",
+			"expires_in":   300,
+		})
+	}))
+}
+
+func (s *TokenProviderTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func TestTokenProviderTestSuite(t *testing.T) {
+	suite.Run(t, new(TokenProviderTestSuite))
+}
+
+func (s *TokenProviderTestSuite) TestClientCredentials() {
+	provider, err := NewTokenProvider(&config.OAuth2ClientConfig{
+		TokenUrl:     s.server.URL,
+		ClientId:     "my-service",
+		ClientSecret: "secret",
+		Scopes:       []string{"orders", "payments"},
+	})
+	s.Require().NoError(err)
+	now := time.Now()
+	provider.(*tokenProvider).now = func() time.Time { return now }
+
+	// Concurrent calls share one token request
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := provider.Token(ctx)
+			s.NoError(err)
+			s.Equal("client_credentials-1", token)
+		}()
+	}
+	wg.Wait()
+	s.Equal(int32(1), s.calls.Load())
+	form := <-s.forms
+	s.Equal("my-service", form["client_id"])
+	s.Equal("orders payments", form["scope"])
+
+	// Token is refreshed within expiry delta
+	now = now.Add(269 * time.Second)
+	token, err := provider.Token(ctx)
+	s.Require().NoError(err)
+	s.Equal("client_credentials-1", token)
+	now = now.Add(2 * time.Second)
+	token, err = provider.Token(ctx)
+	s.Require().NoError(err)
+	s.Equal("client_credentials-2", token)
+}
+
+func (s *TokenProviderTestSuite) TestTokenExchange() {
+	provider, err := NewTokenProvider(&config.OAuth2ClientConfig{
+		Grant:        GrantTokenExchange,
+		TokenUrl:     s.server.URL,
+		ClientId:     "my-service",
+		ClientSecret: "secret",
+		Audience:     "order-service",
+	})
+	s.Require().NoError(err)
+
+	_, err = provider.Token(ctx)
+	s.ErrorIs(err, errSubjectTokenNotFound)
+
+	token, err := provider.Token(commonContext.ContextWithToken(ctx, "user-token"))
+	s.Require().NoError(err)
+	s.Equal(grantTypeTokenExchange+"-1", token)
+	form := <-s.forms
+	s.Equal("user-token", form["subject_token"])
+	s.Equal("order-service", form["audience"])
+
+	// Exchanged token is cached per subject token
+	token, err = provider.Token(commonContext.ContextWithToken(ctx, "user-token"))
+	s.Require().NoError(err)
+	s.Equal(grantTypeTokenExchange+"-1", token)
+	token, err = provider.Token(commonContext.ContextWithToken(ctx, "other-user-token"))
+	s.Require().NoError(err)
+	s.Equal(grantTypeTokenExchange+"-2", token)
+}
+
+func (s *TokenProviderTestSuite) TestForward() {
+	provider, err := NewTokenProvider(&config.OAuth2ClientConfig{Grant: GrantForward})
+	s.Require().NoError(err)
+
+	token, err := provider.Token(commonContext.ContextWithToken(ctx, "user-token"))
+	s.Require().NoError(err)
+	s.Equal("user-token", token)
+	_, err = provider.Token(ctx)
+	s.ErrorIs(err, errSubjectTokenNotFound)
+}
+
+func (s *TokenProviderTestSuite) TestError() {
+	provider, err := NewTokenProvider(&config.OAuth2ClientConfig{TokenUrl: s.server.URL, ClientId: "my-service"})
+	s.Require().NoError(err)
+	_, err = provider.Token(ctx)
+	s.ErrorIs(err, errTokenRequestFailed)
+	s.Contains(err.Error(), "unauthorized_client")
+
+	_, err = NewTokenProvider(&config.OAuth2ClientConfig{Grant: "password"})
+	s.ErrorIs(err, errInvalidGrant)
+	_, err = NewTokenProvider(&config.OAuth2ClientConfig{ClientId: "my-service"})
+	s.ErrorIs(err, errMissingTokenUrl)
+}
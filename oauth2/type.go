@@ -0,0 +1,49 @@
+package oauth2
+
+import (
+	"context"
+	"golang.org/x/sync/singleflight"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type ITokenProvider interface {
+	// Token returns access token to be sent on outgoing request of the context
+	Token(ctx context.Context) (token string, err error)
+}
+
+// forwardProvider sends token of the incoming request as is
+type forwardProvider struct {
+}
+
+// tokenProvider gets token from token endpoint and caches it until near expiry
+type tokenProvider struct {
+	grant        string
+	tokenUrl     string
+	clientId     string
+	clientSecret string
+	scopes       []string
+	audience     string
+	expiryDelta  time.Duration
+	httpClient   *http.Client
+	now          func() time.Time
+
+	// Cached token keyed by subject token, client credentials token is keyed by empty string
+	tokens map[string]*cachedToken
+	mu     sync.Mutex
+	group  singleflight.Group
+}
+
+type cachedToken struct {
+	accessToken string
+	expiry      time.Time
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
@@ -23,6 +23,7 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"sync"
 	"time"
 )
 
@@ -35,6 +36,7 @@ var (
 	tracer   trace.Tracer
 	meter    metric.Meter
 	counters map[string]metric.Int64Counter
+	mu       sync.RWMutex
 )
 
 // Init Initializes an OTLP exporter, and configures the corresponding trace and metric providers.
@@ -263,18 +265,39 @@ func (w *SpanWrapper) TracerProvider() trace.TracerProvider {
 	return nil
 }
 
+// AddCounter registers counter with the given name, it does nothing if otel is disabled
 func AddCounter(_ context.Context, counterName string, unit string) error {
+	if meter == nil {
+		return nil
+	}
+
 	counter, err := meter.Int64Counter(counterName, metric.WithUnit(unit))
 	if err != nil {
 		return err
 	}
 
+	mu.Lock()
+	defer mu.Unlock()
 	counters[counterName] = counter
 	return nil
 }
 
+// HasCounter returns true if counter with the given name is registered
+func HasCounter(counterName string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := counters[counterName]
+	return ok
+}
+
+// Count increments counter with the given name, it does nothing if the counter is not registered
 func Count(ctx context.Context, counterName string, incr int64, opts ...metric.AddOption) {
-	counters[counterName].Add(ctx, incr, opts...)
+	mu.RLock()
+	counter, ok := counters[counterName]
+	mu.RUnlock()
+	if ok {
+		counter.Add(ctx, incr, opts...)
+	}
 }
 
 func isOtelConfigMissingOrDisabled(config *config.Object) bool {
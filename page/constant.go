@@ -1,11 +1,13 @@
 package page
 
 const (
-	DefaultPageNum    = 1
-	DefaultPageSize   = 10
-	MaxPageSize       = 100
-	PageNumQueryKey   = "pageNum"
-	PageSizeQueryKey  = "pageSize"
-	PageNumHeaderKey  = "Page-Num"
-	PageSizeHeaderKey = "Page-Size"
+	DefaultPageNum     = 1
+	DefaultPageSize    = 10
+	MaxPageSize        = 100
+	PageNumQueryKey    = "pageNum"
+	PageSizeQueryKey   = "pageSize"
+	PageNumHeaderKey   = "Page-Num"
+	PageSizeHeaderKey  = "Page-Size"
+	TotalItemHeaderKey = "Total-Item"
+	TotalPageHeaderKey = "Total-Page"
 )
@@ -0,0 +1,21 @@
+package ratelimit
+
+import "github.com/redis/go-redis/v9"
+
+type Option interface {
+	Apply(o *options)
+}
+
+type clientOption struct {
+	client redis.UniversalClient
+}
+
+func (o *clientOption) Apply(opts *options) {
+	opts.client = o.client
+}
+
+// WithRedisClient set redis client which stores the limiter state, default is redis.Client,
+// nil client stores the state in memory of this instance only
+func WithRedisClient(client redis.UniversalClient) Option {
+	return &clientOption{client: client}
+}
@@ -0,0 +1,244 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	goRedis "github.com/redis/go-redis/v9"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/redis"
+	"math"
+	"time"
+)
+
+const (
+	// TokenBucket allows burst up to limit, tokens are refilled continuously at limit per period
+	TokenBucket Algorithm = "tokenBucket"
+
+	// SlidingWindow allows limit per period, weighting previous window count by its overlap with the sliding period
+	SlidingWindow Algorithm = "slidingWindow"
+
+	keyFormat = "ratelimit:%s:%s"
+)
+
+var (
+	// tokenBucketScript takes a token of KEYS[1], ARGV[1] is limit and ARGV[2] is period in milliseconds,
+	// it returns allowed flag, remaining tokens, retry after and reset after in milliseconds
+	tokenBucketScript = goRedis.NewScript(`
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local rate = limit / period
+local s = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local tokens = tonumber(s[1]) or limit
+local last = tonumber(s[2]) or now
+tokens = math.min(limit, tokens + math.max(0, now - last) * rate)
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'last', now)
+redis.call('PEXPIRE', KEYS[1], period)
+return {allowed, math.floor(tokens), retry, math.ceil((limit - tokens) / rate)}`)
+
+	// slidingWindowScript counts a request of KEYS[1], ARGV[1] is limit and ARGV[2] is period in milliseconds,
+	// it returns allowed flag, remaining requests, retry after and reset after in milliseconds
+	slidingWindowScript = goRedis.NewScript(`
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local window = now - (now % period)
+local s = redis.call('HMGET', KEYS[1], 'window', 'current', 'previous')
+local w = tonumber(s[1]) or window
+local current = tonumber(s[2]) or 0
+local previous = tonumber(s[3]) or 0
+if w ~= window then
+	if w == window - period then
+		previous = current
+	else
+		previous = 0
+	end
+	current = 0
+end
+local frac = (now - window) / period
+local estimate = previous * (1 - frac) + current
+local allowed = 0
+local retry = 0
+if estimate + 1 <= limit then
+	current = current + 1
+	estimate = estimate + 1
+	allowed = 1
+elseif current + 1 > limit then
+	local f = 0
+	if current > 0 then
+		f = math.max(0, 1 - (limit - 1) / current)
+	end
+	retry = math.ceil(window + period - now + f * period)
+else
+	retry = math.ceil((1 - (limit - 1 - current) / previous - frac) * period)
+end
+redis.call('HSET', KEYS[1], 'window', window, 'current', current, 'previous', previous)
+redis.call('PEXPIRE', KEYS[1], period * 2)
+return {allowed, math.max(0, limit - math.ceil(estimate)), retry, window + period - now}`)
+)
+
+// New returns rate limiter which allows limit requests per period of each key, name is used as key prefix.
+// State is stored in redis.Client by default so the limit is shared across replicas,
+// it must be called after redis package is initiated, state is stored in memory if no redis client is available.
+// Example to use
+//
+//	limiter := ratelimit.New("api", ratelimit.TokenBucket, 100, time.Minute)
+//	result, err := limiter.Allow(ctx, clientIp)
+func New(name string, algorithm Algorithm, limit int, period time.Duration, opts ...Option) Limiter {
+	ctx := context.Background()
+	o := &options{client: redis.Client}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.Apply(o)
+		}
+	}
+
+	if algorithm != TokenBucket && algorithm != SlidingWindow {
+		log.Warnf(ctx, "Unknown rate limit algorithm, use %s instead, name=%s, algorithm=%s", TokenBucket, name, algorithm)
+		algorithm = TokenBucket
+	}
+	if limit < integer.One {
+		limit = integer.One
+	}
+	if period < time.Millisecond {
+		period = time.Millisecond
+	}
+	if o.client == nil {
+		log.Warnf(ctx, "Redis client is not available, rate limit state is stored in memory, name=%s", name)
+	}
+
+	return &limiterImpl{
+		name:      name,
+		algorithm: algorithm,
+		limit:     limit,
+		period:    period,
+		client:    o.client,
+		states:    make(map[string]*state),
+		lastSweep: time.Now(),
+	}
+}
+
+func (l *limiterImpl) Allow(ctx context.Context, key string) (*Result, error) {
+	if l.client != nil {
+		return l.allowRedis(ctx, key)
+	}
+	return l.allowMemory(time.Now(), key), nil
+}
+
+func (l *limiterImpl) allowRedis(ctx context.Context, key string) (*Result, error) {
+	script := tokenBucketScript
+	if l.algorithm == SlidingWindow {
+		script = slidingWindowScript
+	}
+
+	values, err := script.Run(ctx, l.client, []string{fmt.Sprintf(keyFormat, l.name, key)}, l.limit, l.period.Milliseconds()).Int64Slice()
+	if err != nil {
+		log.Errorf(ctx, err, "Failed to run rate limit script, name=%s, key=%s", l.name, key)
+		return nil, err
+	}
+	return &Result{
+		Allowed:    values[0] == integer.One,
+		Limit:      l.limit,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
+
+func (l *limiterImpl) allowMemory(now time.Time, key string) *Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	s, ok := l.states[key]
+	if !ok {
+		s = &state{tokens: float64(l.limit), last: now, window: now.Truncate(l.period)}
+		l.states[key] = s
+	}
+
+	var result *Result
+	if l.algorithm == SlidingWindow {
+		result = l.slidingWindow(now, s)
+	} else {
+		result = l.tokenBucket(now, s)
+	}
+	s.last = now
+	return result
+}
+
+func (l *limiterImpl) tokenBucket(now time.Time, s *state) *Result {
+	limit := float64(l.limit)
+	rate := limit / float64(l.period)
+	s.tokens = math.Min(limit, s.tokens+float64(now.Sub(s.last))*rate)
+
+	result := &Result{Limit: l.limit}
+	if s.tokens >= 1 {
+		s.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - s.tokens) / rate))
+	}
+	result.Remaining = int(s.tokens)
+	result.ResetAfter = time.Duration(math.Ceil((limit - s.tokens) / rate))
+	return result
+}
+
+func (l *limiterImpl) slidingWindow(now time.Time, s *state) *Result {
+	window := now.Truncate(l.period)
+	if !s.window.Equal(window) {
+		if s.window.Equal(window.Add(-l.period)) {
+			s.previous = s.current
+		} else {
+			s.previous = integer.Zero
+		}
+		s.current = integer.Zero
+		s.window = window
+	}
+
+	limit := float64(l.limit)
+	period := float64(l.period)
+	frac := float64(now.Sub(window)) / period
+	estimate := float64(s.previous)*(1-frac) + float64(s.current)
+
+	result := &Result{Limit: l.limit, ResetAfter: window.Add(l.period).Sub(now)}
+	switch {
+	case estimate+1 <= limit:
+		s.current++
+		estimate++
+		result.Allowed = true
+	case float64(s.current)+1 > limit:
+		// Wait for next window, until weighted current count leaves room for one request
+		f := math.Max(0, 1-(limit-1)/float64(s.current))
+		result.RetryAfter = result.ResetAfter + time.Duration(math.Ceil(f*period))
+	default:
+		// Wait until previous window overlap shrinks enough
+		f := 1 - (limit-1-float64(s.current))/float64(s.previous)
+		result.RetryAfter = time.Duration(math.Ceil((f - frac) * period))
+	}
+	result.Remaining = int(math.Max(0, limit-math.Ceil(estimate)))
+	return result
+}
+
+// sweep removes state of keys which are idle for 2 periods, at most once per period
+func (l *limiterImpl) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.period {
+		return
+	}
+	l.lastSweep = now
+	for key, s := range l.states {
+		if now.Sub(s.last) > 2*l.period {
+			delete(l.states, key)
+		}
+	}
+}
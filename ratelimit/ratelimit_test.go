@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+var ctx context.Context
+
+type RateLimitTestSuite struct {
+	suite.Suite
+}
+
+func (s *RateLimitTestSuite) SetupTest() {
+	ctx = context.Background()
+}
+
+func TestRateLimitTestSuite(t *testing.T) {
+	suite.Run(t, new(RateLimitTestSuite))
+}
+
+func (s *RateLimitTestSuite) TestTokenBucket() {
+	l := New("test", TokenBucket, 2, time.Second, WithRedisClient(nil)).(*limiterImpl)
+	now := time.Now()
+
+	s.True(l.allowMemory(now, "a").Allowed)
+	result := l.allowMemory(now, "a")
+	s.True(result.Allowed)
+	s.Equal(0, result.Remaining)
+
+	result = l.allowMemory(now, "a")
+	s.False(result.Allowed)
+	s.Equal(500*time.Millisecond, result.RetryAfter)
+
+	// Other key has its own bucket
+	s.True(l.allowMemory(now, "b").Allowed)
+
+	// Half period refills one token
+	s.True(l.allowMemory(now.Add(500*time.Millisecond), "a").Allowed)
+}
+
+func (s *RateLimitTestSuite) TestSlidingWindow() {
+	l := New("test", SlidingWindow, 2, time.Minute, WithRedisClient(nil)).(*limiterImpl)
+	window := time.Now().Truncate(time.Minute)
+
+	s.True(l.allowMemory(window, "a").Allowed)
+	s.True(l.allowMemory(window.Add(time.Second), "a").Allowed)
+	result := l.allowMemory(window.Add(2*time.Second), "a")
+	s.False(result.Allowed)
+	s.Equal(58*time.Second, result.ResetAfter)
+
+	// A quarter into next window, previous window still weighs 1.5 requests
+	s.False(l.allowMemory(window.Add(75*time.Second), "a").Allowed)
+
+	// Halfway into next window, previous window weighs 1 request
+	result = l.allowMemory(window.Add(90*time.Second), "a")
+	s.True(result.Allowed)
+	s.Equal(0, result.Remaining)
+}
+
+func (s *RateLimitTestSuite) TestSweep() {
+	l := New("test", TokenBucket, 1, time.Second, WithRedisClient(nil)).(*limiterImpl)
+	now := time.Now()
+	l.allowMemory(now, "a")
+	l.allowMemory(now.Add(3*time.Second), "b")
+	s.NotContains(l.states, "a")
+	s.Contains(l.states, "b")
+}
@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"time"
+)
+
+type Algorithm string
+
+type Limiter interface {
+	// Allow takes one request of the key, the request is rejected if result is not allowed
+	Allow(ctx context.Context, key string) (*Result, error)
+}
+
+// Result is state of the key after a request is taken
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+
+	// ResetAfter is duration until the key is fully replenished
+	ResetAfter time.Duration
+
+	// RetryAfter is duration until next request of the key is allowed, zero if request is allowed
+	RetryAfter time.Duration
+}
+
+type limiterImpl struct {
+	name      string
+	algorithm Algorithm
+	limit     int
+	period    time.Duration
+	client    redis.UniversalClient
+
+	// In-memory state of each key, used if redis client is not available
+	states    map[string]*state
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+// state is token bucket (tokens and last refill time) or sliding window (window start, current and previous window count)
+type state struct {
+	tokens   float64
+	last     time.Time
+	window   time.Time
+	current  int
+	previous int
+}
+
+type options struct {
+	client redis.UniversalClient
+}
@@ -19,9 +19,12 @@ import (
 )
 
 const (
+	healthCheckName = "redis"
+
 	pong         = "PONG"
 	modeSingle   = "single"
 	modeSentinel = "sentinel"
+	modeCluster  = "cluster"
 )
 
 var (
@@ -66,8 +69,19 @@ func Init(ctx context.Context, config config.Config) {
 			return
 		}
 		log.Infof(ctx, "Redis client is initiated in sentinel mode, address=%s, db=%d", cfg.SentinelAddrs, *cfg.DB)
+	} else if mode == modeCluster {
+		if len(cfg.ClusterAddrs) == integer.Zero {
+			log.Fatal(ctx, response.ConfigNotFound, "Missing redis cluster addresses")
+			return
+		}
+		err := initClusterMode(ctx, cfg)
+		if err != nil {
+			log.Fatal(ctx, err, "Redis cluster mode init failed")
+			return
+		}
+		log.Infof(ctx, "Redis client is initiated in cluster mode, address=%s", cfg.ClusterAddrs)
 	} else {
-		log.Fatalf(ctx, response.InvalidConfig, "Unsupported mode '%s', valid mode are single or sentinel", mode)
+		log.Fatalf(ctx, response.InvalidConfig, "Unsupported mode '%s', valid mode are single, sentinel or cluster", mode)
 		return
 	}
 
@@ -80,7 +94,7 @@ func Init(ctx context.Context, config config.Config) {
 	Locker = redislock.New(Client)
 
 	// Add health checker
-	healthcheck.AddChecker("redis", func(ctx context.Context) error {
+	healthcheck.AddCheckerWithConfig(healthCheckName, cfg.HealthCheck, func(ctx context.Context) error {
 		res, err := Client.Ping(ctx).Result()
 		if err != nil {
 			return err
@@ -93,12 +107,30 @@ func Init(ctx context.Context, config config.Config) {
 }
 
 func initSingleMode(ctx context.Context, cfg *config.RedisConfig) error {
+	option, err := newOptions(cfg)
+	if err != nil {
+		log.Error(ctx, err, "Invalid redis config")
+		return err
+	}
+
+	Client = redis.NewClient(option)
+	ping, err := Client.Ping(ctx).Result()
+	if err != nil {
+		log.Error(ctx, err, "Ping failed")
+		return err
+	}
+	log.Trace(ctx, "Redis ping status:", ping)
+	return nil
+}
+
+// newOptions returns client options of single mode config
+func newOptions(cfg *config.RedisConfig) (*redis.Options, error) {
 	singleConfig := cfg
 	option := &redis.Options{
 		Addr: singleConfig.Addr,
 	}
 	if singleConfig.DB == nil {
-		return response.InvalidConfig
+		return nil, response.InvalidConfig
 	}
 	if !utils.IsZeroValue(singleConfig.DB) {
 		option.DB = *singleConfig.DB
@@ -151,8 +183,22 @@ func initSingleMode(ctx context.Context, cfg *config.RedisConfig) error {
 	if !utils.IsZeroValue(singleConfig.ConnMaxLifetime) {
 		option.ConnMaxLifetime = singleConfig.ConnMaxLifetime.Duration
 	}
+	tlsConfig, err := utils.NewTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	option.TLSConfig = tlsConfig
+	return option, nil
+}
 
-	Client = redis.NewClient(option)
+func initSentinelMode(ctx context.Context, cfg *config.RedisConfig) error {
+	option, err := newFailoverOptions(cfg)
+	if err != nil {
+		log.Error(ctx, err, "Invalid redis config")
+		return err
+	}
+
+	Client = redis.NewFailoverClient(option)
 	ping, err := Client.Ping(ctx).Result()
 	if err != nil {
 		log.Error(ctx, err, "Ping failed")
@@ -162,14 +208,15 @@ func initSingleMode(ctx context.Context, cfg *config.RedisConfig) error {
 	return nil
 }
 
-func initSentinelMode(ctx context.Context, cfg *config.RedisConfig) error {
+// newFailoverOptions returns client options of sentinel mode config
+func newFailoverOptions(cfg *config.RedisConfig) (*redis.FailoverOptions, error) {
 	sentinelConfig := cfg
 	option := &redis.FailoverOptions{
 		MasterName:    sentinelConfig.MasterName,
 		SentinelAddrs: sentinelConfig.SentinelAddrs,
 	}
 	if sentinelConfig.DB == nil {
-		return response.InvalidConfig
+		return nil, response.InvalidConfig
 	}
 	if !utils.IsZeroValue(sentinelConfig.DB) {
 		option.DB = *sentinelConfig.DB
@@ -237,8 +284,22 @@ func initSentinelMode(ctx context.Context, cfg *config.RedisConfig) error {
 	if !utils.IsZeroValue(sentinelConfig.UseDisconnectedReplicas) {
 		option.UseDisconnectedReplicas = *sentinelConfig.UseDisconnectedReplicas
 	}
+	tlsConfig, err := utils.NewTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	option.TLSConfig = tlsConfig
+	return option, nil
+}
 
-	Client = redis.NewFailoverClient(option)
+func initClusterMode(ctx context.Context, cfg *config.RedisConfig) error {
+	option, err := newClusterOptions(cfg)
+	if err != nil {
+		log.Error(ctx, err, "Invalid redis config")
+		return err
+	}
+
+	Client = redis.NewClusterClient(option)
 	ping, err := Client.Ping(ctx).Result()
 	if err != nil {
 		log.Error(ctx, err, "Ping failed")
@@ -248,6 +309,77 @@ func initSentinelMode(ctx context.Context, cfg *config.RedisConfig) error {
 	return nil
 }
 
+// newClusterOptions returns client options of cluster mode config
+func newClusterOptions(cfg *config.RedisConfig) (*redis.ClusterOptions, error) {
+	clusterConfig := cfg
+	option := &redis.ClusterOptions{
+		Addrs: clusterConfig.ClusterAddrs,
+	}
+	if !utils.IsZeroValue(clusterConfig.MaxRedirects) {
+		option.MaxRedirects = *clusterConfig.MaxRedirects
+	}
+	if !utils.IsZeroValue(clusterConfig.ReadOnly) {
+		option.ReadOnly = *clusterConfig.ReadOnly
+	}
+	if !utils.IsZeroValue(clusterConfig.RouteByLatency) {
+		option.RouteByLatency = *clusterConfig.RouteByLatency
+	}
+	if !utils.IsZeroValue(clusterConfig.RouteRandomly) {
+		option.RouteRandomly = *clusterConfig.RouteRandomly
+	}
+	if !utils.IsZeroValue(clusterConfig.Username) {
+		option.Username = *clusterConfig.Username
+	}
+	if !utils.IsZeroValue(clusterConfig.Password) {
+		option.Password = *clusterConfig.Password
+	}
+	if !utils.IsZeroValue(clusterConfig.MaxRetries) {
+		option.MaxRetries = *clusterConfig.MaxRetries
+	}
+	if !utils.IsZeroValue(clusterConfig.MinRetryBackoff) {
+		option.MinRetryBackoff = clusterConfig.MinRetryBackoff.Duration
+	}
+	if !utils.IsZeroValue(clusterConfig.MaxRetryBackoff) {
+		option.MaxRetryBackoff = clusterConfig.MaxRetryBackoff.Duration
+	}
+	if !utils.IsZeroValue(clusterConfig.DialTimeout) {
+		option.DialTimeout = clusterConfig.DialTimeout.Duration
+	}
+	if !utils.IsZeroValue(clusterConfig.ReadTimeout) {
+		option.ReadTimeout = clusterConfig.ReadTimeout.Duration
+	}
+	if !utils.IsZeroValue(clusterConfig.WriteTimeout) {
+		option.WriteTimeout = clusterConfig.WriteTimeout.Duration
+	}
+	if !utils.IsZeroValue(clusterConfig.PoolFIFO) {
+		option.PoolFIFO = *clusterConfig.PoolFIFO
+	}
+	if !utils.IsZeroValue(clusterConfig.PoolSize) {
+		option.PoolSize = *clusterConfig.PoolSize
+	}
+	if !utils.IsZeroValue(clusterConfig.PoolTimeout) {
+		option.PoolTimeout = clusterConfig.PoolTimeout.Duration
+	}
+	if !utils.IsZeroValue(clusterConfig.MinIdleConns) {
+		option.MinIdleConns = *clusterConfig.MinIdleConns
+	}
+	if !utils.IsZeroValue(clusterConfig.MaxIdleConns) {
+		option.MaxIdleConns = *clusterConfig.MaxIdleConns
+	}
+	if !utils.IsZeroValue(clusterConfig.ConnMaxIdleTime) {
+		option.ConnMaxIdleTime = clusterConfig.ConnMaxIdleTime.Duration
+	}
+	if !utils.IsZeroValue(clusterConfig.ConnMaxLifetime) {
+		option.ConnMaxLifetime = clusterConfig.ConnMaxLifetime.Duration
+	}
+	tlsConfig, err := utils.NewTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	option.TLSConfig = tlsConfig
+	return option, nil
+}
+
 func (i Int) MarshalBinary() ([]byte, error) {
 	return []byte(strconv.Itoa(int(i))), nil
 }
@@ -0,0 +1,125 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/response"
+	"github.com/rosaekapratama/go-starter/yaml"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type RedisTestSuite struct {
+	suite.Suite
+	server *miniredis.Miniredis
+}
+
+func (s *RedisTestSuite) SetupTest() {
+	s.server = miniredis.RunT(s.T())
+}
+
+func (s *RedisTestSuite) TearDownTest() {
+	if Client != nil {
+		_ = Client.Close()
+		Client = nil
+	}
+}
+
+func TestRedisTestSuite(t *testing.T) {
+	suite.Run(t, new(RedisTestSuite))
+}
+
+func (s *RedisTestSuite) TestInitClusterMode() {
+	ctx := context.Background()
+	s.Require().NoError(initClusterMode(ctx, &config.RedisConfig{
+		Mode:               modeCluster,
+		RedisClusterConfig: config.RedisClusterConfig{ClusterAddrs: []string{s.server.Addr()}},
+	}))
+	s.Require().NoError(Client.Set(ctx, "key", "value", 0).Err())
+	v, err := s.server.Get("key")
+	s.Require().NoError(err)
+	s.Equal("value", v)
+
+	// Invalid TLS config fails before connecting
+	s.Error(initClusterMode(ctx, &config.RedisConfig{
+		Mode:               modeCluster,
+		RedisClusterConfig: config.RedisClusterConfig{ClusterAddrs: []string{s.server.Addr()}},
+		TLS:                &config.TLSConfig{Enabled: true, CaFile: "not-found.crt"},
+	}))
+}
+
+func (s *RedisTestSuite) TestClusterOptions() {
+	maxRedirects, poolSize := 5, 20
+	readOnly, routeByLatency := true, true
+	username, password := "user", "secret"
+	option, err := newClusterOptions(&config.RedisConfig{
+		RedisClusterConfig: config.RedisClusterConfig{
+			ClusterAddrs: []string{"node1:6379", "node2:6379"},
+			MaxRedirects: &maxRedirects,
+			ReadOnly:     &readOnly,
+		},
+		RedisSingleConfig: config.RedisSingleConfig{
+			Username:    &username,
+			Password:    &password,
+			PoolSize:    &poolSize,
+			DialTimeout: &yaml.Duration{Duration: 2 * time.Second},
+		},
+		RedisSentinelConfig: config.RedisSentinelConfig{RouteByLatency: &routeByLatency},
+		TLS:                 &config.TLSConfig{Enabled: true, ServerName: "redis.local"},
+	})
+	s.Require().NoError(err)
+	s.Equal([]string{"node1:6379", "node2:6379"}, option.Addrs)
+	s.Equal(5, option.MaxRedirects)
+	s.True(option.ReadOnly)
+	s.True(option.RouteByLatency)
+	s.False(option.RouteRandomly)
+	s.Equal("user", option.Username)
+	s.Equal("secret", option.Password)
+	s.Equal(20, option.PoolSize)
+	s.Equal(2*time.Second, option.DialTimeout)
+	s.Require().NotNil(option.TLSConfig)
+	s.Equal("redis.local", option.TLSConfig.ServerName)
+	s.Equal(uint16(tls.VersionTLS12), option.TLSConfig.MinVersion)
+
+	// TLS is disabled by default
+	option, err = newClusterOptions(&config.RedisConfig{RedisClusterConfig: config.RedisClusterConfig{ClusterAddrs: []string{"node1:6379"}}})
+	s.Require().NoError(err)
+	s.Nil(option.TLSConfig)
+}
+
+func (s *RedisTestSuite) TestFailoverOptions() {
+	db := 2
+	replicaOnly := true
+	sentinelPassword := "sentinel-secret"
+	option, err := newFailoverOptions(&config.RedisConfig{
+		RedisSingleConfig: config.RedisSingleConfig{DB: &db},
+		RedisSentinelConfig: config.RedisSentinelConfig{
+			MasterName:       "mymaster",
+			SentinelAddrs:    []string{"sentinel1:26379"},
+			SentinelPassword: &sentinelPassword,
+			ReplicaOnly:      &replicaOnly,
+		},
+		TLS: &config.TLSConfig{Enabled: true, InsecureSkipVerify: true},
+	})
+	s.Require().NoError(err)
+	s.Equal("mymaster", option.MasterName)
+	s.Equal([]string{"sentinel1:26379"}, option.SentinelAddrs)
+	s.Equal(2, option.DB)
+	s.Equal("sentinel-secret", option.SentinelPassword)
+	s.True(option.ReplicaOnly)
+	s.Require().NotNil(option.TLSConfig)
+	s.True(option.TLSConfig.InsecureSkipVerify)
+
+	// DB is required by sentinel mode
+	_, err = newFailoverOptions(&config.RedisConfig{RedisSentinelConfig: config.RedisSentinelConfig{MasterName: "mymaster"}})
+	s.ErrorIs(err, response.InvalidConfig)
+
+	_, err = newFailoverOptions(&config.RedisConfig{
+		RedisSingleConfig: config.RedisSingleConfig{DB: &db},
+		TLS:               &config.TLSConfig{Enabled: true, CertFile: "not-found.crt", KeyFile: "not-found.key"},
+	})
+	s.Error(err)
+}
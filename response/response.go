@@ -33,6 +33,10 @@ const (
 	MissingGoogleToken
 	FileSizeMustBeGreaterThanZero
 	PageSizeExceedsMaxLimit
+	TooManyRequests
+	IdempotencyKeyInProgress
+	IdempotencyKeyReused
+	CircuitBreakerOpen
 )
 
 var (
@@ -61,6 +65,10 @@ var (
 		MissingGoogleToken:            "missing google token",
 		FileSizeMustBeGreaterThanZero: "file size must be greater than zero",
 		PageSizeExceedsMaxLimit:       "page size exceeds the maximum limit of 100",
+		TooManyRequests:               "too many requests",
+		IdempotencyKeyInProgress:      "request with the same idempotency key is in progress",
+		IdempotencyKeyReused:          "idempotency key is already used for a different request",
+		CircuitBreakerOpen:            "circuit breaker is open",
 	}
 
 	httpStatusCodeMap = map[Response]int{
@@ -88,6 +96,10 @@ var (
 		MissingGoogleToken:            http.StatusBadRequest,
 		FileSizeMustBeGreaterThanZero: http.StatusBadRequest,
 		PageSizeExceedsMaxLimit:       http.StatusBadRequest,
+		TooManyRequests:               http.StatusTooManyRequests,
+		IdempotencyKeyInProgress:      http.StatusConflict,
+		IdempotencyKeyReused:          http.StatusUnprocessableEntity,
+		CircuitBreakerOpen:            http.StatusServiceUnavailable,
 	}
 
 	otelCodeMap = map[Response]otelCodes.Code{
@@ -115,6 +127,10 @@ var (
 		MissingGoogleToken:            otelCodes.Ok,
 		FileSizeMustBeGreaterThanZero: otelCodes.Ok,
 		PageSizeExceedsMaxLimit:       otelCodes.Ok,
+		TooManyRequests:               otelCodes.Ok,
+		IdempotencyKeyInProgress:      otelCodes.Ok,
+		IdempotencyKeyReused:          otelCodes.Ok,
+		CircuitBreakerOpen:            otelCodes.Error,
 	}
 
 	grpcCodeMap = map[Response]grpcCodes.Code{
@@ -142,6 +158,10 @@ var (
 		MissingGoogleToken:            grpcCodes.FailedPrecondition,
 		FileSizeMustBeGreaterThanZero: grpcCodes.InvalidArgument,
 		PageSizeExceedsMaxLimit:       grpcCodes.InvalidArgument,
+		TooManyRequests:               grpcCodes.ResourceExhausted,
+		IdempotencyKeyInProgress:      grpcCodes.Aborted,
+		IdempotencyKeyReused:          grpcCodes.FailedPrecondition,
+		CircuitBreakerOpen:            grpcCodes.Unavailable,
 	}
 
 	isErrorMap = map[Response]bool{
@@ -169,6 +189,10 @@ var (
 		MissingGoogleToken:            true,
 		FileSizeMustBeGreaterThanZero: false,
 		PageSizeExceedsMaxLimit:       false,
+		TooManyRequests:               false,
+		IdempotencyKeyInProgress:      false,
+		IdempotencyKeyReused:          false,
+		CircuitBreakerOpen:            true,
 	}
 )
 
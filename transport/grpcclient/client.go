@@ -3,84 +3,223 @@ package grpcclient
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/inhies/go-bytesize"
 	"github.com/rosaekapratama/go-starter/config"
 	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/healthcheck"
 	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/oauth2"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/connectivity"
 )
 
+const healthCheckNameFormat = "grpc:%s"
+
 var (
-	defaultPayloadLogSizeLimit = "1KB"
-	errGRPCClientConnNotFound  = errors.New("GRPC client connection not found")
+	defaultPayloadLogSizeLimit     = "1KB"
+	errGRPCClientConnNotFound      = errors.New("GRPC client connection not found")
+	errGRPCClientConnAlreadyExists = errors.New("GRPC client connection already exists")
 
 	Manager IManager
 )
 
 func Init(ctx context.Context, config config.Config) {
-	Manager = &managerImpl{connMap: make(map[string]*grpc.ClientConn)}
+	Manager = newManager()
 	connMap := config.GetObject().Transport.Client.Grpc
 	for connId, connConfig := range connMap {
-		var stdoutLogging bool
-		var databaseLogging string
-		payloadLogSizeLimit := defaultPayloadLogSizeLimit
-		if connConfig.Logging != nil {
-			stdoutLogging = connConfig.Logging.Stdout
-			if connConfig.Logging.Database != str.Empty {
-				databaseLogging = connConfig.Logging.Database
+		if err := Manager.AddConn(ctx, connId, connConfig); err != nil {
+			log.Fatalf(ctx, err, "error on init GRPC connection, connId=%s", connId)
+			return
+		}
+	}
+}
+
+func newManager() *managerImpl {
+	return &managerImpl{connMap: make(map[string]*lazyConn)}
+}
+
+// connStateChecker returns health checker which fails if connection is broken,
+// idle connection is asked to reconnect and given a chance to get ready before the check times out
+func connStateChecker(conn *grpc.ClientConn) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		state := conn.GetState()
+		if state == connectivity.Idle {
+			conn.Connect()
+		}
+		for state != connectivity.Ready {
+			if state == connectivity.Shutdown {
+				return fmt.Errorf("connection state is %s", state)
 			}
-			if connConfig.Logging.PayloadLogSizeLimit != str.Empty {
-				payloadLogSizeLimit = connConfig.Logging.PayloadLogSizeLimit
+			if !conn.WaitForStateChange(ctx, state) {
+				return fmt.Errorf("connection state is %s", state)
 			}
+			state = conn.GetState()
 		}
-		_payloadLogSizeLimit, err := bytesize.Parse(payloadLogSizeLimit)
-		if err != nil {
-			log.Fatal(ctx, err, "Invalid value of GRPC client payloadLogSizeLimit config")
-		}
+		return nil
+	}
+}
 
-		opts := make([]grpc.DialOption, 0)
-		if connConfig.Insecure {
-			opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// watchState logs state changes of the connection until it is closed,
+// connection which turns idle after losing its transport is asked to reconnect
+func watchState(connId string, conn *grpc.ClientConn) {
+	ctx := context.Background()
+	state := conn.GetState()
+	for state != connectivity.Shutdown {
+		conn.WaitForStateChange(ctx, state)
+		prevState := state
+		state = conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			log.Infof(ctx, "GRPC client connection is ready, connId=%s", connId)
+		case connectivity.TransientFailure:
+			log.Warnf(ctx, "GRPC client connection failed, retrying with backoff, connId=%s", connId)
+		case connectivity.Idle:
+			if prevState == connectivity.Ready {
+				log.Warnf(ctx, "GRPC client connection is lost, reconnecting, connId=%s", connId)
+				conn.Connect()
+			}
+		case connectivity.Shutdown:
+			log.Infof(ctx, "GRPC client connection is shut down, connId=%s", connId)
 		}
-		_, err = Manager.initConn(ctx, connId, connConfig.Address, stdoutLogging, databaseLogging, uint64(_payloadLogSizeLimit), opts...)
-		if err != nil {
-			log.Fatalf(ctx, err, "error on init GRPC connection, connId=%s", connId)
-		} else {
+	}
+}
 
+// dialOptions returns interceptor, credential and transport dial options of the connection config
+func dialOptions(ctx context.Context, connConfig *config.GrpcClientConfig) ([]grpc.DialOption, error) {
+	var stdoutLogging bool
+	payloadLogSizeLimit := defaultPayloadLogSizeLimit
+	if connConfig.Logging != nil {
+		stdoutLogging = connConfig.Logging.Stdout
+		if connConfig.Logging.PayloadLogSizeLimit != str.Empty {
+			payloadLogSizeLimit = connConfig.Logging.PayloadLogSizeLimit
 		}
 	}
-}
+	_payloadLogSizeLimit, err := bytesize.Parse(payloadLogSizeLimit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payloadLogSizeLimit, value=%s: %w", payloadLogSizeLimit, err)
+	}
 
-func (m managerImpl) initConn(ctx context.Context, connId string, address string, stdoutLogging bool, databaseLogging string, payloadLogSizeLimit uint64, opts ...grpc.DialOption) (conn *grpc.ClientConn, err error) {
 	grpcOptions := append([]grpc.DialOption{}, grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
 	metadataContextInterceptor := newMetadataContextInterceptor(ctx)
 	grpcOptions = append(grpcOptions, grpc.WithUnaryInterceptor(metadataContextInterceptor.unaryInterceptor))
 	grpcOptions = append(grpcOptions, grpc.WithStreamInterceptor(metadataContextInterceptor.streamInterceptor))
 	if stdoutLogging {
-		loggingInterceptor := newLoggingInterceptor(ctx, payloadLogSizeLimit)
+		loggingInterceptor := newLoggingInterceptor(ctx, uint64(_payloadLogSizeLimit))
 		grpcOptions = append(grpcOptions, grpc.WithUnaryInterceptor(loggingInterceptor.unaryInterceptor))
 		grpcOptions = append(grpcOptions, grpc.WithStreamInterceptor(loggingInterceptor.streamInterceptor))
 	}
+
+	opts, err := newDialOptions(connConfig)
+	if err != nil {
+		return nil, err
+	}
 	grpcOptions = append(grpcOptions, opts...)
-	conn, err = grpc.Dial(address, grpcOptions...)
+
+	if connConfig.OAuth2 != nil {
+		provider, err := oauth2.NewTokenProvider(connConfig.OAuth2)
+		if err != nil {
+			return nil, fmt.Errorf("invalid oauth2 config: %w", err)
+		}
+		requireTransportSecurity := !connConfig.Insecure || (connConfig.TLS != nil && connConfig.TLS.Enabled)
+		grpcOptions = append(grpcOptions, grpc.WithPerRPCCredentials(NewPerRPCCredentials(provider, requireTransportSecurity)))
+	}
+	return grpcOptions, nil
+}
+
+func (m *managerImpl) AddConn(ctx context.Context, connId string, connConfig *config.GrpcClientConfig) (err error) {
+	opts, err := dialOptions(ctx, connConfig)
 	if err != nil {
-		log.Errorf(ctx, err, "failed to init GRPC client conn, connId=%s", connId)
+		log.Errorf(ctx, err, "Invalid GRPC client config, connId=%s", connId)
 		return
 	}
-	m.connMap[connId] = conn
-	log.Infof(ctx, "GRPC client connection is initiated, connId=%s, address=%s", connId, address)
+
+	m.mu.Lock()
+	if _, exists := m.connMap[connId]; exists {
+		m.mu.Unlock()
+		err = errGRPCClientConnAlreadyExists
+		log.Errorf(ctx, err, "GRPC client connection already exists, connId=%s", connId)
+		return
+	}
+	m.connMap[connId] = &lazyConn{address: connConfig.Address, opts: opts}
+	m.mu.Unlock()
+
+	// Add health checker, it dials the connection if it is not used yet
+	healthcheck.AddCheckerWithConfig(fmt.Sprintf(healthCheckNameFormat, connId), connConfig.HealthCheck, func(ctx context.Context) error {
+		conn, err := m.GetConn(ctx, connId)
+		if err != nil {
+			return err
+		}
+		return connStateChecker(conn)(ctx)
+	})
+	log.Infof(ctx, "GRPC client connection is added, connId=%s, address=%s", connId, connConfig.Address)
 	return
 }
 
-func (m managerImpl) GetConn(ctx context.Context, connId string) (conn *grpc.ClientConn, err error) {
-	if v, exists := m.connMap[connId]; exists {
-		conn = v
+func (m *managerImpl) GetConn(ctx context.Context, connId string) (conn *grpc.ClientConn, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lc, exists := m.connMap[connId]
+	if !exists {
+		err = errGRPCClientConnNotFound
+		log.Errorf(ctx, err, "GRPC client connection not found, connId=%s", connId)
 		return
 	}
 
-	err = errGRPCClientConnNotFound
-	log.Errorf(ctx, err, "GRPC client connection not found, connId=%s", connId)
+	if lc.conn == nil {
+		conn, err = grpc.Dial(lc.address, lc.opts...)
+		if err != nil {
+			log.Errorf(ctx, err, "failed to init GRPC client conn, connId=%s", connId)
+			return
+		}
+		lc.conn = conn
+		go watchState(connId, conn)
+		log.Infof(ctx, "GRPC client connection is initiated, connId=%s, address=%s", connId, lc.address)
+	}
+	conn = lc.conn
+	return
+}
+
+func (m *managerImpl) Close(ctx context.Context, connId string) (err error) {
+	m.mu.Lock()
+	lc, exists := m.connMap[connId]
+	delete(m.connMap, connId)
+	m.mu.Unlock()
+
+	if !exists {
+		err = errGRPCClientConnNotFound
+		log.Errorf(ctx, err, "GRPC client connection not found, connId=%s", connId)
+		return
+	}
+	return closeConn(ctx, connId, lc)
+}
+
+func (m *managerImpl) CloseAll(ctx context.Context) (err error) {
+	m.mu.Lock()
+	connMap := m.connMap
+	m.connMap = make(map[string]*lazyConn)
+	m.mu.Unlock()
+
+	errs := make([]error, 0)
+	for connId, lc := range connMap {
+		if err := closeConn(ctx, connId, lc); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closeConn unregisters health checker of the connection and closes it if it is dialed
+func closeConn(ctx context.Context, connId string, lc *lazyConn) (err error) {
+	healthcheck.RemoveChecker(fmt.Sprintf(healthCheckNameFormat, connId))
+	if lc.conn != nil {
+		if err = lc.conn.Close(); err != nil {
+			log.Errorf(ctx, err, "failed to close GRPC client conn, connId=%s", connId)
+			return
+		}
+	}
+	log.Infof(ctx, "GRPC client connection is closed, connId=%s", connId)
 	return
 }
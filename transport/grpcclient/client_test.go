@@ -0,0 +1,171 @@
+package grpcclient
+
+import (
+	"context"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/yaml"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"net"
+	"testing"
+	"time"
+)
+
+type ClientTestSuite struct {
+	suite.Suite
+	server  *grpc.Server
+	address string
+}
+
+func (s *ClientTestSuite) SetupTest() {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().NoError(err)
+	s.server = grpc.NewServer()
+	healthpb.RegisterHealthServer(s.server, health.NewServer())
+	go func(server *grpc.Server) {
+		_ = server.Serve(lis)
+	}(s.server)
+	s.address = lis.Addr().String()
+}
+
+func (s *ClientTestSuite) TearDownTest() {
+	s.server.Stop()
+}
+
+func TestClientTestSuite(t *testing.T) {
+	suite.Run(t, new(ClientTestSuite))
+}
+
+func (s *ClientTestSuite) TestServiceConfig() {
+	sc, err := newServiceConfig(&config.GrpcClientConfig{
+		LoadBalancing: "round_robin",
+		Retry: &config.GrpcClientRetryConfig{
+			MaxAttempts:          4,
+			MaxBackoff:           &yaml.Duration{Duration: 2 * time.Second},
+			RetryableStatusCodes: []string{"unavailable", "RESOURCE_EXHAUSTED"},
+		},
+	})
+	s.Require().NoError(err)
+	s.JSONEq(`{
+		"loadBalancingConfig": [{"round_robin": {}}],
+		"methodConfig": [{
+			"name": [{}],
+			"retryPolicy": {
+				"maxAttempts": 4,
+				"initialBackoff": "0.1s",
+				"maxBackoff": "2s",
+				"backoffMultiplier": 2,
+				"retryableStatusCodes": ["UNAVAILABLE", "RESOURCE_EXHAUSTED"]
+			}
+		}]
+	}`, sc)
+
+	sc, err = newServiceConfig(&config.GrpcClientConfig{LoadBalancing: "pick_first"})
+	s.Require().NoError(err)
+	s.JSONEq(`{"loadBalancingConfig": [{"pick_first": {}}]}`, sc)
+}
+
+func (s *ClientTestSuite) TestInvalidConfig() {
+	_, err := newDialOptions(&config.GrpcClientConfig{Insecure: true, MaxRecvMsgSize: "big"})
+	s.Error(err)
+
+	_, err = newDialOptions(&config.GrpcClientConfig{TLS: &config.TLSConfig{Enabled: true, CaFile: "not-found.crt"}})
+	s.Error(err)
+
+	// Unknown balancer is refused on dial
+	opts, err := newDialOptions(&config.GrpcClientConfig{Insecure: true, LoadBalancing: "unknown"})
+	s.Require().NoError(err)
+	_, err = grpc.Dial(s.address, opts...)
+	s.Error(err)
+}
+
+func (s *ClientTestSuite) TestConn() {
+	manager := newManager()
+	s.Require().NoError(manager.AddConn(context.Background(), "test", &config.GrpcClientConfig{
+		Address:        "dns:///" + s.address,
+		Insecure:       true,
+		LoadBalancing:  "round_robin",
+		Retry:          &config.GrpcClientRetryConfig{},
+		MaxRecvMsgSize: "16MB",
+		MaxSendMsgSize: "16MB",
+		Keepalive: &config.GrpcClientKeepaliveConfig{
+			Time:                &yaml.Duration{Duration: 30 * time.Second},
+			Timeout:             &yaml.Duration{Duration: 5 * time.Second},
+			PermitWithoutStream: true,
+		},
+	}))
+	conn, err := manager.GetConn(context.Background(), "test")
+	s.Require().NoError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	s.NoError(connStateChecker(conn)(ctx))
+
+	res, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	s.Require().NoError(err)
+	s.Equal(healthpb.HealthCheckResponse_SERVING, res.Status)
+
+	// Broken connection fails the check with its state
+	s.server.Stop()
+	s.Require().NoError(manager.Close(ctx, "test"))
+	s.ErrorContains(connStateChecker(conn)(ctx), "SHUTDOWN")
+}
+
+func (s *ClientTestSuite) TestLazyConn() {
+	ctx := context.Background()
+	manager := newManager()
+	s.Require().NoError(manager.AddConn(ctx, "test", &config.GrpcClientConfig{Address: s.address, Insecure: true}))
+	s.ErrorIs(manager.AddConn(ctx, "test", &config.GrpcClientConfig{Address: s.address, Insecure: true}), errGRPCClientConnAlreadyExists)
+	s.Error(manager.AddConn(ctx, "invalid", &config.GrpcClientConfig{Insecure: true, MaxSendMsgSize: "big"}))
+	s.Nil(manager.connMap["test"].conn)
+
+	conn, err := manager.GetConn(ctx, "test")
+	s.Require().NoError(err)
+	same, err := manager.GetConn(ctx, "test")
+	s.Require().NoError(err)
+	s.Same(conn, same)
+
+	s.Require().NoError(manager.Close(ctx, "test"))
+	s.Equal(connectivity.Shutdown, conn.GetState())
+	_, err = manager.GetConn(ctx, "test")
+	s.ErrorIs(err, errGRPCClientConnNotFound)
+	s.ErrorIs(manager.Close(ctx, "test"), errGRPCClientConnNotFound)
+
+	// Connection is registered again after it is closed, not dialed one is closed as well
+	s.Require().NoError(manager.AddConn(ctx, "test", &config.GrpcClientConfig{Address: s.address, Insecure: true}))
+	s.Require().NoError(manager.AddConn(ctx, "other", &config.GrpcClientConfig{Address: s.address, Insecure: true}))
+	conn, err = manager.GetConn(ctx, "test")
+	s.Require().NoError(err)
+	s.NoError(manager.CloseAll(ctx))
+	s.Equal(connectivity.Shutdown, conn.GetState())
+	s.Empty(manager.connMap)
+}
+
+func (s *ClientTestSuite) TestReconnect() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	manager := newManager()
+	s.Require().NoError(manager.AddConn(ctx, "test", &config.GrpcClientConfig{Address: s.address, Insecure: true}))
+	defer manager.CloseAll(ctx)
+	conn, err := manager.GetConn(ctx, "test")
+	s.Require().NoError(err)
+	s.Require().NoError(connStateChecker(conn)(ctx))
+
+	// Connection is reestablished without any RPC once the server is back
+	s.server.Stop()
+	for state := conn.GetState(); state == connectivity.Ready; state = conn.GetState() {
+		s.Require().True(conn.WaitForStateChange(ctx, state))
+	}
+	lis, err := net.Listen("tcp", s.address)
+	s.Require().NoError(err)
+	s.server = grpc.NewServer()
+	go func(server *grpc.Server) {
+		_ = server.Serve(lis)
+	}(s.server)
+	for state := conn.GetState(); state != connectivity.Ready; state = conn.GetState() {
+		s.Require().True(conn.WaitForStateChange(ctx, state), "state=%s", state)
+	}
+}
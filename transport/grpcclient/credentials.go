@@ -0,0 +1,30 @@
+package grpcclient
+
+import (
+	"context"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/oauth2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+const authorizationMetadataKey = "authorization"
+
+// NewPerRPCCredentials returns per RPC credential which sends bearer token of the provider on every call,
+// the token is refused on insecure connection if requireTransportSecurity is true
+func NewPerRPCCredentials(provider oauth2.ITokenProvider, requireTransportSecurity bool) credentials.PerRPCCredentials {
+	return &tokenCredentials{provider: provider, requireTransportSecurity: requireTransportSecurity}
+}
+
+func (c *tokenCredentials) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	token, err := c.provider.Token(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "failed to get token, error=%v", err)
+	}
+	return map[string]string{authorizationMetadataKey: headers.BearerTokenPrefix + token}, nil
+}
+
+func (c *tokenCredentials) RequireTransportSecurity() bool {
+	return c.requireTransportSecurity
+}
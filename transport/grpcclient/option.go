@@ -0,0 +1,152 @@
+package grpcclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/inhies/go-bytesize"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts       = 3
+	defaultRetryInitialBackoff    = 100 * time.Millisecond
+	defaultRetryMaxBackoff        = time.Second
+	defaultRetryBackoffMultiplier = 2
+	defaultRetryableStatusCode    = "UNAVAILABLE"
+)
+
+// serviceConfig is gRPC service config, see https://github.com/grpc/grpc/blob/master/doc/service_config.md
+type serviceConfig struct {
+	LoadBalancingConfig []map[string]struct{} `json:"loadBalancingConfig,omitempty"`
+	MethodConfig        []methodConfig        `json:"methodConfig,omitempty"`
+}
+
+type methodConfig struct {
+	Name        []struct{}   `json:"name"`
+	RetryPolicy *retryPolicy `json:"retryPolicy,omitempty"`
+}
+
+type retryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+// newDialOptions returns transport credential, keepalive, message size and service config dial options of the connection config
+func newDialOptions(connConfig *config.GrpcClientConfig) ([]grpc.DialOption, error) {
+	opts := make([]grpc.DialOption, 0)
+
+	tlsConfig, err := utils.NewTLSConfig(connConfig.TLS)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case tlsConfig != nil:
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	case connConfig.Insecure:
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	default:
+		// Secure connection verified by system CA pool
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(nil)))
+	}
+
+	if connConfig.Keepalive != nil {
+		params := keepalive.ClientParameters{PermitWithoutStream: connConfig.Keepalive.PermitWithoutStream}
+		if connConfig.Keepalive.Time != nil {
+			params.Time = connConfig.Keepalive.Time.Duration
+		}
+		if connConfig.Keepalive.Timeout != nil {
+			params.Timeout = connConfig.Keepalive.Timeout.Duration
+		}
+		opts = append(opts, grpc.WithKeepaliveParams(params))
+	}
+
+	callOpts := make([]grpc.CallOption, 0)
+	if connConfig.MaxRecvMsgSize != str.Empty {
+		size, err := bytesize.Parse(connConfig.MaxRecvMsgSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maxRecvMsgSize, value=%s: %w", connConfig.MaxRecvMsgSize, err)
+		}
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(int(size)))
+	}
+	if connConfig.MaxSendMsgSize != str.Empty {
+		size, err := bytesize.Parse(connConfig.MaxSendMsgSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maxSendMsgSize, value=%s: %w", connConfig.MaxSendMsgSize, err)
+		}
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(int(size)))
+	}
+	if len(callOpts) > integer.Zero {
+		opts = append(opts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+
+	if connConfig.LoadBalancing != str.Empty || connConfig.Retry != nil {
+		sc, err := newServiceConfig(connConfig)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithDefaultServiceConfig(sc))
+	}
+	return opts, nil
+}
+
+// newServiceConfig returns JSON service config of load balancing policy and retry policy applied to every method,
+// service config given by the resolver (ex: DNS TXT record or xDS) takes precedence over it
+func newServiceConfig(connConfig *config.GrpcClientConfig) (string, error) {
+	sc := serviceConfig{}
+	if connConfig.LoadBalancing != str.Empty {
+		sc.LoadBalancingConfig = []map[string]struct{}{{connConfig.LoadBalancing: {}}}
+	}
+
+	if connConfig.Retry != nil {
+		policy := &retryPolicy{
+			MaxAttempts:          defaultRetryMaxAttempts,
+			InitialBackoff:       protoDuration(defaultRetryInitialBackoff),
+			MaxBackoff:           protoDuration(defaultRetryMaxBackoff),
+			BackoffMultiplier:    defaultRetryBackoffMultiplier,
+			RetryableStatusCodes: []string{defaultRetryableStatusCode},
+		}
+		if connConfig.Retry.MaxAttempts > integer.Zero {
+			policy.MaxAttempts = connConfig.Retry.MaxAttempts
+		}
+		if connConfig.Retry.InitialBackoff != nil && connConfig.Retry.InitialBackoff.Duration > integer.Zero {
+			policy.InitialBackoff = protoDuration(connConfig.Retry.InitialBackoff.Duration)
+		}
+		if connConfig.Retry.MaxBackoff != nil && connConfig.Retry.MaxBackoff.Duration > integer.Zero {
+			policy.MaxBackoff = protoDuration(connConfig.Retry.MaxBackoff.Duration)
+		}
+		if connConfig.Retry.BackoffMultiplier > integer.Zero {
+			policy.BackoffMultiplier = connConfig.Retry.BackoffMultiplier
+		}
+		if len(connConfig.Retry.RetryableStatusCodes) > integer.Zero {
+			policy.RetryableStatusCodes = make([]string, integer.Zero, len(connConfig.Retry.RetryableStatusCodes))
+			for _, code := range connConfig.Retry.RetryableStatusCodes {
+				policy.RetryableStatusCodes = append(policy.RetryableStatusCodes, strings.ToUpper(code))
+			}
+		}
+		// Empty name matches every method of every service
+		sc.MethodConfig = []methodConfig{{Name: []struct{}{{}}, RetryPolicy: policy}}
+	}
+
+	b, err := json.Marshal(sc)
+	if err != nil {
+		return str.Empty, err
+	}
+	return string(b), nil
+}
+
+// protoDuration returns duration in JSON format of google.protobuf.Duration, ex: 0.1s
+func protoDuration(d time.Duration) string {
+	return fmt.Sprintf("%gs", d.Seconds())
+}
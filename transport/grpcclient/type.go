@@ -2,16 +2,32 @@ package grpcclient
 
 import (
 	"context"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/oauth2"
 	"google.golang.org/grpc"
+	"sync"
 )
 
 type IManager interface {
-	initConn(ctx context.Context, connId string, address string, stdoutLogging bool, databaseLogging string, payloadLogSizeLimit uint64, opts ...grpc.DialOption) (conn *grpc.ClientConn, err error)
+	// AddConn registers connection of the given config, it is dialed on the first GetConn call
+	AddConn(ctx context.Context, connId string, connConfig *config.GrpcClientConfig) (err error)
 	GetConn(ctx context.Context, connId string) (conn *grpc.ClientConn, err error)
+	// Close closes and unregisters the connection
+	Close(ctx context.Context, connId string) (err error)
+	// CloseAll closes and unregisters every connection
+	CloseAll(ctx context.Context) (err error)
 }
 
 type managerImpl struct {
-	connMap map[string]*grpc.ClientConn
+	mu      sync.Mutex
+	connMap map[string]*lazyConn
+}
+
+// lazyConn is registered connection which is dialed on first use
+type lazyConn struct {
+	address string
+	opts    []grpc.DialOption
+	conn    *grpc.ClientConn
 }
 
 type Interceptor interface {
@@ -34,3 +50,9 @@ type loggingClientStream struct {
 	firstRecv           bool // Flag to indicate the first received message
 	payloadLogSizeLimit uint64
 }
+
+// tokenCredentials is per RPC credential of OAuth2 token provider
+type tokenCredentials struct {
+	provider                 oauth2.ITokenProvider
+	requireTransportSecurity bool
+}
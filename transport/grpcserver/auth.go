@@ -0,0 +1,121 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	commonContext "github.com/rosaekapratama/go-starter/context"
+	"github.com/rosaekapratama/go-starter/keycloak"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/response"
+	"github.com/rosaekapratama/go-starter/slices"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"strings"
+)
+
+const authorizationMetadataKey = "authorization"
+
+var errVerifierNotFound = errors.New("keycloak token verifier not found, keycloak config is missing or disabled")
+
+// publicServicePrefixes are services callable without token
+var publicServicePrefixes = []string{"/grpc.health.v1.Health/", "/grpc.reflection."}
+
+func newAuthInterceptor(_ context.Context, cfg *config.GrpcServerAuthConfig) Interceptor {
+	return &authInterceptor{publicMethods: cfg.PublicMethods, methodRoles: cfg.MethodRoles}
+}
+
+func (i *authInterceptor) unaryInterceptor(ctx context.Context, req any, serverInfo *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	ctx, err = i.authenticate(ctx, serverInfo.FullMethod)
+	if err != nil {
+		return nil, response.GrpcErrFromErr(err)
+	}
+	return handler(ctx, req)
+}
+
+func (i *authInterceptor) streamInterceptor(srv any, ss grpc.ServerStream, serverInfo *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	ctx, err := i.authenticate(ss.Context(), serverInfo.FullMethod)
+	if err != nil {
+		return response.GrpcErrFromErr(err)
+	}
+	return handler(srv, &authServerStream{ss, ctx})
+}
+
+// authenticate verifies bearer token of the call, sets its claims to context, then checks required roles of the method,
+// it returns response.UnauthorizedAccess if token is missing or invalid, or response.OperationNotPermitted if a role is missing
+func (i *authInterceptor) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if i.isPublic(method) {
+		return ctx, nil
+	}
+
+	// Token of authorization metadata, or the one propagated as GRPC client metadata context
+	token := bearerTokenFromIncomingContext(ctx)
+	if token == str.Empty {
+		token, _ = commonContext.TokenFromContext(ctx)
+	}
+	if token == str.Empty {
+		log.Debugf(ctx, "Missing bearer token, method=%s", method)
+		return ctx, response.UnauthorizedAccess
+	}
+
+	claims, err := i.claims(ctx, token)
+	if err != nil {
+		if errors.Is(err, response.UnauthorizedAccess) {
+			log.Debugf(ctx, "Invalid keycloak token, method=%s", method)
+		} else {
+			log.Errorf(ctx, err, "Failed to verify keycloak token, method=%s", method)
+		}
+		return ctx, response.UnauthorizedAccess
+	}
+	ctx = commonContext.ContextWithToken(ctx, token)
+	ctx = keycloak.ContextWithClaims(ctx, claims)
+
+	if roles, ok := i.methodRoles[method]; ok {
+		userRoles, _ := commonContext.RolesFromContext(ctx)
+		if !slices.ContainStringsCaseSensitive(userRoles, roles...) {
+			userId, _ := commonContext.UserIdFromContext(ctx)
+			log.Debugf(ctx, "Operation is not permitted, method=%s, userId=%s, reason=missing required roles %v", method, userId, roles)
+			return ctx, response.OperationNotPermitted
+		}
+	}
+	return ctx, nil
+}
+
+// claims returns verified claims of the token, every token is refused if keycloak verifier is disabled
+func (i *authInterceptor) claims(ctx context.Context, token string) (map[string]interface{}, error) {
+	verifier := i.verifier
+	if verifier == nil {
+		verifier = keycloak.Verifier
+	}
+	if verifier == nil {
+		return nil, errVerifierNotFound
+	}
+
+	claims, err := verifier.Verify(ctx, token)
+	if err != nil {
+		return nil, response.UnauthorizedAccess
+	}
+	return claims, nil
+}
+
+func (i *authInterceptor) isPublic(method string) bool {
+	for _, prefix := range publicServicePrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return slices.ContainStringCaseSensitive(i.publicMethods, method)
+}
+
+// bearerTokenFromIncomingContext returns bearer token of authorization metadata, metadata key is case-insensitive
+func bearerTokenFromIncomingContext(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, auth := range md.Get(authorizationMetadataKey) {
+		if len(auth) > len(headers.BearerTokenPrefix) && strings.EqualFold(auth[:len(headers.BearerTokenPrefix)], headers.BearerTokenPrefix) {
+			return auth[len(headers.BearerTokenPrefix):]
+		}
+	}
+	return str.Empty
+}
@@ -0,0 +1,153 @@
+package grpcserver
+
+import (
+	"context"
+	"github.com/rosaekapratama/go-starter/config"
+	commonContext "github.com/rosaekapratama/go-starter/context"
+	"github.com/rosaekapratama/go-starter/keycloak"
+	"github.com/rosaekapratama/go-starter/keycloak/keycloaktest"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+const (
+	testMethod      = "/test.TestService/Get"
+	testAdminMethod = "/test.TestService/Delete"
+)
+
+type AuthTestSuite struct {
+	suite.Suite
+	server      *keycloaktest.Server
+	interceptor *authInterceptor
+	token       string
+}
+
+func (s *AuthTestSuite) SetupTest() {
+	s.server = keycloaktest.NewServer()
+	s.token = s.server.Token("myrealm", map[string]interface{}{
+		keycloak.ClaimSub:               "user-1",
+		keycloak.ClaimPreferredUsername: "john",
+		keycloak.ClaimRealmAccess:       map[string]interface{}{"roles": []string{"user", "auditor"}},
+	})
+
+	s.interceptor = newAuthInterceptor(context.Background(), &config.GrpcServerAuthConfig{
+		Enabled:       true,
+		PublicMethods: []string{"/test.TestService/Ping"},
+		MethodRoles:   map[string][]string{testMethod: {"user"}, testAdminMethod: {"user", "admin"}},
+	}).(*authInterceptor)
+	s.interceptor.verifier = keycloak.NewVerifier(&config.KeycloakConfig{BaseUrl: s.server.URL()})
+}
+
+func (s *AuthTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func TestAuthTestSuite(t *testing.T) {
+	suite.Run(t, new(AuthTestSuite))
+}
+
+// call invokes unary interceptor with the authorization metadata, it returns context passed to the handler
+func (s *AuthTestSuite) call(method string, authorization string) (context.Context, error) {
+	ctx := context.Background()
+	if authorization != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(authorizationMetadataKey, authorization))
+	}
+
+	var handlerCtx context.Context
+	_, err := s.interceptor.unaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, _ any) (any, error) {
+		handlerCtx = ctx
+		return nil, nil
+	})
+	return handlerCtx, err
+}
+
+func (s *AuthTestSuite) TestContext() {
+	ctx, err := s.call(testMethod, "bearer "+s.token)
+	s.Require().NoError(err)
+	userId, _ := commonContext.UserIdFromContext(ctx)
+	s.Equal("user-1", userId)
+	username, _ := commonContext.UsernameFromContext(ctx)
+	s.Equal("john", username)
+	realm, _ := commonContext.RealmFromContext(ctx)
+	s.Equal("myrealm", realm)
+	roles, _ := commonContext.RolesFromContext(ctx)
+	s.Equal([]string{"user", "auditor"}, roles)
+	token, _ := commonContext.TokenFromContext(ctx)
+	s.Equal(s.token, token)
+}
+
+func (s *AuthTestSuite) TestUnauthenticated() {
+	_, err := s.call(testMethod, "")
+	s.Equal(codes.PermissionDenied, status.Code(err))
+
+	_, err = s.call(testMethod, "Bearer "+s.token[:len(s.token)-4]+"AAAA")
+	s.Equal(codes.PermissionDenied, status.Code(err))
+
+	_, err = s.call(testMethod, "Basic am9objpzZWNyZXQ=")
+	s.Equal(codes.PermissionDenied, status.Code(err))
+
+	// Public method and health check are callable without token
+	_, err = s.call("/test.TestService/Ping", "")
+	s.NoError(err)
+	_, err = s.call("/grpc.health.v1.Health/Check", "")
+	s.NoError(err)
+}
+
+func (s *AuthTestSuite) TestMethodRoles() {
+	_, err := s.call(testAdminMethod, "Bearer "+s.token)
+	s.Equal(codes.PermissionDenied, status.Code(err))
+
+	// Method without roles only needs valid token
+	_, err = s.call("/test.TestService/List", "Bearer "+s.token)
+	s.NoError(err)
+}
+
+func (s *AuthTestSuite) TestStream() {
+	ss := &authServerStream{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(authorizationMetadataKey, "Bearer "+s.token))}
+	err := s.interceptor.streamInterceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: testMethod}, func(_ any, stream grpc.ServerStream) error {
+		username, _ := commonContext.UsernameFromContext(stream.Context())
+		s.Equal("john", username)
+		return nil
+	})
+	s.NoError(err)
+
+	ss = &authServerStream{ctx: context.Background()}
+	err = s.interceptor.streamInterceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: testMethod}, func(_ any, _ grpc.ServerStream) error {
+		return nil
+	})
+	s.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (s *AuthTestSuite) TestNilVerifier() {
+	// Forged token is refused instead of being decoded without verification
+	s.interceptor.verifier = nil
+	forged := s.server.Sign(map[string]interface{}{
+		keycloak.ClaimSub:         "user-1",
+		keycloak.ClaimRealmAccess: map[string]interface{}{"roles": []string{"user"}},
+	})
+	_, err := s.call(testMethod, "Bearer "+forged)
+	s.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (s *AuthTestSuite) TestInvalidClaimType() {
+	token := s.server.Token("myrealm", map[string]interface{}{
+		keycloak.ClaimSub:               1,
+		keycloak.ClaimPreferredUsername: []string{"john"},
+		keycloak.ClaimName:              true,
+		keycloak.ClaimRealmAccess:       "admin",
+		keycloak.ClaimResourceAccess:    map[string]interface{}{"billing": "admin"},
+	})
+	s.NotPanics(func() {
+		_, err := s.call(testMethod, "Bearer "+token)
+		s.Equal(codes.PermissionDenied, status.Code(err))
+
+		ctx, err := s.call("/test.TestService/List", "Bearer "+token)
+		s.Require().NoError(err)
+		_, exists := commonContext.UserIdFromContext(ctx)
+		s.False(exists)
+	})
+}
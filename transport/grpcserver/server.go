@@ -7,6 +7,7 @@ import (
 	"github.com/inhies/go-bytesize"
 	"github.com/rosaekapratama/go-starter/config"
 	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/keycloak"
 	"github.com/rosaekapratama/go-starter/log"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
@@ -50,6 +51,16 @@ func Init(ctx context.Context, config config.Config) {
 		unaryInterceptorList = append(unaryInterceptorList, loggingInterceptor.unaryInterceptor)
 		streamInterceptorList = append(streamInterceptorList, loggingInterceptor.streamInterceptor)
 	}
+	if serverConfig.Auth != nil && serverConfig.Auth.Enabled {
+		// Unverified token claims must never be trusted for authentication
+		if keycloak.Verifier == nil {
+			log.Fatal(ctx, errVerifierNotFound, "GRPC server auth requires keycloak token verifier")
+			return
+		}
+		authInterceptor := newAuthInterceptor(ctx, serverConfig.Auth)
+		unaryInterceptorList = append(unaryInterceptorList, authInterceptor.unaryInterceptor)
+		streamInterceptorList = append(streamInterceptorList, authInterceptor.streamInterceptor)
+	}
 
 	opts := make([]grpc.ServerOption, 0)
 	opts = append(opts, grpc.StatsHandler(otelgrpc.NewServerHandler()))
@@ -2,6 +2,7 @@ package grpcserver
 
 import (
 	"context"
+	"github.com/rosaekapratama/go-starter/keycloak"
 	"google.golang.org/grpc"
 )
 
@@ -31,3 +32,11 @@ type metadataContextInterceptor struct {
 type loggingInterceptor struct {
 	payloadLogSizeLimit uint64
 }
+
+// authInterceptor verifies bearer token of incoming call and authorizes it by roles of the method
+type authInterceptor struct {
+	// verifier overrides keycloak.Verifier
+	verifier      keycloak.IVerifier
+	publicMethods []string
+	methodRoles   map[string][]string
+}
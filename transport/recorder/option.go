@@ -0,0 +1,50 @@
+package recorder
+
+import "net/http"
+
+type Option interface {
+	Apply(r *Recorder)
+}
+
+type modeOption struct {
+	mode Mode
+}
+
+type matchersOption struct {
+	matchers []Matcher
+}
+
+type redactHeadersOption struct {
+	headers []string
+}
+
+func (o *modeOption) Apply(r *Recorder) {
+	r.mode = o.mode
+}
+
+func (o *matchersOption) Apply(r *Recorder) {
+	r.matchers = o.matchers
+}
+
+func (o *redactHeadersOption) Apply(r *Recorder) {
+	for _, header := range o.headers {
+		r.redacted[http.CanonicalHeaderKey(header)] = true
+	}
+}
+
+// WithMode set mode of the recorder, default is ModeAuto
+func WithMode(mode Mode) Option {
+	return &modeOption{mode: mode}
+}
+
+// WithMatchers replace default matchers which are MatchMethod, MatchUrl and MatchBody,
+// request matches recorded request if all matchers return true
+func WithMatchers(matchers ...Matcher) Option {
+	return &matchersOption{matchers: matchers}
+}
+
+// WithRedactHeaders add headers whose value is redacted in cassette,
+// Authorization, Proxy-Authorization, Cookie, Set-Cookie and X-API-Key are always redacted
+func WithRedactHeaders(headers ...string) Option {
+	return &redactHeadersOption{headers: headers}
+}
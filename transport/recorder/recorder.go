@@ -0,0 +1,258 @@
+// Package recorder records real HTTP interactions to cassette file and replays them in tests,
+// plug it into restclient with restclient.WithRecorder or into soapclient with soapclient.WithRecorder
+package recorder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"gopkg.in/yaml.v3"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// ModeAuto replays matched request and records unmatched one
+	ModeAuto Mode = iota
+	// ModeReplay replays matched request and fails unmatched one, real server is never called
+	ModeReplay
+	// ModeRecord sends every request to real server and rewrites the cassette
+	ModeRecord
+
+	RedactedValue = "[REDACTED]"
+
+	cassetteFilePerm = 0644
+	cassetteDirPerm  = 0755
+)
+
+var (
+	ErrInteractionNotFound = errors.New("no recorded interaction matches the request")
+
+	defaultRedactedHeaders = []string{
+		headers.Authorization,
+		headers.ProxyAuthorization,
+		"Cookie",
+		"Set-Cookie",
+		"X-API-Key",
+	}
+)
+
+// New returns recorder of the cassette file, cassette is loaded if it exists,
+// call Stop when done to save recorded interactions
+func New(path string, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:     path,
+		mode:     ModeAuto,
+		matchers: []Matcher{MatchMethod, MatchUrl, MatchBody},
+		redacted: make(map[string]bool),
+		cassette: &Cassette{},
+		replayed: make(map[*Interaction]bool),
+	}
+	for _, header := range defaultRedactedHeaders {
+		r.redacted[http.CanonicalHeaderKey(header)] = true
+	}
+	for _, opt := range opts {
+		opt.Apply(r)
+	}
+
+	if r.mode == ModeRecord {
+		return r, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && r.mode == ModeAuto {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(b, r.cassette); err != nil {
+		return nil, fmt.Errorf("invalid cassette file, path=%s, error=%w", path, err)
+	}
+	return r, nil
+}
+
+// Wrap returns transport which replays from cassette, or calls next and records the interaction
+func (r *Recorder) Wrap(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &recordingTransport{recorder: r, next: next}
+}
+
+// Stop saves the cassette if there is new interaction
+func (r *Recorder) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.dirty {
+		return nil
+	}
+	b, err := yaml.Marshal(r.cassette)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(r.path), cassetteDirPerm); err != nil {
+		return err
+	}
+	if err = os.WriteFile(r.path, b, cassetteFilePerm); err != nil {
+		return err
+	}
+	r.dirty = false
+	return nil
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	r := t.recorder
+	if r.mode != ModeRecord {
+		if interaction := r.match(req, body); interaction != nil {
+			return interaction.Response.toHttpResponse(req), nil
+		}
+		if r.mode == ModeReplay {
+			return nil, fmt.Errorf("%w, method=%s, url=%s", ErrInteractionNotFound, req.Method, req.URL)
+		}
+	}
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	r.record(&Interaction{
+		Request: &RecordedRequest{
+			Method:  req.Method,
+			Url:     req.URL.String(),
+			Headers: r.redact(req.Header),
+			Body:    string(body),
+		},
+		Response: &RecordedResponse{
+			StatusCode: res.StatusCode,
+			Headers:    r.redact(res.Header),
+			Body:       string(resBody),
+		},
+	})
+	return res, nil
+}
+
+// match returns first matched interaction which is not replayed yet,
+// or the last matched one if all of them are replayed
+func (r *Recorder) match(req *http.Request, body []byte) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var last *Interaction
+	for _, interaction := range r.cassette.Interactions {
+		if !r.matches(req, body, interaction.Request) {
+			continue
+		}
+		if !r.replayed[interaction] {
+			r.replayed[interaction] = true
+			return interaction
+		}
+		last = interaction
+	}
+	return last
+}
+
+func (r *Recorder) matches(req *http.Request, body []byte, recorded *RecordedRequest) bool {
+	for _, matcher := range r.matchers {
+		if !matcher(req, body, recorded) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Recorder) record(interaction *Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Recorded interaction counts as replayed, so the same request made again is answered the same way as on replay
+	r.replayed[interaction] = true
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.dirty = true
+}
+
+func (r *Recorder) redact(h http.Header) http.Header {
+	redacted := h.Clone()
+	for k := range redacted {
+		if r.redacted[http.CanonicalHeaderKey(k)] {
+			redacted[k] = []string{RedactedValue}
+		}
+	}
+	return redacted
+}
+
+func (res *RecordedResponse) toHttpResponse(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", res.StatusCode, http.StatusText(res.StatusCode)),
+		StatusCode:    res.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        res.Headers.Clone(),
+		Body:          io.NopCloser(strings.NewReader(res.Body)),
+		ContentLength: int64(len(res.Body)),
+		Request:       req,
+	}
+}
+
+// readRequestBody reads request body and puts it back, so it can still be sent
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// MatchMethod matches request method
+func MatchMethod(r *http.Request, _ []byte, recorded *RecordedRequest) bool {
+	return r.Method == recorded.Method
+}
+
+// MatchUrl matches full request URL including query
+func MatchUrl(r *http.Request, _ []byte, recorded *RecordedRequest) bool {
+	return r.URL.String() == recorded.Url
+}
+
+// MatchBody matches request body, leading and trailing whitespaces are ignored
+func MatchBody(_ *http.Request, body []byte, recorded *RecordedRequest) bool {
+	return strings.TrimSpace(string(body)) == strings.TrimSpace(recorded.Body)
+}
+
+// MatchHeader returns matcher of the request header, it can't match redacted header
+func MatchHeader(header string) Matcher {
+	return func(r *http.Request, _ []byte, recorded *RecordedRequest) bool {
+		return r.Header.Get(header) == recorded.Headers.Get(header)
+	}
+}
+
+// MatchPath returns matcher of URL path which ignores host and query, useful if host is random like httptest server
+func MatchPath(r *http.Request, _ []byte, recorded *RecordedRequest) bool {
+	u, err := url.Parse(recorded.Url)
+	if err != nil {
+		return false
+	}
+	return r.URL.Path == u.Path
+}
@@ -0,0 +1,115 @@
+package recorder
+
+import (
+	"github.com/stretchr/testify/suite"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type RecorderTestSuite struct {
+	suite.Suite
+	server   *httptest.Server
+	calls    atomic.Int32
+	cassette string
+}
+
+func (s *RecorderTestSuite) SetupTest() {
+	s.calls.Store(0)
+	s.cassette = filepath.Join(s.T().TempDir(), "testdata", "users.yaml")
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := s.calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("X-Call", string(rune('0'+call)))
+		_, _ = w.Write([]byte(r.Method + " " + r.URL.Path + " " + string(body)))
+	}))
+}
+
+func (s *RecorderTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func TestRecorderTestSuite(t *testing.T) {
+	suite.Run(t, new(RecorderTestSuite))
+}
+
+func (s *RecorderTestSuite) do(client *http.Client, method, path, body string) (*http.Response, string, error) {
+	req, err := http.NewRequest(method, s.server.URL+path, strings.NewReader(body))
+	s.Require().NoError(err)
+	req.Header.Set("Authorization", "Bearer secret")
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	b, err := io.ReadAll(res.Body)
+	s.Require().NoError(err)
+	return res, string(b), nil
+}
+
+func (s *RecorderTestSuite) TestRecordAndReplay() {
+	rec, err := New(s.cassette)
+	s.Require().NoError(err)
+	client := &http.Client{Transport: rec.Wrap(nil)}
+
+	_, body, err := s.do(client, http.MethodPost, "/v1/users", `{"name":"john"}`)
+	s.Require().NoError(err)
+	s.Equal(`POST /v1/users {"name":"john"}`, body)
+	_, _, err = s.do(client, http.MethodGet, "/v1/users/1", "")
+	s.Require().NoError(err)
+	s.Require().NoError(rec.Stop())
+	s.Equal(int32(2), s.calls.Load())
+
+	// Secret headers are redacted
+	b, err := os.ReadFile(s.cassette)
+	s.Require().NoError(err)
+	s.NotContains(string(b), "secret")
+	s.Contains(string(b), RedactedValue)
+
+	// Replay doesn't call the server
+	rec, err = New(s.cassette, WithMode(ModeReplay))
+	s.Require().NoError(err)
+	client = &http.Client{Transport: rec.Wrap(nil)}
+	res, body, err := s.do(client, http.MethodGet, "/v1/users/1", "")
+	s.Require().NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal("GET /v1/users/1 ", body)
+	s.Equal("2", res.Header.Get("X-Call"))
+	_, body, err = s.do(client, http.MethodPost, "/v1/users", `{"name":"john"}`)
+	s.Require().NoError(err)
+	s.Equal(`POST /v1/users {"name":"john"}`, body)
+	s.Equal(int32(2), s.calls.Load())
+
+	// Different body doesn't match
+	_, _, err = s.do(client, http.MethodPost, "/v1/users", `{"name":"jane"}`)
+	s.ErrorIs(err, ErrInteractionNotFound)
+}
+
+func (s *RecorderTestSuite) TestAutoMode() {
+	rec, err := New(s.cassette, WithMatchers(MatchMethod, MatchPath))
+	s.Require().NoError(err)
+	client := &http.Client{Transport: rec.Wrap(nil)}
+
+	// Same request is recorded once and replayed afterward
+	for i := 0; i < 2; i++ {
+		res, _, err := s.do(client, http.MethodGet, "/v1/users?q="+string(rune('a'+i)), "")
+		s.Require().NoError(err)
+		s.Equal("1", res.Header.Get("X-Call"))
+	}
+	_, _, err = s.do(client, http.MethodGet, "/v1/orders", "")
+	s.Require().NoError(err)
+	s.Equal(int32(2), s.calls.Load())
+	s.Require().NoError(rec.Stop())
+
+	// Missing cassette fails in replay mode
+	_, err = New(filepath.Join(s.T().TempDir(), "missing.yaml"), WithMode(ModeReplay))
+	s.Error(err)
+}
@@ -0,0 +1,51 @@
+package recorder
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Mode decides whether request is replayed from cassette or sent to the real server
+type Mode int
+
+// Matcher returns true if the request matches the recorded request, body is the request body already read
+type Matcher func(r *http.Request, body []byte, recorded *RecordedRequest) bool
+
+// Recorder records HTTP interactions to cassette file and replays them, wrap transport of a client with Wrap
+type Recorder struct {
+	path     string
+	mode     Mode
+	matchers []Matcher
+	redacted map[string]bool
+	cassette *Cassette
+	replayed map[*Interaction]bool
+	dirty    bool
+	mu       sync.Mutex
+}
+
+type recordingTransport struct {
+	recorder *Recorder
+	next     http.RoundTripper
+}
+
+type Cassette struct {
+	Interactions []*Interaction `yaml:"interactions"`
+}
+
+type Interaction struct {
+	Request  *RecordedRequest  `yaml:"request"`
+	Response *RecordedResponse `yaml:"response"`
+}
+
+type RecordedRequest struct {
+	Method  string      `yaml:"method"`
+	Url     string      `yaml:"url"`
+	Headers http.Header `yaml:"headers,omitempty"`
+	Body    string      `yaml:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int         `yaml:"statusCode"`
+	Headers    http.Header `yaml:"headers,omitempty"`
+	Body       string      `yaml:"body,omitempty"`
+}
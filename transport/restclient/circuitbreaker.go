@@ -0,0 +1,169 @@
+package restclient
+
+import (
+	"context"
+	"errors"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/otel"
+	"github.com/rosaekapratama/go-starter/response"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBreakerFailureThreshold = 5
+	defaultBreakerOpenTimeout      = 30 * time.Second
+	defaultBreakerHalfOpenRequests = 1
+
+	counterBreakerTransition = "restclient.circuitbreaker.transition"
+	counterBreakerRejected   = "restclient.circuitbreaker.rejected"
+)
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+var countersOnce sync.Once
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+func registerCounters(ctx context.Context) {
+	countersOnce.Do(func() {
+		for _, counterName := range []string{counterRetry, counterBreakerTransition, counterBreakerRejected} {
+			if err := otel.AddCounter(ctx, counterName, "1"); err != nil {
+				log.Warnf(ctx, "Failed to add REST client counter, name=%s, error=%v", counterName, err)
+			}
+		}
+	})
+}
+
+func newCircuitBreaker(opts *circuitBreakerOptions) *circuitBreaker {
+	registerCounters(context.Background())
+	return &circuitBreaker{
+		opts:  opts,
+		hosts: make(map[string]*hostCircuit),
+		now:   time.Now,
+	}
+}
+
+// RoundTrip rejects request with response.CircuitBreakerOpen if circuit of the host is open
+func (t *circuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	host := req.URL.Host
+	if !t.breaker.allow(ctx, host) {
+		otel.Count(ctx, counterBreakerRejected, integer.One, metric.WithAttributes(attribute.String("host", host)))
+		return nil, response.CircuitBreakerOpen
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	switch {
+	case err != nil && (errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled)):
+		// Cancelled by caller, it says nothing about the host
+		t.breaker.release(host)
+	case err != nil || resp.StatusCode >= http.StatusInternalServerError:
+		t.breaker.record(ctx, host, false)
+	default:
+		t.breaker.record(ctx, host, true)
+	}
+	return resp, err
+}
+
+func (b *circuitBreaker) allow(ctx context.Context, host string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(host)
+	if c.state == circuitOpen {
+		if b.now().Sub(c.openedAt) < b.opts.openTimeout {
+			return false
+		}
+		b.transition(ctx, host, c, circuitHalfOpen)
+	}
+	if c.state == circuitHalfOpen {
+		if c.probes >= b.opts.halfOpenRequests {
+			return false
+		}
+		c.probes++
+	}
+	return true
+}
+
+func (b *circuitBreaker) record(ctx context.Context, host string, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(host)
+	switch c.state {
+	case circuitClosed:
+		if success {
+			c.failures = integer.Zero
+			return
+		}
+		c.failures++
+		if c.failures >= b.opts.failureThreshold {
+			b.transition(ctx, host, c, circuitOpen)
+		}
+	case circuitHalfOpen:
+		c.probes--
+		if !success {
+			b.transition(ctx, host, c, circuitOpen)
+			return
+		}
+		c.successes++
+		if c.successes >= b.opts.halfOpenRequests {
+			b.transition(ctx, host, c, circuitClosed)
+		}
+	default:
+		// Request sent before the circuit opened, ignore it
+	}
+}
+
+// release frees probe slot of cancelled request without counting it
+func (b *circuitBreaker) release(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c := b.circuit(host); c.state == circuitHalfOpen && c.probes > integer.Zero {
+		c.probes--
+	}
+}
+
+func (b *circuitBreaker) circuit(host string) *hostCircuit {
+	c, ok := b.hosts[host]
+	if !ok {
+		c = &hostCircuit{}
+		b.hosts[host] = c
+	}
+	return c
+}
+
+func (b *circuitBreaker) transition(ctx context.Context, host string, c *hostCircuit, state circuitState) {
+	log.Warnf(ctx, "REST client circuit breaker state changed, host=%s, from=%s, to=%s, failures=%d", host, c.state, state, c.failures)
+	otel.Count(ctx, counterBreakerTransition, integer.One, metric.WithAttributes(
+		attribute.String("host", host),
+		attribute.String("from", c.state.String()),
+		attribute.String("to", state.String()),
+	))
+
+	c.state = state
+	c.failures = integer.Zero
+	c.successes = integer.Zero
+	c.probes = integer.Zero
+	if state == circuitOpen {
+		c.openedAt = b.now()
+	}
+}
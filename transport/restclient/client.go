@@ -15,6 +15,8 @@ import (
 	"github.com/rosaekapratama/go-starter/log"
 	"github.com/rosaekapratama/go-starter/log/constant"
 	"github.com/rosaekapratama/go-starter/log/transport/repositories"
+	"github.com/rosaekapratama/go-starter/oauth2"
+	"github.com/rosaekapratama/go-starter/utils"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"net/http"
 	"reflect"
@@ -22,6 +24,8 @@ import (
 )
 
 var (
+	errRestClientNotFound = errors.New("REST client not found")
+
 	_config             config.Config
 	Manager             IManager
 	LogRepository       repositories.ITransportLogRepository
@@ -42,8 +46,26 @@ func Init(ctx context.Context, config config.Config, restLogRepository repositor
 		log.Fatal(ctx, err, "Failed to init default rest client")
 		return
 	}
-	Manager = &managerImpl{
+	manager := &managerImpl{
 		defaultClient: client,
+		clients:       make(map[string]*Client),
+	}
+	Manager = manager
+
+	// Init named clients
+	for name, clientConfig := range _config.GetObject().Transport.Client.Rest.Clients {
+		opts, err := namedClientOptions(clientConfig)
+		if err != nil {
+			log.Fatalf(ctx, err, "Invalid REST client config, name=%s", name)
+			return
+		}
+		namedClient, err := newClient(ctx, opts...)
+		if err != nil {
+			log.Fatalf(ctx, err, "Failed to init REST client, name=%s", name)
+			return
+		}
+		manager.clients[name] = namedClient
+		log.Infof(ctx, "REST client is initiated, name=%s, baseUrl=%s", name, clientConfig.BaseUrl)
 	}
 
 	if _logDB != str.Empty {
@@ -55,6 +77,62 @@ func (m *managerImpl) GetDefaultClient() *Client {
 	return m.defaultClient
 }
 
+func (m *managerImpl) GetClient(name string) (*Client, error) {
+	if client, exists := m.clients[name]; exists {
+		return client, nil
+	}
+	return nil, fmt.Errorf("%w, name=%s", errRestClientNotFound, name)
+}
+
+// namedClientOptions returns client options of the named client config
+func namedClientOptions(cfg *config.NamedRestClientConfig) ([]ClientOption, error) {
+	opts := make([]ClientOption, 0)
+	if cfg == nil {
+		return opts, nil
+	}
+	if cfg.BaseUrl != str.Empty {
+		opts = append(opts, WithBaseUrl(cfg.BaseUrl))
+	}
+	if cfg.Timeout > 0 {
+		opts = append(opts, WithTimeout(time.Duration(cfg.Timeout)*time.Second))
+	}
+	if cfg.Insecure {
+		opts = append(opts, WithInsecureSkipVerify(cfg.Insecure))
+	}
+	tlsConfig, err := utils.NewTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		opts = append(opts, WithTLSConfig(tlsConfig))
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, WithHeaders(cfg.Headers))
+	}
+	if cfg.Auth != nil {
+		if cfg.Auth.Basic != nil {
+			opts = append(opts, WithBasicAuth(cfg.Auth.Basic.Username, cfg.Auth.Basic.Password))
+		}
+		if cfg.Auth.Bearer != nil {
+			opts = append(opts, WithBearerToken(cfg.Auth.Bearer.Token))
+		}
+		if cfg.Auth.ApiKey != nil {
+			opts = append(opts, WithAPIKey(cfg.Auth.ApiKey.Header, cfg.Auth.ApiKey.Value))
+		}
+		if cfg.Auth.OAuth2 != nil {
+			provider, err := oauth2.NewTokenProvider(cfg.Auth.OAuth2)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, WithTokenProvider(provider))
+		}
+	}
+	if cfg.Proxy != str.Empty {
+		opts = append(opts, WithProxy(cfg.Proxy))
+	}
+	return opts, nil
+}
+
 func newClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
 	// List of common config
 	logStdout := _config.GetObject().Transport.Client.Rest.Logging.Stdout
@@ -64,7 +142,7 @@ func newClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
 
 	client := &Client{
 		Resty:     resty.New().EnableTrace(),
-		transport: http.DefaultTransport.(*http.Transport),
+		transport: http.DefaultTransport.(*http.Transport).Clone(),
 	}
 
 	// Apply common config
@@ -82,7 +160,14 @@ func newClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
 	}
 
 	// Set pre and post of request process
-	client.Resty.SetTransport(otelhttp.NewTransport(client.transport))
+	var transport http.RoundTripper = client.transport
+	if client.recorder != nil {
+		transport = client.recorder.Wrap(transport)
+	}
+	if client.circuitBreaker != nil {
+		transport = &circuitBreakerTransport{next: transport, breaker: client.circuitBreaker}
+	}
+	client.Resty.SetTransport(otelhttp.NewTransport(transport))
 	if client.logging != nil && client.logging.stdout {
 		client.Resty.OnBeforeRequest(preStdoutLogging)
 		client.Resty.OnAfterResponse(postStdoutLogging)
@@ -0,0 +1,361 @@
+package restclient
+
+import (
+	"context"
+	"errors"
+	"github.com/rosaekapratama/go-starter/config"
+	commonContext "github.com/rosaekapratama/go-starter/context"
+	mocksConfig "github.com/rosaekapratama/go-starter/mocks/config"
+	"github.com/rosaekapratama/go-starter/oauth2"
+	"github.com/rosaekapratama/go-starter/page"
+	"github.com/rosaekapratama/go-starter/response"
+	"github.com/rosaekapratama/go-starter/transport/recorder"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var ctx context.Context
+
+type ClientTestSuite struct {
+	suite.Suite
+	server   *httptest.Server
+	calls    atomic.Int32
+	handlers []http.HandlerFunc
+}
+
+func (s *ClientTestSuite) SetupTest() {
+	ctx = context.Background()
+	mockConfig := &mocksConfig.MockConfig{}
+	mockConfig.On("GetObject").Return(&config.Object{
+		Transport: &config.TransportConfig{
+			Client: &config.ClientConfig{
+				Rest: &config.RestClientConfig{
+					Logging: &config.RestClientLoggingConfig{},
+					Timeout: 5,
+				},
+			},
+		},
+	})
+	_config = mockConfig
+
+	s.calls.Store(0)
+	s.handlers = nil
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(s.calls.Add(1)) - 1
+		if call < len(s.handlers) {
+			s.handlers[call](w, r)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func (s *ClientTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func TestClientTestSuite(t *testing.T) {
+	suite.Run(t, new(ClientTestSuite))
+}
+
+func status(statusCode int) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(statusCode)
+	}
+}
+
+func (s *ClientTestSuite) TestRetry() {
+	client, err := newClient(ctx, WithRetry(3, WithRetryBackoff(time.Millisecond, 5*time.Millisecond)))
+	s.Require().NoError(err)
+
+	s.handlers = []http.HandlerFunc{status(http.StatusServiceUnavailable), status(http.StatusBadGateway)}
+	resp, err := client.NewRequest(ctx).Get(s.server.URL)
+	s.Require().NoError(err)
+	s.Equal(http.StatusOK, resp.StatusCode())
+	s.Equal(int32(3), s.calls.Load())
+
+	// Non idempotent request is not retried
+	s.calls.Store(0)
+	resp, err = client.NewRequest(ctx).Post(s.server.URL)
+	s.Require().NoError(err)
+	s.Equal(http.StatusServiceUnavailable, resp.StatusCode())
+	s.Equal(int32(1), s.calls.Load())
+
+	// Unless it has idempotency key
+	s.calls.Store(0)
+	resp, err = client.NewRequest(ctx).SetHeader("Idempotency-Key", "key-1").Post(s.server.URL)
+	s.Require().NoError(err)
+	s.Equal(http.StatusOK, resp.StatusCode())
+	s.Equal(int32(3), s.calls.Load())
+
+	// Not retryable status
+	s.calls.Store(0)
+	s.handlers = []http.HandlerFunc{status(http.StatusBadRequest)}
+	resp, err = client.NewRequest(ctx).Get(s.server.URL)
+	s.Require().NoError(err)
+	s.Equal(http.StatusBadRequest, resp.StatusCode())
+	s.Equal(int32(1), s.calls.Load())
+}
+
+func (s *ClientTestSuite) TestRetryAfter() {
+	client, err := newClient(ctx, WithRetry(2, WithRetryBackoff(time.Millisecond, time.Millisecond)))
+	s.Require().NoError(err)
+
+	s.handlers = []http.HandlerFunc{func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}}
+	start := time.Now()
+	resp, err := client.NewRequest(ctx).Get(s.server.URL)
+	s.Require().NoError(err)
+	s.Equal(http.StatusOK, resp.StatusCode())
+	s.GreaterOrEqual(time.Since(start), time.Second)
+
+	s.Equal(2*time.Second, parseRetryAfter("2"))
+	s.Zero(parseRetryAfter("invalid"))
+	s.Greater(parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)), 58*time.Second)
+}
+
+func (s *ClientTestSuite) TestCircuitBreaker() {
+	client, err := newClient(ctx, WithCircuitBreaker(WithBreakerFailureThreshold(2), WithBreakerOpenTimeout(time.Minute)))
+	s.Require().NoError(err)
+	now := time.Now()
+	client.circuitBreaker.now = func() time.Time { return now }
+
+	s.handlers = []http.HandlerFunc{status(http.StatusInternalServerError), status(http.StatusInternalServerError), status(http.StatusInternalServerError)}
+	for i := 0; i < 2; i++ {
+		resp, err := client.NewRequest(ctx).Get(s.server.URL)
+		s.Require().NoError(err)
+		s.Equal(http.StatusInternalServerError, resp.StatusCode())
+	}
+
+	// Open circuit rejects request without calling the host
+	_, err = client.NewRequest(ctx).Get(s.server.URL)
+	s.True(errors.Is(err, response.CircuitBreakerOpen), "unexpected error %v", err)
+	s.Equal(int32(2), s.calls.Load())
+
+	// Failed probe opens the circuit again
+	now = now.Add(time.Minute)
+	resp, err := client.NewRequest(ctx).Get(s.server.URL)
+	s.Require().NoError(err)
+	s.Equal(http.StatusInternalServerError, resp.StatusCode())
+	_, err = client.NewRequest(ctx).Get(s.server.URL)
+	s.True(errors.Is(err, response.CircuitBreakerOpen), "unexpected error %v", err)
+
+	// Succeeded probe closes the circuit
+	now = now.Add(time.Minute)
+	resp, err = client.NewRequest(ctx).Get(s.server.URL)
+	s.Require().NoError(err)
+	s.Equal(http.StatusOK, resp.StatusCode())
+	s.Equal(circuitClosed, client.circuitBreaker.hosts[strings.TrimPrefix(s.server.URL, "http://")].state)
+}
+
+func (s *ClientTestSuite) TestNamedClient() {
+	s.handlers = []http.HandlerFunc{func(w http.ResponseWriter, r *http.Request) {
+		s.Equal("/v1/orders", r.URL.Path)
+		s.Equal("my-service", r.Header.Get("X-Client-Id"))
+		s.Equal("Bearer my-token", r.Header.Get("Authorization"))
+		s.Equal("my-api-key", r.Header.Get("X-API-Key"))
+		w.WriteHeader(http.StatusOK)
+	}}
+	opts, err := namedClientOptions(&config.NamedRestClientConfig{
+		BaseUrl: s.server.URL,
+		Headers: map[string]string{"X-Client-Id": "my-service"},
+		Auth: &config.RestClientAuthConfig{
+			Bearer: &config.BearerAuthConfig{Token: "my-token"},
+			ApiKey: &config.ApiKeyAuthConfig{Value: "my-api-key"},
+		},
+	})
+	s.Require().NoError(err)
+	client, err := newClient(ctx, opts...)
+	s.Require().NoError(err)
+
+	resp, err := client.NewRequest(ctx).Get("/v1/orders")
+	s.Require().NoError(err)
+	s.Equal(http.StatusOK, resp.StatusCode())
+	s.Equal(int32(1), s.calls.Load())
+
+	manager := &managerImpl{clients: map[string]*Client{"orderApi": client}}
+	namedClient, err := manager.GetClient("orderApi")
+	s.Require().NoError(err)
+	s.Same(client, namedClient)
+	_, err = manager.GetClient("unknown")
+	s.ErrorIs(err, errRestClientNotFound)
+}
+
+func (s *ClientTestSuite) TestTokenProvider() {
+	s.handlers = []http.HandlerFunc{func(w http.ResponseWriter, r *http.Request) {
+		s.Equal("Bearer user-token", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}}
+	client, err := newClient(ctx, WithTokenProvider(oauth2.NewForwardProvider()))
+	s.Require().NoError(err)
+
+	resp, err := client.NewRequest(commonContext.ContextWithToken(ctx, "user-token")).Get(s.server.URL)
+	s.Require().NoError(err)
+	s.Equal(http.StatusOK, resp.StatusCode())
+
+	// Request is not sent without token
+	_, err = client.NewRequest(ctx).Get(s.server.URL)
+	s.Error(err)
+	s.Equal(int32(1), s.calls.Load())
+}
+
+type testUser struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func body(statusCode int, b string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		_, _ = w.Write([]byte(b))
+	}
+}
+
+func (s *ClientTestSuite) TestDo() {
+	client, err := newClient(ctx, WithBaseUrl(s.server.URL))
+	s.Require().NoError(err)
+
+	s.handlers = []http.HandlerFunc{body(http.StatusOK,
+		`{"response":{"code":"0000","description":"success"},"pagination":{"prevPage":1,"nextPage":3,"totalPage":4,"totalItem":35},"data":[{"id":"1","name":"john"}]}`)}
+	users, pagination, err := Do[[]*testUser](ctx, client, &Request{
+		Method:      http.MethodGet,
+		Url:         "/v1/users",
+		QueryParams: map[string][]string{"pageNum": {"2"}},
+	})
+	s.Require().NoError(err)
+	s.Require().Len(users, 1)
+	s.Equal("john", users[0].Name)
+	s.Equal(35, pagination.TotalItem)
+
+	// Success without data
+	s.calls.Store(0)
+	s.handlers = []http.HandlerFunc{body(http.StatusOK, `{"response":{"code":"0000","description":"success"}}`)}
+	user, pagination, err := Post[*testUser](ctx, client, "/v1/users", &testUser{Name: "john"})
+	s.Require().NoError(err)
+	s.Nil(user)
+	s.Nil(pagination)
+}
+
+func (s *ClientTestSuite) TestDoError() {
+	client, err := newClient(ctx, WithBaseUrl(s.server.URL))
+	s.Require().NoError(err)
+
+	s.handlers = []http.HandlerFunc{body(http.StatusNotFound, `{"response":{"code":"0006","description":"data not found"}}`)}
+	_, _, err = Get[*testUser](ctx, client, "/v1/users/1")
+	s.ErrorIs(err, response.DataNotFound)
+	responseErr := &ResponseError{}
+	s.Require().ErrorAs(err, &responseErr)
+	s.Equal(http.StatusNotFound, responseErr.HttpStatusCode())
+	s.Equal(response.DataNotFound.Code(), responseErr.Code())
+
+	// Remote validation error keeps its fields
+	s.calls.Store(0)
+	s.handlers = []http.HandlerFunc{body(http.StatusBadRequest,
+		`{"response":{"code":"0013","description":"invalid body request"},"errors":[{"field":"name","tag":"required","message":"name is required"}]}`)}
+	_, _, err = Post[*testUser](ctx, client, "/v1/users", &testUser{})
+	s.Require().ErrorAs(err, &responseErr)
+	s.Equal(response.InvalidBodyRequest, responseErr.Response)
+	s.Require().Len(responseErr.Fields, 1)
+	s.Equal("name", responseErr.Fields[0].Field)
+
+	// Unknown remote code is general error but keeps remote code and description
+	s.calls.Store(0)
+	s.handlers = []http.HandlerFunc{body(http.StatusConflict, `{"response":{"code":"9001","description":"order is closed"}}`)}
+	_, _, err = Delete[*testUser](ctx, client, "/v1/orders/1")
+	s.Require().ErrorAs(err, &responseErr)
+	s.Equal(response.GeneralError, responseErr.Response)
+	s.Equal("9001", responseErr.Code())
+	s.Equal("order is closed", responseErr.Description())
+
+	// Body which is not base response
+	s.calls.Store(0)
+	s.handlers = []http.HandlerFunc{body(http.StatusBadGateway, `<html>bad gateway</html>`)}
+	_, _, err = Get[*testUser](ctx, client, "/v1/users/1")
+	s.ErrorIs(err, errInvalidResponseBody)
+}
+
+func (s *ClientTestSuite) TestDoProblemFormat() {
+	client, err := newClient(ctx, WithBaseUrl(s.server.URL))
+	s.Require().NoError(err)
+
+	// Success body is raw data with pagination in headers
+	s.handlers = []http.HandlerFunc{func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Total-Item", "35")
+		w.Header().Set("Total-Page", "4")
+		w.Header().Set("Link", `</v1/users?pageNum=1>; rel="first", </v1/users?pageNum=1>; rel="prev", </v1/users?pageNum=3>; rel="next", </v1/users?pageNum=4>; rel="last"`)
+		_, _ = w.Write([]byte(`[{"id":"1","name":"john"}]`))
+	}}
+	users, pagination, err := Get[[]*testUser](ctx, client, "/v1/users?pageNum=2")
+	s.Require().NoError(err)
+	s.Require().Len(users, 1)
+	s.Equal("john", users[0].Name)
+	s.Equal(&page.PageResponse{PrevPage: 1, NextPage: 3, TotalPage: 4, TotalItem: 35}, pagination)
+
+	s.calls.Store(0)
+	s.handlers = []http.HandlerFunc{body(http.StatusOK, `{"id":"1","name":"john"}`)}
+	user, pagination, err := Get[*testUser](ctx, client, "/v1/users/1")
+	s.Require().NoError(err)
+	s.Equal("john", user.Name)
+	s.Nil(pagination)
+
+	// Empty body of 204 is zero value
+	s.calls.Store(0)
+	s.handlers = []http.HandlerFunc{status(http.StatusNoContent)}
+	user, pagination, err = Delete[*testUser](ctx, client, "/v1/users/1")
+	s.Require().NoError(err)
+	s.Nil(user)
+	s.Nil(pagination)
+
+	// Problem details is mapped by its code
+	s.calls.Store(0)
+	s.handlers = []http.HandlerFunc{func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid body request","code":"0013",` +
+			`"errors":[{"field":"name","tag":"required","message":"name is required"}]}`))
+	}}
+	_, _, err = Post[*testUser](ctx, client, "/v1/users", &testUser{})
+	s.ErrorIs(err, response.InvalidBodyRequest)
+	responseErr := &ResponseError{}
+	s.Require().ErrorAs(err, &responseErr)
+	s.Equal(http.StatusBadRequest, responseErr.HttpStatusCode())
+	s.Equal("0013", responseErr.Code())
+	s.Equal("invalid body request", responseErr.Description())
+	s.Require().Len(responseErr.Fields, 1)
+	s.Equal("name", responseErr.Fields[0].Field)
+}
+
+func (s *ClientTestSuite) TestRecorder() {
+	cassette := filepath.Join(s.T().TempDir(), "orders.yaml")
+	s.handlers = []http.HandlerFunc{body(http.StatusOK, `{"response":{"code":"0000","description":"success"},"data":{"id":"1","name":"john"}}`)}
+
+	rec, err := recorder.New(cassette)
+	s.Require().NoError(err)
+	client, err := newClient(ctx, WithBaseUrl(s.server.URL), WithRecorder(rec))
+	s.Require().NoError(err)
+	user, _, err := Get[*testUser](ctx, client, "/v1/users/1")
+	s.Require().NoError(err)
+	s.Equal("john", user.Name)
+	s.Require().NoError(rec.Stop())
+
+	// Replayed without the server
+	s.server.Close()
+	rec, err = recorder.New(cassette, recorder.WithMode(recorder.ModeReplay))
+	s.Require().NoError(err)
+	client, err = newClient(ctx, WithBaseUrl(s.server.URL), WithRecorder(rec))
+	s.Require().NoError(err)
+	user, _, err = Get[*testUser](ctx, client, "/v1/users/1")
+	s.Require().NoError(err)
+	s.Equal("john", user.Name)
+	s.Equal(int32(1), s.calls.Load())
+}
@@ -0,0 +1,203 @@
+package restclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/constant/sym"
+	"github.com/rosaekapratama/go-starter/page"
+	"github.com/rosaekapratama/go-starter/response"
+	otelCodes "go.opentelemetry.io/otel/codes"
+	grpcCodes "google.golang.org/grpc/codes"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	nullJson               = "null"
+	contentTypeProblemJson = "application/problem+json"
+	linkRelPrev            = "prev"
+	linkRelNext            = "next"
+)
+
+var errInvalidResponseBody = errors.New("response body is not base response")
+
+// Do sends the request and decodes data of base response {response, pagination, errors, data} into T,
+// pagination is nil if the response has none. Response of service with problem response format is supported too,
+// its success body is the data itself with pagination in headers, and its problem details error is mapped by code.
+// Empty success body such as 204 is zero T. Error response is returned as *ResponseError,
+// transport error such as timeout or response.CircuitBreakerOpen is returned as is
+func Do[T any](ctx context.Context, client *Client, req *Request) (data T, pagination *page.PageResponse, err error) {
+	r := client.NewRequest(ctx).
+		SetPathParams(req.PathParams).
+		SetQueryParamsFromValues(req.QueryParams).
+		SetHeaders(req.Headers)
+	if req.Body != nil {
+		r.SetBody(req.Body)
+	}
+
+	resp, err := r.Execute(req.Method, req.Url)
+	if err != nil {
+		return
+	}
+
+	body := bytes.TrimSpace(resp.Body())
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header().Get(headers.ContentType)); mediaType == contentTypeProblemJson {
+		problem := &problemDetails{}
+		if err = json.Unmarshal(body, problem); err != nil || problem.Code == str.Empty {
+			err = fmt.Errorf("%w, method=%s, url=%s, status=%d", errInvalidResponseBody, req.Method, req.Url, resp.StatusCode())
+			return
+		}
+		description := problem.Detail
+		if description == str.Empty {
+			description = problem.Title
+		}
+		statusCode := problem.Status
+		if statusCode == integer.Zero {
+			statusCode = resp.StatusCode()
+		}
+		err = newResponseError(problem.Code, description, statusCode, problem.Errors)
+		return
+	}
+
+	base := &baseResponse{}
+	isBase := json.Unmarshal(body, base) == nil && base.Response != nil
+	if !resp.IsSuccess() {
+		if !isBase {
+			err = fmt.Errorf("%w, method=%s, url=%s, status=%d", errInvalidResponseBody, req.Method, req.Url, resp.StatusCode())
+			return
+		}
+		err = newResponseError(base.Response.Code, base.Response.Description, resp.StatusCode(), base.Errors)
+		return
+	}
+
+	// Success body of problem response format is the data itself
+	raw := json.RawMessage(body)
+	pagination = pageFromHeaders(resp.Header())
+	if isBase {
+		raw = base.Data
+		pagination = base.Pagination
+	}
+	if len(raw) > integer.Zero && !bytes.Equal(raw, []byte(nullJson)) {
+		if err = json.Unmarshal(raw, &data); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Get is Do of GET request
+func Get[T any](ctx context.Context, client *Client, url string) (T, *page.PageResponse, error) {
+	return Do[T](ctx, client, &Request{Method: http.MethodGet, Url: url})
+}
+
+// Post is Do of POST request with JSON body
+func Post[T any](ctx context.Context, client *Client, url string, body interface{}) (T, *page.PageResponse, error) {
+	return Do[T](ctx, client, &Request{Method: http.MethodPost, Url: url, Body: body})
+}
+
+// Put is Do of PUT request with JSON body
+func Put[T any](ctx context.Context, client *Client, url string, body interface{}) (T, *page.PageResponse, error) {
+	return Do[T](ctx, client, &Request{Method: http.MethodPut, Url: url, Body: body})
+}
+
+// Patch is Do of PATCH request with JSON body
+func Patch[T any](ctx context.Context, client *Client, url string, body interface{}) (T, *page.PageResponse, error) {
+	return Do[T](ctx, client, &Request{Method: http.MethodPatch, Url: url, Body: body})
+}
+
+// Delete is Do of DELETE request
+func Delete[T any](ctx context.Context, client *Client, url string) (T, *page.PageResponse, error) {
+	return Do[T](ctx, client, &Request{Method: http.MethodDelete, Url: url})
+}
+
+// newResponseError maps remote code back to response.Response, remote code unknown to this service is response.GeneralError
+func newResponseError(code string, description string, statusCode int, fields []*FieldError) *ResponseError {
+	e := &ResponseError{
+		Response:    response.GeneralError,
+		code:        code,
+		description: description,
+		statusCode:  statusCode,
+		Fields:      fields,
+	}
+	if c, err := strconv.Atoi(code); err == nil {
+		if res := response.Response(c); res.Description() != str.Empty {
+			e.Response = res
+		}
+	}
+	return e
+}
+
+// pageFromHeaders returns pagination of Total-Item, Total-Page and Link headers of problem response format,
+// it is nil if the response has none
+func pageFromHeaders(h http.Header) *page.PageResponse {
+	totalItem, itemErr := strconv.Atoi(h.Get(page.TotalItemHeaderKey))
+	totalPage, pageErr := strconv.Atoi(h.Get(page.TotalPageHeaderKey))
+	if itemErr != nil || pageErr != nil {
+		return nil
+	}
+
+	p := &page.PageResponse{TotalItem: totalItem, TotalPage: totalPage}
+	for _, link := range strings.Split(h.Get(headers.Link), sym.Comma) {
+		start, end := strings.Index(link, "<"), strings.Index(link, ">")
+		if start < integer.Zero || end < start {
+			continue
+		}
+		u, err := url.Parse(link[start+integer.One : end])
+		if err != nil {
+			continue
+		}
+		pageNum, _ := strconv.Atoi(u.Query().Get(page.PageNumQueryKey))
+		switch rel := link[end+integer.One:]; {
+		case strings.Contains(rel, `rel="`+linkRelPrev+`"`):
+			p.PrevPage = pageNum
+		case strings.Contains(rel, `rel="`+linkRelNext+`"`):
+			p.NextPage = pageNum
+		}
+	}
+	return p
+}
+
+// Code returns remote code as is
+func (e *ResponseError) Code() string {
+	return e.code
+}
+
+// Description returns remote description, its args are already applied by the remote service
+func (e *ResponseError) Description() string {
+	return e.description
+}
+
+// HttpStatusCode returns remote HTTP status, so the same status is propagated by restserver.SetResponse
+func (e *ResponseError) HttpStatusCode() int {
+	return e.statusCode
+}
+
+func (e *ResponseError) OtelCode() otelCodes.Code {
+	return e.Response.OtelCode()
+}
+
+func (e *ResponseError) GrpcCode() grpcCodes.Code {
+	return e.Response.GrpcCode()
+}
+
+func (e *ResponseError) IsError() bool {
+	return e.Response.IsError()
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s, code=%s, status=%d", e.description, e.code, e.statusCode)
+}
+
+// Unwrap returns local response of the remote code, so errors.Is(err, response.DataNotFound) works
+func (e *ResponseError) Unwrap() error {
+	return e.Response
+}
@@ -3,6 +3,14 @@ package restclient
 import (
 	"context"
 	"crypto/tls"
+	"errors"
+	"github.com/go-resty/resty/v2"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/oauth2"
+	"github.com/rosaekapratama/go-starter/transport/recorder"
+	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -57,3 +65,290 @@ func WithInsecureSkipVerify(insecureSkipVerify bool) ClientOption {
 func WithTimeout(timeout time.Duration) ClientOption {
 	return &timeoutClientOption{timeout: timeout}
 }
+
+type retryClientOption struct {
+	maxAttempts int
+	opts        []RetryOption
+}
+
+type circuitBreakerClientOption struct {
+	opts []CircuitBreakerOption
+}
+
+type RetryOption interface {
+	Apply(o *retryOptions)
+}
+
+type retryBackoffOption struct {
+	waitTime    time.Duration
+	maxWaitTime time.Duration
+}
+
+type retryStatusCodesOption struct {
+	statusCodes []int
+}
+
+type retryNonIdempotentOption struct {
+}
+
+type CircuitBreakerOption interface {
+	Apply(o *circuitBreakerOptions)
+}
+
+type breakerFailureThresholdOption struct {
+	failureThreshold int
+}
+
+type breakerOpenTimeoutOption struct {
+	openTimeout time.Duration
+}
+
+type breakerHalfOpenRequestsOption struct {
+	halfOpenRequests int
+}
+
+func (o *retryClientOption) Apply(_ context.Context, client *Client) error {
+	if o.maxAttempts < 1 {
+		return errors.New("retry max attempts must be at least 1")
+	}
+	r := &retryOptions{
+		maxAttempts: o.maxAttempts,
+		waitTime:    defaultRetryWaitTime,
+		maxWaitTime: defaultRetryMaxWaitTime,
+		statusCodes: defaultRetryStatusCodes,
+	}
+	for _, opt := range o.opts {
+		opt.Apply(r)
+	}
+	r.apply(client)
+	return nil
+}
+
+func (o *circuitBreakerClientOption) Apply(_ context.Context, client *Client) error {
+	b := &circuitBreakerOptions{
+		failureThreshold: defaultBreakerFailureThreshold,
+		openTimeout:      defaultBreakerOpenTimeout,
+		halfOpenRequests: defaultBreakerHalfOpenRequests,
+	}
+	for _, opt := range o.opts {
+		opt.Apply(b)
+	}
+	if b.failureThreshold < 1 || b.halfOpenRequests < 1 {
+		return errors.New("circuit breaker failure threshold and half-open requests must be at least 1")
+	}
+	client.circuitBreaker = newCircuitBreaker(b)
+	return nil
+}
+
+func (o *retryBackoffOption) Apply(opts *retryOptions) {
+	opts.waitTime = o.waitTime
+	opts.maxWaitTime = o.maxWaitTime
+}
+
+func (o *retryStatusCodesOption) Apply(opts *retryOptions) {
+	opts.statusCodes = o.statusCodes
+}
+
+func (o *retryNonIdempotentOption) Apply(opts *retryOptions) {
+	opts.nonIdempotent = true
+}
+
+func (o *breakerFailureThresholdOption) Apply(opts *circuitBreakerOptions) {
+	opts.failureThreshold = o.failureThreshold
+}
+
+func (o *breakerOpenTimeoutOption) Apply(opts *circuitBreakerOptions) {
+	opts.openTimeout = o.openTimeout
+}
+
+func (o *breakerHalfOpenRequestsOption) Apply(opts *circuitBreakerOptions) {
+	opts.halfOpenRequests = o.halfOpenRequests
+}
+
+// WithRetry retries failed request up to max attempts including the first one,
+// by default only idempotent request is retried on connection error or 408, 429, 502, 503 and 504 status code
+func WithRetry(maxAttempts int, opts ...RetryOption) ClientOption {
+	return &retryClientOption{maxAttempts: maxAttempts, opts: opts}
+}
+
+// WithRetryBackoff set exponential backoff with jitter between attempts, default is 100ms up to 2s,
+// Retry-After response header is honored up to 1 minute instead
+func WithRetryBackoff(waitTime time.Duration, maxWaitTime time.Duration) RetryOption {
+	return &retryBackoffOption{waitTime: waitTime, maxWaitTime: maxWaitTime}
+}
+
+// WithRetryStatusCodes set response status codes which are retried
+func WithRetryStatusCodes(statusCodes ...int) RetryOption {
+	return &retryStatusCodesOption{statusCodes: statusCodes}
+}
+
+// WithRetryNonIdempotent retries POST and PATCH request too, request with Idempotency-Key header is always retried
+func WithRetryNonIdempotent() RetryOption {
+	return &retryNonIdempotentOption{}
+}
+
+// WithCircuitBreaker fails fast with response.CircuitBreakerOpen while the host keeps failing,
+// each host has its own breaker which opens after consecutive connection errors or 5xx responses,
+// then lets probe requests through after open timeout and closes once they succeed
+func WithCircuitBreaker(opts ...CircuitBreakerOption) ClientOption {
+	return &circuitBreakerClientOption{opts: opts}
+}
+
+// WithBreakerFailureThreshold set consecutive failures which open the breaker, default is 5
+func WithBreakerFailureThreshold(failureThreshold int) CircuitBreakerOption {
+	return &breakerFailureThresholdOption{failureThreshold: failureThreshold}
+}
+
+// WithBreakerOpenTimeout set how long the breaker is open before probing, default is 30s
+func WithBreakerOpenTimeout(openTimeout time.Duration) CircuitBreakerOption {
+	return &breakerOpenTimeoutOption{openTimeout: openTimeout}
+}
+
+// WithBreakerHalfOpenRequests set number of probe requests which must succeed to close the breaker, default is 1
+func WithBreakerHalfOpenRequests(halfOpenRequests int) CircuitBreakerOption {
+	return &breakerHalfOpenRequestsOption{halfOpenRequests: halfOpenRequests}
+}
+
+type baseUrlOption struct {
+	baseUrl string
+}
+
+type headersOption struct {
+	headers map[string]string
+}
+
+type basicAuthOption struct {
+	username string
+	password string
+}
+
+type bearerTokenOption struct {
+	token string
+}
+
+type apiKeyOption struct {
+	header string
+	value  string
+}
+
+type proxyOption struct {
+	proxyUrl string
+}
+
+type tokenProviderOption struct {
+	provider oauth2.ITokenProvider
+}
+
+type recorderOption struct {
+	recorder *recorder.Recorder
+}
+
+type tlsConfigOption struct {
+	tlsConfig *tls.Config
+}
+
+func (o *baseUrlOption) Apply(_ context.Context, client *Client) error {
+	client.Resty.SetBaseURL(o.baseUrl)
+	return nil
+}
+
+func (o *headersOption) Apply(_ context.Context, client *Client) error {
+	client.Resty.SetHeaders(o.headers)
+	return nil
+}
+
+func (o *basicAuthOption) Apply(_ context.Context, client *Client) error {
+	client.Resty.SetBasicAuth(o.username, o.password)
+	return nil
+}
+
+func (o *bearerTokenOption) Apply(_ context.Context, client *Client) error {
+	client.Resty.SetAuthToken(o.token)
+	return nil
+}
+
+func (o *apiKeyOption) Apply(_ context.Context, client *Client) error {
+	header := o.header
+	if header == str.Empty {
+		header = headers.XAPIKey
+	}
+	client.Resty.SetHeader(header, o.value)
+	return nil
+}
+
+func (o *proxyOption) Apply(_ context.Context, client *Client) error {
+	proxyUrl, err := url.Parse(o.proxyUrl)
+	if err != nil {
+		return err
+	}
+	client.transport.Proxy = http.ProxyURL(proxyUrl)
+	return nil
+}
+
+func (o *tlsConfigOption) Apply(_ context.Context, client *Client) error {
+	client.transport.TLSClientConfig = o.tlsConfig
+	return nil
+}
+
+// Token is got on every attempt, so retried request doesn't send expired token
+func (o *tokenProviderOption) Apply(_ context.Context, client *Client) error {
+	client.Resty.OnBeforeRequest(func(_ *resty.Client, r *resty.Request) error {
+		token, err := o.provider.Token(r.Context())
+		if err != nil {
+			return err
+		}
+		r.SetAuthToken(token)
+		return nil
+	})
+	return nil
+}
+
+func (o *recorderOption) Apply(_ context.Context, client *Client) error {
+	client.recorder = o.recorder
+	return nil
+}
+
+// WithBaseUrl set base URL prepended to relative request URL
+func WithBaseUrl(baseUrl string) ClientOption {
+	return &baseUrlOption{baseUrl: baseUrl}
+}
+
+// WithHeaders set headers sent on every request
+func WithHeaders(headers map[string]string) ClientOption {
+	return &headersOption{headers: headers}
+}
+
+// WithBasicAuth set basic auth sent on every request
+func WithBasicAuth(username string, password string) ClientOption {
+	return &basicAuthOption{username: username, password: password}
+}
+
+// WithBearerToken set bearer token sent on every request
+func WithBearerToken(token string) ClientOption {
+	return &bearerTokenOption{token: token}
+}
+
+// WithAPIKey set API key header sent on every request, default header is X-API-Key
+func WithAPIKey(header string, value string) ClientOption {
+	return &apiKeyOption{header: header, value: value}
+}
+
+// WithProxy set proxy URL of every request instead of proxy environment variables
+func WithProxy(proxyUrl string) ClientOption {
+	return &proxyOption{proxyUrl: proxyUrl}
+}
+
+// WithTLSConfig set TLS config of the connection, it replaces WithInsecureSkipVerify
+func WithTLSConfig(tlsConfig *tls.Config) ClientOption {
+	return &tlsConfigOption{tlsConfig: tlsConfig}
+}
+
+// WithTokenProvider set bearer token of every request from the OAuth2 token provider
+func WithTokenProvider(provider oauth2.ITokenProvider) ClientOption {
+	return &tokenProviderOption{provider: provider}
+}
+
+// WithRecorder replay response from cassette of the recorder, or record it from the real server, for tests only
+func WithRecorder(recorder *recorder.Recorder) ClientOption {
+	return &recorderOption{recorder: recorder}
+}
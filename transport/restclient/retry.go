@@ -0,0 +1,136 @@
+package restclient
+
+import (
+	"context"
+	"errors"
+	"github.com/go-resty/resty/v2"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/otel"
+	"github.com/rosaekapratama/go-starter/response"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryWaitTime    = 100 * time.Millisecond
+	defaultRetryMaxWaitTime = 2 * time.Second
+
+	// maxRetryAfter caps wait time requested by Retry-After header
+	maxRetryAfter = time.Minute
+
+	counterRetry = "restclient.retry"
+)
+
+var (
+	defaultRetryStatusCodes = []int{
+		http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+
+	idempotentMethods = []string{
+		http.MethodGet,
+		http.MethodHead,
+		http.MethodOptions,
+		http.MethodTrace,
+		http.MethodPut,
+		http.MethodDelete,
+	}
+)
+
+func (o *retryOptions) apply(client *Client) {
+	registerCounters(context.Background())
+	client.Resty.
+		SetRetryCount(o.maxAttempts - 1).
+		SetRetryWaitTime(o.waitTime).
+		SetRetryMaxWaitTime(maxRetryAfter).
+		SetRetryAfter(o.retryAfter).
+		AddRetryCondition(o.shouldRetry).
+		AddRetryHook(o.onRetry)
+}
+
+// shouldRetry replaces resty default condition, so connection error is checked here too
+func (o *retryOptions) shouldRetry(r *resty.Response, err error) bool {
+	if r == nil || r.Request == nil {
+		return false
+	}
+	ctx := r.Request.Context()
+	if ctx.Err() != nil || errors.Is(err, response.CircuitBreakerOpen) {
+		return false
+	}
+	if !o.nonIdempotent && !isIdempotent(r.Request) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return slices.Contains(o.statusCodes, r.StatusCode())
+}
+
+// retryAfter returns wait time of Retry-After header if any, otherwise exponential backoff with jitter
+func (o *retryOptions) retryAfter(_ *resty.Client, r *resty.Response) (time.Duration, error) {
+	if wait := parseRetryAfter(r.Header().Get(headers.RetryAfter)); wait > integer.Zero {
+		return wait, nil
+	}
+
+	wait := o.maxWaitTime
+	if shift := r.Request.Attempt - 1; shift < 32 {
+		if backoff := o.waitTime << shift; backoff > integer.Zero && backoff < o.maxWaitTime {
+			wait = backoff
+		}
+	}
+
+	// Equal jitter, wait is between half and full backoff
+	half := wait / 2
+	if half <= integer.Zero {
+		return wait, nil
+	}
+	return half + time.Duration(rand.Int63n(int64(half))), nil
+}
+
+func (o *retryOptions) onRetry(r *resty.Response, err error) {
+	if r == nil || r.Request == nil {
+		return
+	}
+	ctx := r.Request.Context()
+	log.Warnf(ctx, "Retry REST request, method=%s, url=%s, attempt=%d, status=%d, error=%v",
+		r.Request.Method, r.Request.URL, r.Request.Attempt, r.StatusCode(), err)
+	otel.Count(ctx, counterRetry, integer.One, metric.WithAttributes(
+		attribute.String("method", r.Request.Method),
+		attribute.Int("status", r.StatusCode()),
+	))
+}
+
+// isIdempotent returns true if the method is idempotent or the request has Idempotency-Key header
+func isIdempotent(r *resty.Request) bool {
+	return slices.Contains(idempotentMethods, r.Method) || r.Header.Get(headers.IdempotencyKey) != str.Empty
+}
+
+// parseRetryAfter parses Retry-After header in seconds or HTTP date, zero means absent or invalid
+func parseRetryAfter(value string) time.Duration {
+	if value == str.Empty {
+		return integer.Zero
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < integer.Zero {
+			return integer.Zero
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(t); wait > integer.Zero {
+			return wait
+		}
+	}
+	return integer.Zero
+}
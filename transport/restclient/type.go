@@ -2,26 +2,128 @@ package restclient
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/go-resty/resty/v2"
+	"github.com/rosaekapratama/go-starter/page"
+	"github.com/rosaekapratama/go-starter/response"
+	"github.com/rosaekapratama/go-starter/transport/recorder"
 	"net/http"
+	"net/url"
+	"sync"
+	"time"
 )
 
 type IManager interface {
 	GetDefaultClient() *Client
 	NewClient(ctx context.Context, opts ...ClientOption) (*Client, error)
+
+	// GetClient returns named client of transport.client.rest.clients config
+	GetClient(name string) (*Client, error)
 }
 
 type managerImpl struct {
 	defaultClient *Client
+	clients       map[string]*Client
 }
 
 type Client struct {
-	Resty     *resty.Client
-	transport *http.Transport
-	logging   *clientLogging
+	Resty          *resty.Client
+	transport      *http.Transport
+	logging        *clientLogging
+	circuitBreaker *circuitBreaker
+	recorder       *recorder.Recorder
 }
 
 type clientLogging struct {
 	stdout   bool
 	database string
 }
+
+type retryOptions struct {
+	maxAttempts   int
+	waitTime      time.Duration
+	maxWaitTime   time.Duration
+	statusCodes   []int
+	nonIdempotent bool
+}
+
+type circuitBreakerOptions struct {
+	failureThreshold int
+	openTimeout      time.Duration
+	halfOpenRequests int
+}
+
+type circuitState int
+
+// circuitBreaker keeps circuit of each host
+type circuitBreaker struct {
+	opts  *circuitBreakerOptions
+	hosts map[string]*hostCircuit
+	mu    sync.Mutex
+	now   func() time.Time
+}
+
+type hostCircuit struct {
+	state     circuitState
+	failures  int
+	successes int
+	probes    int
+	openedAt  time.Time
+}
+
+type circuitBreakerTransport struct {
+	next    http.RoundTripper
+	breaker *circuitBreaker
+}
+
+// Request is request of Do, body is marshalled as JSON
+type Request struct {
+	Method      string
+	Url         string
+	PathParams  map[string]string
+	QueryParams url.Values
+	Headers     map[string]string
+	Body        interface{}
+}
+
+// ResponseError is error response of remote go-starter service,
+// set it with restserver.SetResponse to propagate remote code, description and HTTP status as is
+type ResponseError struct {
+	// Response is local response of the remote code, it is response.GeneralError if the code is unknown
+	Response response.Response
+	// Fields is invalid fields of remote validation error
+	Fields []*FieldError
+
+	code        string
+	description string
+	statusCode  int
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// baseResponse is restserver.BaseResponse with raw data, data is decoded only on success
+type baseResponse struct {
+	Response   *baseResponseStatus `json:"response"`
+	Pagination *page.PageResponse  `json:"pagination"`
+	Errors     []*FieldError       `json:"errors"`
+	Data       json.RawMessage     `json:"data"`
+}
+
+type baseResponseStatus struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// problemDetails is restserver.ProblemDetails error response of problem response format
+type problemDetails struct {
+	Title  string        `json:"title"`
+	Status int           `json:"status"`
+	Detail string        `json:"detail"`
+	Code   string        `json:"code"`
+	Errors []*FieldError `json:"errors"`
+}
@@ -0,0 +1,99 @@
+package restserver
+
+import (
+	"github.com/gin-gonic/gin"
+	commonContext "github.com/rosaekapratama/go-starter/context"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/response"
+	"github.com/rosaekapratama/go-starter/slices"
+)
+
+// Policy is custom authorization rule, request is permitted if it returns true,
+// returned error is set as response as is, or as general error if it is not response.Response
+type Policy func(c *gin.Context) (permitted bool, err error)
+
+// RequireRoles permits request only if user has all of the realm roles,
+// it must be placed after InjectKeycloakContext
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userRoles, _ := commonContext.RolesFromContext(c.Request.Context())
+		authorize(c, slices.ContainStringsCaseSensitive(userRoles, roles...), "missing required roles %v", roles)
+	}
+}
+
+// RequireAnyRole permits request if user has at least one of the realm roles,
+// it must be placed after InjectKeycloakContext
+func RequireAnyRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userRoles, _ := commonContext.RolesFromContext(c.Request.Context())
+		authorize(c, containAny(userRoles, roles), "missing any of roles %v", roles)
+	}
+}
+
+// RequireClientRoles permits request only if user has all of the client roles from resource_access claim,
+// it must be placed after InjectKeycloakContext
+func RequireClientRoles(clientId string, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientRoles, _ := commonContext.ClientRolesFromContext(c.Request.Context())
+		authorize(c, slices.ContainStringsCaseSensitive(clientRoles[clientId], roles...), "missing required client roles %v of client %s", roles, clientId)
+	}
+}
+
+// RequireAnyClientRole permits request if user has at least one of the client roles from resource_access claim,
+// it must be placed after InjectKeycloakContext
+func RequireAnyClientRole(clientId string, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientRoles, _ := commonContext.ClientRolesFromContext(c.Request.Context())
+		authorize(c, containAny(clientRoles[clientId], roles), "missing any of client roles %v of client %s", roles, clientId)
+	}
+}
+
+// RequireScopes permits request only if token has all of the scopes,
+// it must be placed after InjectKeycloakContext
+func RequireScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenScopes, _ := commonContext.ScopesFromContext(c.Request.Context())
+		authorize(c, slices.ContainStringsCaseSensitive(tokenScopes, scopes...), "missing required scopes %v", scopes)
+	}
+}
+
+// RequirePolicy permits request only if all of the policies permit it, policies are evaluated in order
+func RequirePolicy(policies ...Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, policy := range policies {
+			permitted, err := policy(c)
+			if err != nil {
+				log.Error(c.Request.Context(), err, "Failed to evaluate authorization policy")
+				SetResponse(c.Writer, err)
+				c.Abort()
+				return
+			}
+			if !authorize(c, permitted, "denied by authorization policy") {
+				return
+			}
+		}
+	}
+}
+
+// authorize aborts request with response.OperationNotPermitted if it is not permitted
+func authorize(c *gin.Context, permitted bool, reason string, a ...any) bool {
+	if permitted {
+		return true
+	}
+
+	r := c.Request
+	userId, _ := commonContext.UserIdFromContext(r.Context())
+	log.Debugf(r.Context(), "Operation is not permitted, path=%s, method=%s, userId=%s, reason="+reason, append([]any{r.URL.Path, r.Method, userId}, a...)...)
+	SetResponse(c.Writer, response.OperationNotPermitted)
+	c.Abort()
+	return false
+}
+
+func containAny(slice []string, elems []string) bool {
+	for _, elem := range elems {
+		if slices.ContainStringCaseSensitive(slice, elem) {
+			return true
+		}
+	}
+	return false
+}
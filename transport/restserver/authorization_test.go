@@ -0,0 +1,135 @@
+package restserver
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/keycloak"
+	"github.com/rosaekapratama/go-starter/keycloak/keycloaktest"
+	"github.com/rosaekapratama/go-starter/response"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type AuthorizationTestSuite struct {
+	suite.Suite
+	server   *keycloaktest.Server
+	verifier keycloak.IVerifier
+	token    string
+}
+
+func (s *AuthorizationTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.server = keycloaktest.NewServer()
+	s.token = s.server.Token("myrealm", map[string]interface{}{
+		keycloak.ClaimPreferredUsername: "john",
+		keycloak.ClaimRealmAccess:       map[string]interface{}{"roles": []string{"user", "auditor"}},
+		keycloak.ClaimResourceAccess:    map[string]interface{}{"billing": map[string]interface{}{"roles": []string{"invoice:read"}}},
+		keycloak.ClaimScope:             "openid profile orders:read",
+	})
+	s.verifier = keycloak.NewVerifier(&config.KeycloakConfig{BaseUrl: s.server.URL()})
+}
+
+func (s *AuthorizationTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func TestAuthorizationTestSuite(t *testing.T) {
+	suite.Run(t, new(AuthorizationTestSuite))
+}
+
+// serve sends request with the token to new router of the handlers, it returns HTTP status of the response
+func (s *AuthorizationTestSuite) serve(token string, handlers ...gin.HandlerFunc) int {
+	router := gin.New()
+	router.Use(interceptResponse(1024), injectAuthContext, InjectKeycloakContext(WithTokenVerifier(s.verifier)))
+	handlers = append(handlers, func(c *gin.Context) {
+		SetResponse(c.Writer, response.Success)
+	})
+	router.GET("/test", handlers...)
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set(headers.Authorization, headers.BearerTokenPrefix+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func (s *AuthorizationTestSuite) TestInvalidToken() {
+	s.Equal(http.StatusUnauthorized, s.serve(s.token[:len(s.token)-4]+"AAAA"))
+}
+
+func (s *AuthorizationTestSuite) TestRoles() {
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		status  int
+	}{
+		{"all roles", RequireRoles("user", "auditor"), http.StatusOK},
+		{"missing role", RequireRoles("user", "admin"), http.StatusForbidden},
+		{"any role", RequireAnyRole("admin", "auditor"), http.StatusOK},
+		{"none of roles", RequireAnyRole("admin"), http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			s.Equal(tt.status, s.serve(s.token, tt.handler))
+		})
+	}
+}
+
+func (s *AuthorizationTestSuite) TestClientRolesAndScopes() {
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		status  int
+	}{
+		{"client roles", RequireClientRoles("billing", "invoice:read"), http.StatusOK},
+		{"role of other client", RequireAnyClientRole("shipping", "invoice:read"), http.StatusForbidden},
+		{"scopes", RequireScopes("orders:read"), http.StatusOK},
+		{"missing scope", RequireScopes("orders:write"), http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			s.Equal(tt.status, s.serve(s.token, tt.handler))
+		})
+	}
+}
+
+func (s *AuthorizationTestSuite) TestPolicy() {
+	s.Run("denied", func() {
+		ownerOnly := func(c *gin.Context) (bool, error) {
+			return c.Query("owner") == "john", nil
+		}
+		s.Equal(http.StatusForbidden, s.serve(s.token, RequirePolicy(ownerOnly)))
+	})
+	s.Run("failed", func() {
+		s.Equal(http.StatusInternalServerError, s.serve(s.token, RequirePolicy(func(c *gin.Context) (bool, error) {
+			return false, errors.New("policy store is unavailable")
+		})))
+	})
+}
+
+func (s *AuthorizationTestSuite) TestMissingVerifier() {
+	router := gin.New()
+	router.Use(interceptResponse(1024), injectAuthContext, InjectKeycloakContext())
+	router.GET("/test", func(c *gin.Context) {
+		SetResponse(c.Writer, response.Success)
+	})
+	serve := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set(headers.Authorization, headers.BearerTokenPrefix+s.token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Token is refused if keycloak config is missing
+	s.Equal(http.StatusUnauthorized, serve())
+
+	// Token is decoded without verification only if verification is explicitly disabled
+	keycloak.Disabled = true
+	defer func() { keycloak.Disabled = false }()
+	s.Equal(http.StatusOK, serve())
+}
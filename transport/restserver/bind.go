@@ -0,0 +1,213 @@
+package restserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/constant/sym"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/response"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const (
+	tagJson = "json"
+	tagForm = "form"
+	tagUri  = "uri"
+
+	// maxMultipartMemory is max memory used to parse multipart form, the rest is stored on disk
+	maxMultipartMemory = 32 << 20
+)
+
+var fieldErrorMessages = map[string]string{
+	"required": "is required",
+	"email":    "must be a valid email",
+	"uuid":     "must be a valid UUID",
+	"url":      "must be a valid URL",
+	"min":      "must be at least %s",
+	"max":      "must be at most %s",
+	"len":      "must have length of %s",
+	"gt":       "must be greater than %s",
+	"gte":      "must be greater than or equal to %s",
+	"lt":       "must be less than %s",
+	"lte":      "must be less than or equal to %s",
+	"oneof":    "must be one of [%s]",
+	"type":     "must be of type %s",
+}
+
+// FieldError is an invalid field of request, it is rendered inside BaseResponse errors field
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError is response.InvalidBodyRequest with its invalid fields,
+// set it with SetResponse to render the fields in BaseResponse
+type ValidationError struct {
+	response.Response
+	Fields []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, integer.Zero, len(e.Fields))
+	for _, field := range e.Fields {
+		fields = append(fields, field.Field+str.Space+field.Message)
+	}
+	return fmt.Sprintf("%s, fields=[%s]", e.Description(), strings.Join(fields, sym.Comma+str.Space))
+}
+
+// Bind decodes query params, JSON or form body and path params into T, in that order,
+// then validates it by its binding tags. It returns *ValidationError if any field is invalid,
+// or response.InvalidBodyRequest if request can't be decoded.
+//
+// Query and form fields are mapped by form tag, body by json tag and path params by uri tag
+func Bind[T any](c *gin.Context) (*T, error) {
+	ctx := c.Request.Context()
+	obj := new(T)
+	objType := reflect.TypeOf(obj).Elem()
+
+	if err := binding.MapFormWithTag(obj, c.Request.URL.Query(), tagForm); err != nil {
+		log.Debugf(ctx, "Failed to bind query params, error=%v", err)
+		return nil, response.InvalidBodyRequest
+	}
+
+	if err := bindBody(c, obj); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, &ValidationError{
+				Response: response.InvalidBodyRequest,
+				Fields: []*FieldError{{
+					Field:   typeErr.Field,
+					Tag:     "type",
+					Param:   typeErr.Type.String(),
+					Message: fmt.Sprintf(fieldErrorMessages["type"], typeErr.Type.String()),
+				}},
+			}
+		}
+		log.Debugf(ctx, "Failed to bind request body, error=%v", err)
+		return nil, response.InvalidBodyRequest
+	}
+
+	if len(c.Params) > integer.Zero {
+		params := make(map[string][]string)
+		for _, param := range c.Params {
+			params[param.Key] = []string{param.Value}
+		}
+		if err := binding.MapFormWithTag(obj, params, tagUri); err != nil {
+			log.Debugf(ctx, "Failed to bind path params, error=%v", err)
+			return nil, response.InvalidBodyRequest
+		}
+	}
+
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		var validationErrs validator.ValidationErrors
+		if !errors.As(err, &validationErrs) {
+			log.Debugf(ctx, "Failed to validate request, error=%v", err)
+			return nil, response.InvalidBodyRequest
+		}
+
+		fields := make([]*FieldError, integer.Zero, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, newFieldError(objType, fe))
+		}
+		return nil, &ValidationError{Response: response.InvalidBodyRequest, Fields: fields}
+	}
+	return obj, nil
+}
+
+func bindBody(c *gin.Context, obj any) error {
+	r := c.Request
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	switch c.ContentType() {
+	case binding.MIMEJSON, str.Empty:
+		err := json.NewDecoder(r.Body).Decode(obj)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	case binding.MIMEPOSTForm:
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+		return binding.MapFormWithTag(obj, r.PostForm, tagForm)
+	case binding.MIMEMultipartPOSTForm:
+		if err := r.ParseMultipartForm(maxMultipartMemory); err != nil {
+			return err
+		}
+		return binding.MapFormWithTag(obj, r.MultipartForm.Value, tagForm)
+	default:
+		return fmt.Errorf("unsupported content type %s", c.ContentType())
+	}
+}
+
+func newFieldError(objType reflect.Type, fe validator.FieldError) *FieldError {
+	message, ok := fieldErrorMessages[fe.Tag()]
+	if !ok {
+		message = "failed on " + fe.Tag() + " validation"
+	} else if strings.Contains(message, "%s") {
+		message = fmt.Sprintf(message, fe.Param())
+	}
+	return &FieldError{
+		Field:   fieldPath(objType, fe.StructNamespace()),
+		Tag:     fe.Tag(),
+		Param:   fe.Param(),
+		Message: message,
+	}
+}
+
+// fieldPath converts struct namespace, ex: Request.Items[0].Name, into path of json, form or uri names, ex: items[0].name
+func fieldPath(t reflect.Type, namespace string) string {
+	parts := strings.Split(namespace, sym.Dot)
+	if len(parts) > integer.One {
+		// Remove root struct name
+		parts = parts[integer.One:]
+	}
+
+	for i, part := range parts {
+		name, index, _ := strings.Cut(part, sym.OpenSquareBracket)
+		if index != str.Empty {
+			index = sym.OpenSquareBracket + index
+		}
+
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			break
+		}
+		field, ok := t.FieldByName(name)
+		if !ok {
+			break
+		}
+		parts[i] = fieldName(field) + index
+
+		t = field.Type
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+	}
+	return strings.Join(parts, sym.Dot)
+}
+
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{tagJson, tagForm, tagUri} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), sym.Comma)
+		if name != str.Empty && name != sym.Hyphen {
+			return name
+		}
+	}
+	return field.Name
+}
@@ -0,0 +1,100 @@
+package restserver
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/response"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type bindItem struct {
+	Sku string `json:"sku" binding:"required"`
+	Qty int    `json:"qty" binding:"gte=1"`
+}
+
+type bindRequest struct {
+	Id     string      `uri:"id" binding:"required,uuid"`
+	Dryrun bool        `form:"dryRun"`
+	Email  string      `json:"email" binding:"required,email"`
+	Items  []*bindItem `json:"items" binding:"required,min=1,dive"`
+}
+
+type BindTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	bound  *bindRequest
+}
+
+func (s *BindTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.bound = nil
+	s.router = gin.New()
+	s.router.Use(interceptResponse(1024))
+	s.router.POST("/orders/:id", func(c *gin.Context) {
+		req, err := Bind[bindRequest](c)
+		if err != nil {
+			SetResponse(c.Writer, err)
+			return
+		}
+		s.bound = req
+		SetResponse(c.Writer, response.Success)
+	})
+}
+
+func TestBindTestSuite(t *testing.T) {
+	suite.Run(t, new(BindTestSuite))
+}
+
+func (s *BindTestSuite) serve(path string, body string) (int, *BaseResponse) {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set(headers.ContentType, contentTypeApplicationJson)
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	res := &BaseResponse{}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), res))
+	return rec.Code, res
+}
+
+func (s *BindTestSuite) TestBind() {
+	code, _ := s.serve("/orders/6f1c7c1e-6f0a-4f43-9c3c-5c9a8b0e7f11?dryRun=true", `{"email":"john@example.com","items":[{"sku":"A1","qty":2}]}`)
+	s.Equal(http.StatusOK, code)
+	s.Require().NotNil(s.bound)
+	s.Equal("6f1c7c1e-6f0a-4f43-9c3c-5c9a8b0e7f11", s.bound.Id)
+	s.True(s.bound.Dryrun)
+	s.Equal("A1", s.bound.Items[0].Sku)
+}
+
+func (s *BindTestSuite) TestFieldErrors() {
+	code, res := s.serve("/orders/123", `{"email":"john","items":[{"qty":0}]}`)
+	s.Equal(http.StatusBadRequest, code)
+	s.Equal(response.InvalidBodyRequest.Code(), res.Response.Code)
+
+	fields := make(map[string]string)
+	for _, fe := range res.Errors {
+		fields[fe.Field] = fe.Tag
+	}
+	s.Equal(map[string]string{
+		"id":           "uuid",
+		"email":        "email",
+		"items[0].sku": "required",
+		"items[0].qty": "gte",
+	}, fields)
+}
+
+func (s *BindTestSuite) TestMalformedBody() {
+	code, res := s.serve("/orders/123", `{"email":`)
+	s.Equal(http.StatusBadRequest, code)
+	s.Empty(res.Errors)
+
+	code, res = s.serve("/orders/123", `{"email":1}`)
+	s.Equal(http.StatusBadRequest, code)
+	s.Require().Len(res.Errors, 1)
+	s.Equal("email", res.Errors[0].Field)
+	s.Equal("type", res.Errors[0].Tag)
+}
@@ -0,0 +1,373 @@
+package restserver
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/inhies/go-bytesize"
+	"github.com/klauspost/compress/zstd"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/constant/sym"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/response"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	EncodingBrotli = "br"
+	EncodingZstd   = "zstd"
+	EncodingGzip   = "gzip"
+
+	defaultCompressionMinSize = 1024
+	wildcard                  = "*"
+	qualityParamPrefix        = "q="
+	weakETagPrefix            = "W/"
+	etagHashLen               = 16
+)
+
+var (
+	defaultEncodings = []string{EncodingBrotli, EncodingZstd, EncodingGzip}
+
+	// zstdEncoder is safe for concurrent EncodeAll
+	zstdEncoder, _ = zstd.NewWriter(nil)
+)
+
+// encodingWriter buffers compressible responses to compress them and set ETag once handler is done,
+// it sits under WriterInterceptor so the logged body is the uncompressed one
+type encodingWriter struct {
+	gin.ResponseWriter
+	req       *http.Request
+	encodings []string
+	minSize   int
+	etag      bool
+
+	status      int
+	buf         bytes.Buffer
+	decided     bool
+	passthrough bool
+}
+
+func (w *encodingWriter) WriteHeader(statusCode int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	w.status = statusCode
+
+	// Response of these status has no body or is a protocol switch, so nothing to buffer
+	if statusCode < http.StatusOK || statusCode == http.StatusNoContent || statusCode == http.StatusNotModified {
+		w.startPassthrough()
+	}
+}
+
+func (w *encodingWriter) WriteHeaderNow() {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	if w.status == integer.Zero {
+		w.status = http.StatusOK
+	}
+}
+
+func (w *encodingWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.decide()
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.buf.Write(b)
+}
+
+func (w *encodingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *encodingWriter) Status() int {
+	if w.passthrough || w.status == integer.Zero {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *encodingWriter) Size() int {
+	if w.passthrough || !w.decided {
+		return w.ResponseWriter.Size()
+	}
+	return w.buf.Len()
+}
+
+func (w *encodingWriter) Written() bool {
+	if w.passthrough {
+		return w.ResponseWriter.Written()
+	}
+	return w.status != integer.Zero || w.decided
+}
+
+// Flush sends buffered response as is and stops buffering, it is used by streaming response like SSE
+func (w *encodingWriter) Flush() {
+	w.startPassthrough()
+	w.ResponseWriter.Flush()
+}
+
+// Hijack stops buffering and hijacks the connection, it is used by WebSocket upgrade
+func (w *encodingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	w.passthrough = true
+	return w.ResponseWriter.Hijack()
+}
+
+func (w *encodingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide buffers response only if its content type is compressible, otherwise it is written as is
+func (w *encodingWriter) decide() {
+	w.decided = true
+	if w.status == integer.Zero {
+		w.status = http.StatusOK
+	}
+	h := w.Header()
+	if h.Get(headers.ContentEncoding) != str.Empty || h.Get(headers.ContentRange) != str.Empty ||
+		!isCompressibleContentType(h.Get(headers.ContentType)) {
+		w.startPassthrough()
+	}
+}
+
+func (w *encodingWriter) startPassthrough() {
+	if w.passthrough {
+		return
+	}
+	w.decided = true
+	w.passthrough = true
+	if w.status != integer.Zero {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.buf.Len() > integer.Zero {
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+}
+
+// finish writes buffered response, it returns http.StatusNotModified if If-None-Match matches the ETag
+func (w *encodingWriter) finish(ctx context.Context) int {
+	if w.passthrough {
+		return w.ResponseWriter.Status()
+	}
+	if !w.decided {
+		// Handler only set status without body
+		if w.status != integer.Zero {
+			w.ResponseWriter.WriteHeader(w.status)
+		}
+		return w.status
+	}
+
+	h := w.Header()
+	body := w.buf.Bytes()
+
+	var encoding string
+	if len(w.encodings) > integer.Zero {
+		h.Add(headers.Vary, headers.AcceptEncoding)
+		if len(body) >= w.minSize {
+			encoding = negotiateEncoding(w.req.Header.Get(headers.AcceptEncoding), w.encodings)
+		}
+	}
+
+	if w.etag && w.status == http.StatusOK && (w.req.Method == http.MethodGet || w.req.Method == http.MethodHead) {
+		etag := h.Get(headers.ETag)
+		if etag == str.Empty {
+			// Compressed body is different representation, so it has different strong ETag
+			etag = strongETag(body, encoding)
+			h.Set(headers.ETag, etag)
+		}
+		if etagMatch(w.req.Header.Get(headers.IfNoneMatch), etag) {
+			h.Del(headers.ContentType)
+			h.Del(headers.ContentLength)
+			h.Del(headers.ContentEncoding)
+			w.ResponseWriter.WriteHeader(http.StatusNotModified)
+			return http.StatusNotModified
+		}
+	}
+
+	if encoding != str.Empty {
+		compressed, err := compress(encoding, body)
+		if err != nil {
+			log.Warnf(ctx, "Failed to compress response, send it uncompressed, encoding=%s, error=%v", encoding, err)
+		} else {
+			body = compressed
+			h.Set(headers.ContentEncoding, encoding)
+		}
+	}
+
+	h.Set(headers.ContentLength, strconv.Itoa(len(body)))
+	w.ResponseWriter.WriteHeader(w.status)
+	if _, err := w.ResponseWriter.Write(body); err != nil {
+		log.Warnf(ctx, "Failed to write response, error=%v", err)
+	}
+	return w.status
+}
+
+// encodeResponse compresses compressible response based on Accept-Encoding
+// and sets strong ETag to GET response, replying 304 if it matches If-None-Match
+func encodeResponse(ctx context.Context, compressionCfg *config.CompressionConfig, etagCfg *config.ETagConfig) gin.HandlerFunc {
+	var encodings []string
+	minSize := defaultCompressionMinSize
+	if compressionCfg == nil || !compressionCfg.Disabled {
+		encodings = defaultEncodings
+		if compressionCfg != nil && len(compressionCfg.Encodings) > integer.Zero {
+			encodings = make([]string, integer.Zero, len(compressionCfg.Encodings))
+			for _, encoding := range compressionCfg.Encodings {
+				encoding = strings.ToLower(strings.TrimSpace(encoding))
+				if encoding != EncodingBrotli && encoding != EncodingZstd && encoding != EncodingGzip {
+					log.Fatalf(ctx, response.InvalidConfig, "Unsupported REST server compression encoding, encoding=%s", encoding)
+					return nil
+				}
+				encodings = append(encodings, encoding)
+			}
+		}
+		if compressionCfg != nil && compressionCfg.MinSize != str.Empty {
+			size, err := bytesize.Parse(compressionCfg.MinSize)
+			if err != nil {
+				log.Fatal(ctx, err, "Invalid value of REST server compression minSize config")
+				return nil
+			}
+			minSize = int(size)
+		}
+	}
+	etag := etagCfg == nil || !etagCfg.Disabled
+
+	return func(c *gin.Context) {
+		w := &encodingWriter{
+			ResponseWriter: c.Writer,
+			req:            c.Request,
+			encodings:      encodings,
+			minSize:        minSize,
+			etag:           etag,
+		}
+		c.Writer = w
+		c.Next()
+
+		if status := w.finish(c.Request.Context()); status == http.StatusNotModified {
+			if i := castInterceptor(c.Writer); i != nil {
+				i.status = status
+			}
+		}
+	}
+}
+
+// isCompressibleContentType returns true for text based content type except SSE
+func isCompressibleContentType(contentType string) bool {
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, sym.SemiColon)[integer.Zero]))
+	switch {
+	case contentType == contentTypeEventStream:
+		return false
+	case strings.HasPrefix(contentType, "text/"):
+		return true
+	case strings.HasSuffix(contentType, "+json"), strings.HasSuffix(contentType, "+xml"):
+		return true
+	}
+	switch contentType {
+	case contentTypeApplicationJson, "application/xml", "application/javascript", "application/x-www-form-urlencoded":
+		return true
+	}
+	return false
+}
+
+// negotiateEncoding returns supported encoding with the highest quality in Accept-Encoding,
+// encoding listed first in supported wins if quality is equal, empty means identity
+func negotiateEncoding(acceptEncoding string, supported []string) string {
+	if acceptEncoding == str.Empty {
+		return str.Empty
+	}
+
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, sym.Comma) {
+		params := strings.Split(part, sym.SemiColon)
+		name := strings.ToLower(strings.TrimSpace(params[integer.Zero]))
+		quality := 1.0
+		for _, param := range params[integer.One:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, qualityParamPrefix) {
+				q, err := strconv.ParseFloat(strings.TrimPrefix(param, qualityParamPrefix), 64)
+				if err != nil {
+					q = 0
+				}
+				quality = q
+			}
+		}
+		qualities[name] = quality
+	}
+
+	var best string
+	var bestQuality float64
+	for _, encoding := range supported {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality, ok = qualities[wildcard]
+		}
+		if ok && quality > bestQuality {
+			best = encoding
+			bestQuality = quality
+		}
+	}
+	return best
+}
+
+func compress(encoding string, b []byte) ([]byte, error) {
+	if encoding == EncodingZstd {
+		return zstdEncoder.EncodeAll(b, make([]byte, integer.Zero, len(b)/2)), nil
+	}
+
+	buf := &bytes.Buffer{}
+	var cw io.WriteCloser
+	if encoding == EncodingBrotli {
+		cw = brotli.NewWriterLevel(buf, brotli.DefaultCompression)
+	} else {
+		cw = gzip.NewWriter(buf)
+	}
+	if _, err := cw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := cw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// strongETag returns quoted hash of the body, suffixed with the content encoding if any
+func strongETag(body []byte, encoding string) string {
+	sum := sha256.Sum256(body)
+	tag := hex.EncodeToString(sum[:etagHashLen])
+	if encoding != str.Empty {
+		tag += sym.Hyphen + encoding
+	}
+	return strconv.Quote(tag)
+}
+
+// etagMatch compares If-None-Match with weak comparison as required by RFC 9110
+func etagMatch(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == str.Empty {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, weakETagPrefix)
+	for _, tag := range strings.Split(ifNoneMatch, sym.Comma) {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), weakETagPrefix)
+		if tag == wildcard || tag == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package restserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/response"
+	"github.com/stretchr/testify/suite"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type CompressionTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	items  []string
+}
+
+func (s *CompressionTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.items = make([]string, 200)
+	for i := range s.items {
+		s.items[i] = "item"
+	}
+	s.router = gin.New()
+	s.router.Use(encodeResponse(context.Background(), &config.CompressionConfig{MinSize: "1KB"}, nil), interceptResponse(16))
+	s.router.GET("/items", func(c *gin.Context) {
+		SetResponse(c.Writer, response.Success)
+		c.JSON(http.StatusOK, s.items)
+	})
+	s.router.GET("/small", func(c *gin.Context) {
+		SetResponse(c.Writer, response.Success)
+		c.JSON(http.StatusOK, map[string]string{"id": "1"})
+	})
+	s.router.POST("/items", func(c *gin.Context) {
+		SetResponse(c.Writer, response.Success)
+		c.JSON(http.StatusOK, s.items)
+	})
+}
+
+func TestCompressionTestSuite(t *testing.T) {
+	suite.Run(t, new(CompressionTestSuite))
+}
+
+func (s *CompressionTestSuite) do(method, path string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// decode returns data of BaseResponse in uncompressed body
+func (s *CompressionTestSuite) decode(encoding string, body []byte) []string {
+	var r io.Reader = bytes.NewReader(body)
+	switch encoding {
+	case EncodingGzip:
+		gr, err := gzip.NewReader(r)
+		s.Require().NoError(err)
+		r = gr
+	case EncodingBrotli:
+		r = brotli.NewReader(r)
+	case EncodingZstd:
+		zr, err := zstd.NewReader(r)
+		s.Require().NoError(err)
+		defer zr.Close()
+		r = zr
+	}
+	b, err := io.ReadAll(r)
+	s.Require().NoError(err)
+
+	var resp struct {
+		Data []string `json:"data"`
+	}
+	s.Require().NoError(json.Unmarshal(b, &resp))
+	return resp.Data
+}
+
+func (s *CompressionTestSuite) TestCompression() {
+	for acceptEncoding, expected := range map[string]string{
+		"gzip":                  EncodingGzip,
+		"gzip, br":              EncodingBrotli,
+		"gzip;q=1, br;q=0.5":    EncodingGzip,
+		"zstd, *;q=0.1":         EncodingZstd,
+		"br;q=0, gzip;q=0, *":   EncodingZstd,
+		"identity":              "",
+		"":                      "",
+		"deflate, br;q=invalid": "",
+	} {
+		rec := s.do(http.MethodGet, "/items", map[string]string{headers.AcceptEncoding: acceptEncoding})
+		s.Equal(http.StatusOK, rec.Code)
+		s.Equal(expected, rec.Header().Get(headers.ContentEncoding), acceptEncoding)
+		s.Equal(headers.AcceptEncoding, rec.Header().Get(headers.Vary))
+		s.Equal(s.items, s.decode(expected, rec.Body.Bytes()), acceptEncoding)
+		s.Equal(strconv.Itoa(rec.Body.Len()), rec.Header().Get(headers.ContentLength))
+	}
+}
+
+func (s *CompressionTestSuite) TestSmallResponseNotCompressed() {
+	rec := s.do(http.MethodGet, "/small", map[string]string{headers.AcceptEncoding: EncodingGzip})
+	s.Empty(rec.Header().Get(headers.ContentEncoding))
+	s.JSONEq(`{"response":{"code":"0000","description":"success"},"data":{"id":"1"}}`, rec.Body.String())
+}
+
+func (s *CompressionTestSuite) TestETag() {
+	rec := s.do(http.MethodGet, "/items", map[string]string{headers.AcceptEncoding: EncodingGzip})
+	etag := rec.Header().Get(headers.ETag)
+	s.True(strings.HasSuffix(etag, `-gzip"`), etag)
+
+	// Identity representation has different ETag
+	identityETag := s.do(http.MethodGet, "/items", nil).Header().Get(headers.ETag)
+	s.NotEmpty(identityETag)
+	s.NotEqual(etag, identityETag)
+
+	rec = s.do(http.MethodGet, "/items", map[string]string{headers.AcceptEncoding: EncodingGzip, headers.IfNoneMatch: `"other", W/` + etag})
+	s.Equal(http.StatusNotModified, rec.Code)
+	s.Empty(rec.Body.Bytes())
+	s.Equal(etag, rec.Header().Get(headers.ETag))
+	s.Empty(rec.Header().Get(headers.ContentEncoding))
+
+	rec = s.do(http.MethodGet, "/items", map[string]string{headers.IfNoneMatch: `"other"`})
+	s.Equal(http.StatusOK, rec.Code)
+
+	// Non GET response has no ETag
+	rec = s.do(http.MethodPost, "/items", map[string]string{headers.IfNoneMatch: identityETag})
+	s.Equal(http.StatusOK, rec.Code)
+	s.Empty(rec.Header().Get(headers.ETag))
+}
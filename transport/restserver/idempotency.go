@@ -0,0 +1,349 @@
+package restserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bsm/redislock"
+	"github.com/gin-gonic/gin"
+	goRedis "github.com/redis/go-redis/v9"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	commonContext "github.com/rosaekapratama/go-starter/context"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/redis"
+	"github.com/rosaekapratama/go-starter/response"
+	otelCodes "go.opentelemetry.io/otel/codes"
+	grpcCodes "google.golang.org/grpc/codes"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	idempotencyKeyFormat     = "idempotency:%s:%s"
+	idempotencyLockKeyFormat = "idempotency:lock:%s:%s"
+	defaultIdempotencyTTL    = 24 * time.Hour
+	defaultIdempotencyLock   = time.Minute
+	maxIdempotencyKeyLength  = 255
+)
+
+// Replayed response must not carry headers of the original request and connection
+var idempotencyExcludedHeaders = []string{
+	headers.ContentLength, "Date", headers.Traceparent, "Tracestate",
+	headers.RateLimitLimit, headers.RateLimitRemaining, headers.RateLimitReset, headers.RetryAfter,
+}
+
+type IdempotencyOption interface {
+	Apply(o *idempotencyOptions)
+}
+
+type idempotencyOptions struct {
+	ttl      time.Duration
+	lockTTL  time.Duration
+	required bool
+	store    idempotencyStore
+}
+
+type idempotencyTTLOption struct {
+	ttl time.Duration
+}
+
+type idempotencyLockTTLOption struct {
+	ttl time.Duration
+}
+
+type idempotencyRequiredOption struct {
+}
+
+type idempotencyStoreOption struct {
+	store idempotencyStore
+}
+
+func (o *idempotencyTTLOption) Apply(opts *idempotencyOptions) {
+	opts.ttl = o.ttl
+}
+
+func (o *idempotencyLockTTLOption) Apply(opts *idempotencyOptions) {
+	opts.lockTTL = o.ttl
+}
+
+func (o *idempotencyRequiredOption) Apply(opts *idempotencyOptions) {
+	opts.required = true
+}
+
+func (o *idempotencyStoreOption) Apply(opts *idempotencyOptions) {
+	opts.store = o.store
+}
+
+// WithIdempotencyTTL set how long stored response is replayed, default is 24h
+func WithIdempotencyTTL(ttl time.Duration) IdempotencyOption {
+	return &idempotencyTTLOption{ttl: ttl}
+}
+
+// WithIdempotencyLockTTL set max processing time of a request before its key can be taken by a retry, default is 1m
+func WithIdempotencyLockTTL(ttl time.Duration) IdempotencyOption {
+	return &idempotencyLockTTLOption{ttl: ttl}
+}
+
+// WithIdempotencyRequired rejects request without Idempotency-Key header with response.InvalidArgument
+func WithIdempotencyRequired() IdempotencyOption {
+	return &idempotencyRequiredOption{}
+}
+
+// idempotencyRecord is final response of a request, stored by its idempotency key
+type idempotencyRecord struct {
+	Hash        string              `json:"hash"`
+	Status      int                 `json:"status"`
+	Code        string              `json:"code"`
+	Description string              `json:"description"`
+	Headers     map[string][]string `json:"headers,omitempty"`
+	Body        []byte              `json:"body,omitempty"`
+}
+
+type idempotencyStore interface {
+	get(ctx context.Context, key string) (*idempotencyRecord, error)
+	set(ctx context.Context, key string, record *idempotencyRecord, ttl time.Duration) error
+
+	// lock locks the key until release is called or ttl is passed, it returns redislock.ErrNotObtained if key is locked
+	lock(ctx context.Context, key string, ttl time.Duration) (release func(), err error)
+}
+
+type redisIdempotencyStore struct {
+	client goRedis.UniversalClient
+	locker redis.ILocker
+}
+
+func (s *redisIdempotencyStore) get(ctx context.Context, key string) (*idempotencyRecord, error) {
+	b, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, goRedis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	record := &idempotencyRecord{}
+	if err = json.Unmarshal(b, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (s *redisIdempotencyStore) set(ctx context.Context, key string, record *idempotencyRecord, ttl time.Duration) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, key, b, ttl).Err()
+}
+
+func (s *redisIdempotencyStore) lock(ctx context.Context, key string, ttl time.Duration) (func(), error) {
+	lock, err := s.locker.Obtain(ctx, key, ttl, nil)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		if err := lock.Release(context.WithoutCancel(ctx)); err != nil && !errors.Is(err, redislock.ErrLockNotHeld) {
+			log.Warnf(ctx, "Failed to release idempotency key lock, key=%s, error=%v", key, err)
+		}
+	}, nil
+}
+
+// replayedResponse is response.IResponse of stored record, so replayed request is logged with original code
+type replayedResponse struct {
+	record *idempotencyRecord
+}
+
+func (r *replayedResponse) Code() string {
+	return r.record.Code
+}
+
+func (r *replayedResponse) Description() string {
+	return r.record.Description
+}
+
+func (r *replayedResponse) HttpStatusCode() int {
+	return r.record.Status
+}
+
+func (r *replayedResponse) OtelCode() otelCodes.Code {
+	return otelCodes.Ok
+}
+
+func (r *replayedResponse) GrpcCode() grpcCodes.Code {
+	return grpcCodes.OK
+}
+
+func (r *replayedResponse) IsError() bool {
+	return false
+}
+
+// Idempotency replays stored response of a request with the same Idempotency-Key header instead of processing it again.
+// Key is scoped by user ID set by InjectKeycloakContext, locked by redis.Locker while the first request is processed,
+// so concurrent duplicates get response.IdempotencyKeyInProgress, and reuse of a key for different method, path or body
+// gets response.IdempotencyKeyReused. Server errors are not stored, so the request can be retried with the same key.
+// It must be called after redis package is initiated, requests are processed as is if redis is not available.
+// Example to use
+//
+//	router.POST("/v1/payments", restserver.InjectKeycloakContext(), restserver.Idempotency(), createPayment)
+func Idempotency(opts ...IdempotencyOption) gin.HandlerFunc {
+	o := &idempotencyOptions{
+		ttl:     defaultIdempotencyTTL,
+		lockTTL: defaultIdempotencyLock,
+	}
+	if redis.Client != nil && redis.Locker != nil {
+		o.store = &redisIdempotencyStore{client: redis.Client, locker: redis.Locker}
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.Apply(o)
+		}
+	}
+
+	if o.store == nil {
+		log.Warn(context.Background(), "Redis is not available, idempotency key is ignored")
+		return func(c *gin.Context) {}
+	}
+
+	return func(c *gin.Context) {
+		r := c.Request
+		ctx := r.Context()
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			return
+		}
+
+		idempotencyKey := c.GetHeader(headers.IdempotencyKey)
+		if idempotencyKey == str.Empty {
+			if o.required {
+				SetResponse(c.Writer, response.InvalidArgument)
+				c.Abort()
+			}
+			return
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			SetResponse(c.Writer, response.InvalidArgument)
+			c.Abort()
+			return
+		}
+
+		hash, err := requestHash(r)
+		if err != nil {
+			log.Error(ctx, err, "Failed to read request body for idempotency hash")
+			SetResponse(c.Writer, response.GeneralError)
+			c.Abort()
+			return
+		}
+
+		userId, _ := commonContext.UserIdFromContext(ctx)
+		storeKey := fmt.Sprintf(idempotencyKeyFormat, userId, idempotencyKey)
+		if replay(c, o.store, storeKey, hash) {
+			return
+		}
+
+		release, err := o.store.lock(ctx, fmt.Sprintf(idempotencyLockKeyFormat, userId, idempotencyKey), o.lockTTL)
+		if errors.Is(err, redislock.ErrNotObtained) {
+			log.Debugf(ctx, "Request with the same idempotency key is in progress, key=%s", idempotencyKey)
+			SetResponse(c.Writer, response.IdempotencyKeyInProgress)
+			c.Abort()
+			return
+		}
+		if err != nil {
+			log.Warnf(ctx, "Failed to lock idempotency key, request is processed without idempotency, key=%s, error=%v", idempotencyKey, err)
+			return
+		}
+		defer release()
+
+		// First request might be completed between the check above and the lock
+		if replay(c, o.store, storeKey, hash) {
+			return
+		}
+
+		i := castInterceptor(c.Writer)
+		i.capture = true
+		c.Next()
+		i.writeIfNotWritten()
+
+		if i.status >= http.StatusInternalServerError {
+			return
+		}
+		record := &idempotencyRecord{
+			Hash:    hash,
+			Status:  i.status,
+			Headers: make(map[string][]string),
+			Body:    i.captured,
+		}
+		if i.response != nil {
+			record.Code = i.response.Code()
+			record.Description = i.response.Description()
+		}
+		for k, v := range i.Header() {
+			record.Headers[k] = v
+		}
+		for _, k := range idempotencyExcludedHeaders {
+			delete(record.Headers, k)
+		}
+		if err = o.store.set(context.WithoutCancel(ctx), storeKey, record, o.ttl); err != nil {
+			log.Errorf(ctx, err, "Failed to store idempotency response, key=%s", idempotencyKey)
+		}
+	}
+}
+
+// replay writes stored response of the key, it returns true if request is completed
+func replay(c *gin.Context, store idempotencyStore, key string, hash string) bool {
+	ctx := c.Request.Context()
+	record, err := store.get(ctx, key)
+	if err != nil {
+		log.Warnf(ctx, "Failed to get idempotency response, key=%s, error=%v", key, err)
+		return false
+	}
+	if record == nil {
+		return false
+	}
+
+	if record.Hash != hash {
+		log.Debugf(ctx, "Idempotency key is reused for different request, key=%s", key)
+		SetResponse(c.Writer, response.IdempotencyKeyReused)
+		c.Abort()
+		return true
+	}
+
+	log.Debugf(ctx, "Replay idempotency response, key=%s, status=%d", key, record.Status)
+	i := castInterceptor(c.Writer)
+	for k, v := range record.Headers {
+		i.Header()[k] = v
+	}
+	i.Header().Set(headers.IdempotentReplayed, str.True)
+	i.response = &replayedResponse{record: record}
+	i.IsRaw = true
+	i.WriteHeader(record.Status)
+	if len(record.Body) > integer.Zero {
+		if _, err = i.Write(record.Body); err != nil {
+			log.Error(ctx, err, "Failed to write idempotency response")
+		}
+	} else {
+		i.IsWritten = true
+	}
+	c.Abort()
+	return true
+}
+
+// requestHash returns hash of method, path, query and body, body is restored so handler can read it
+func requestHash(r *http.Request) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "?" + r.URL.RawQuery + "\n"))
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return str.Empty, err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		h.Write(body)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
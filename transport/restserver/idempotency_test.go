@@ -0,0 +1,141 @@
+package restserver
+
+import (
+	"context"
+	"github.com/bsm/redislock"
+	"github.com/gin-gonic/gin"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/response"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryIdempotencyStore struct {
+	records map[string]*idempotencyRecord
+	locks   map[string]bool
+	mu      sync.Mutex
+}
+
+func (s *memoryIdempotencyStore) get(_ context.Context, key string) (*idempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records[key], nil
+}
+
+func (s *memoryIdempotencyStore) set(_ context.Context, key string, record *idempotencyRecord, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = record
+	return nil
+}
+
+func (s *memoryIdempotencyStore) lock(_ context.Context, key string, _ time.Duration) (func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locks[key] {
+		return nil, redislock.ErrNotObtained
+	}
+	s.locks[key] = true
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.locks, key)
+	}, nil
+}
+
+type IdempotencyTestSuite struct {
+	suite.Suite
+	store  *memoryIdempotencyStore
+	router *gin.Engine
+	calls  int
+	status response.Response
+}
+
+func (s *IdempotencyTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.calls = 0
+	s.status = response.Success
+	s.store = &memoryIdempotencyStore{records: make(map[string]*idempotencyRecord), locks: make(map[string]bool)}
+	s.router = gin.New()
+	s.router.Use(interceptResponse(16))
+	s.router.POST("/payments", Idempotency(&idempotencyStoreOption{store: s.store}), func(c *gin.Context) {
+		s.calls++
+		c.Header("X-Payment-Id", "pay-1")
+		SetResponse(c.Writer, s.status)
+		c.JSON(http.StatusOK, map[string]interface{}{"id": "pay-1", "amount": 100, "calls": s.calls})
+	})
+}
+
+func TestIdempotencyTestSuite(t *testing.T) {
+	suite.Run(t, new(IdempotencyTestSuite))
+}
+
+func (s *IdempotencyTestSuite) serve(key string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(body))
+	req.Header.Set(headers.ContentType, contentTypeApplicationJson)
+	if key != "" {
+		req.Header.Set(headers.IdempotencyKey, key)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *IdempotencyTestSuite) TestReplay() {
+	first := s.serve("key-1", `{"amount":100}`)
+	s.Equal(http.StatusOK, first.Code)
+
+	second := s.serve("key-1", `{"amount":100}`)
+	s.Equal(1, s.calls)
+	s.Equal(http.StatusOK, second.Code)
+	s.Equal(first.Body.String(), second.Body.String())
+	s.Equal("pay-1", second.Header().Get("X-Payment-Id"))
+	s.Equal("true", second.Header().Get(headers.IdempotentReplayed))
+
+	// Full body is replayed regardless of payload log size limit
+	s.Greater(second.Body.Len(), 16)
+}
+
+func (s *IdempotencyTestSuite) TestReusedKey() {
+	s.serve("key-1", `{"amount":100}`)
+	rec := s.serve("key-1", `{"amount":200}`)
+	s.Equal(http.StatusUnprocessableEntity, rec.Code)
+	s.Equal(1, s.calls)
+
+	// Same body with different query is different request
+	req := httptest.NewRequest(http.MethodPost, "/payments?currency=USD", strings.NewReader(`{"amount":100}`))
+	req.Header.Set(headers.ContentType, contentTypeApplicationJson)
+	req.Header.Set(headers.IdempotencyKey, "key-1")
+	rec = httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	s.Equal(http.StatusUnprocessableEntity, rec.Code)
+	s.Equal(1, s.calls)
+}
+
+func (s *IdempotencyTestSuite) TestInProgress() {
+	release, err := s.store.lock(context.Background(), "idempotency:lock::key-1", time.Minute)
+	s.Require().NoError(err)
+	defer release()
+
+	rec := s.serve("key-1", `{"amount":100}`)
+	s.Equal(http.StatusConflict, rec.Code)
+	s.Equal(0, s.calls)
+}
+
+func (s *IdempotencyTestSuite) TestServerErrorIsNotStored() {
+	s.status = response.GeneralError
+	s.serve("key-1", `{"amount":100}`)
+	s.status = response.Success
+	rec := s.serve("key-1", `{"amount":100}`)
+	s.Equal(http.StatusOK, rec.Code)
+	s.Equal(2, s.calls)
+
+	// Request without key is always processed
+	s.serve("", `{"amount":100}`)
+	s.Equal(3, s.calls)
+}
@@ -33,6 +33,7 @@ const (
 type BaseResponse struct {
 	Response   *Response          `json:"response"`
 	Pagination *page.PageResponse `json:"pagination,omitempty"`
+	Errors     []*FieldError      `json:"errors,omitempty"`
 	Data       string             `json:"data,omitempty"`
 }
 
@@ -54,6 +55,12 @@ type WriterInterceptor struct {
 	IsRaw               bool
 	a                   []any
 	payloadLogSizeLimit int
+
+	// Full written body is kept in captured if capture is true, unlike body which is truncated for logging
+	capture  bool
+	captured []byte
+
+	request *http.Request
 }
 
 func (w *WriterInterceptor) Unwrap() http.ResponseWriter {
@@ -89,15 +96,9 @@ func (w *WriterInterceptor) Write(b []byte) (int, error) {
 	contentType := strings.ToLower(strings.Split(w.ResponseWriter.Header().Get(headers.ContentType), sym.SemiColon)[0])
 	if contentType != contentTypeApplicationJson || w.IsRaw {
 		w.IsWritten = true
-		if len(b) > w.payloadLogSizeLimit {
-			w.body = make([]byte, w.payloadLogSizeLimit)
-			copy(w.body, b[:w.payloadLogSizeLimit])
-			w.body = append(w.body, sym.Ellipsis...)
-		} else if len(b) > 0 {
-			w.body = make([]byte, len(b))
-			copy(w.body, b)
-		} else {
-			w.body = make([]byte, 0)
+		w.setLogBody(b)
+		if w.capture {
+			w.captured = append(w.captured, b...)
 		}
 		return w.ResponseWriter.Write(b)
 	}
@@ -106,6 +107,10 @@ func (w *WriterInterceptor) Write(b []byte) (int, error) {
 		b = make([]byte, integer.Zero)
 	}
 
+	if responseFormat == ResponseFormatProblem {
+		return w.writeProblemFormat(b)
+	}
+
 	r := &BaseResponse{
 		Response: &Response{},
 	}
@@ -157,15 +162,9 @@ func (w *WriterInterceptor) Write(b []byte) (int, error) {
 	w.IsWritten = true
 	w.size = realLen
 	w.status = w.response.HttpStatusCode()
-	if len(rb) > w.payloadLogSizeLimit {
-		w.body = make([]byte, w.payloadLogSizeLimit)
-		copy(w.body, rb[:w.payloadLogSizeLimit])
-		w.body = append(w.body, sym.Ellipsis...)
-	} else if len(rb) > 0 {
-		w.body = make([]byte, len(rb))
-		copy(w.body, rb)
-	} else {
-		w.body = make([]byte, 0)
+	w.setLogBody(rb)
+	if w.capture {
+		w.captured = append(w.captured, rb...)
 	}
 	w.ResponseWriter.WriteHeader(w.response.HttpStatusCode())
 	_, err = w.ResponseWriter.Write(rb)
@@ -232,6 +231,23 @@ func NewWriterInterceptor(ctx context.Context, w http.ResponseWriter, payloadLog
 		false,
 		nil,
 		payloadLogSizeLimit,
+		false,
+		nil,
+		nil,
+	}
+}
+
+// setLogBody keeps written body for logging, truncated to payload log size limit
+func (w *WriterInterceptor) setLogBody(b []byte) {
+	if len(b) > w.payloadLogSizeLimit {
+		w.body = make([]byte, w.payloadLogSizeLimit)
+		copy(w.body, b[:w.payloadLogSizeLimit])
+		w.body = append(w.body, sym.Ellipsis...)
+	} else if len(b) > 0 {
+		w.body = make([]byte, len(b))
+		copy(w.body, b)
+	} else {
+		w.body = make([]byte, 0)
 	}
 }
 
@@ -252,6 +268,11 @@ func (r *BaseResponse) setResponse(i *WriterInterceptor) error {
 	} else {
 		r.Response.Description = i.response.Description()
 	}
+
+	// Set invalid fields if response is validation error
+	if validationErr, ok := i.response.(*ValidationError); ok {
+		r.Errors = validationErr.Fields
+	}
 	return err
 }
 
@@ -268,6 +289,7 @@ func (r *BaseResponse) marshal(ctx context.Context) ([]byte, error) {
 		r.Response.Code = response.GeneralError.Code()
 		r.Response.Description = response.GeneralError.Description()
 		r.Pagination = nil
+		r.Errors = nil
 		r.Data = str.Empty
 
 		// It should not be error, just in case
@@ -281,6 +303,22 @@ func (r *BaseResponse) marshal(ctx context.Context) ([]byte, error) {
 	return b, nil
 }
 
+// writeIfNotWritten writes BaseResponse of the response set by SetResponse if handler doesn't write any body,
+// content type is application/json if handler doesn't set any
+func (w *WriterInterceptor) writeIfNotWritten() {
+	contentType := strings.ToLower(strings.Split(w.ResponseWriter.Header().Get(headers.ContentType), sym.SemiColon)[0])
+	if contentType == str.Empty {
+		w.ResponseWriter.Header().Set(headers.ContentType, contentTypeApplicationJson)
+		contentType = contentTypeApplicationJson
+	}
+	if !w.IsWritten && contentType == contentTypeApplicationJson {
+		_, err := w.Write(nil)
+		if err != nil {
+			log.Error(w.ctx, err)
+		}
+	}
+}
+
 func castInterceptor(w http.ResponseWriter) *WriterInterceptor {
 	var i *WriterInterceptor
 	switch w.(type) {
@@ -2,8 +2,7 @@ package restserver
 
 import (
 	"context"
-	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/rosaekapratama/go-starter/constant/headers"
 	"github.com/rosaekapratama/go-starter/constant/str"
@@ -14,10 +13,13 @@ import (
 	"github.com/rosaekapratama/go-starter/response"
 	"github.com/rosaekapratama/go-starter/slices"
 	commonStrings "github.com/rosaekapratama/go-starter/strings"
-	"strings"
 )
 
-var additionalClaims []string
+var (
+	additionalClaims []string
+
+	errVerifierNotFound = errors.New("keycloak token verifier not found, keycloak config is missing")
+)
 
 type KeycloakContextOption interface {
 	Apply(ctx context.Context, c *gin.Context, claims map[string]interface{})
@@ -30,6 +32,10 @@ type KeycloakContextWhitelistedHostOption struct {
 	whilistedHosts []string
 }
 
+type KeycloakContextTokenVerifierOption struct {
+	verifier keycloak.IVerifier
+}
+
 func (o *KeycloakContextAdditionalClaimOption) Apply(_ context.Context, _ *gin.Context, _ map[string]interface{}) {
 }
 
@@ -55,6 +61,9 @@ func (o *KeycloakContextWhitelistedHostOption) Apply(ctx context.Context, c *gin
 	}
 }
 
+func (o *KeycloakContextTokenVerifierOption) Apply(_ context.Context, _ *gin.Context, _ map[string]interface{}) {
+}
+
 func WithAdditionalClaim(claims ...string) KeycloakContextOption {
 	additionalClaims = append(additionalClaims, claims...)
 	return &KeycloakContextAdditionalClaimOption{}
@@ -64,7 +73,19 @@ func WithWhitelistedHost(hosts ...string) KeycloakContextOption {
 	return &KeycloakContextWhitelistedHostOption{whilistedHosts: hosts}
 }
 
+// WithTokenVerifier overrides keycloak.Verifier used to verify token signature and claims
+func WithTokenVerifier(verifier keycloak.IVerifier) KeycloakContextOption {
+	return &KeycloakContextTokenVerifierOption{verifier: verifier}
+}
+
 func InjectKeycloakContext(options ...KeycloakContextOption) gin.HandlerFunc {
+	var verifier keycloak.IVerifier
+	for _, option := range options {
+		if o, ok := option.(*KeycloakContextTokenVerifierOption); ok {
+			verifier = o.verifier
+		}
+	}
+
 	return func(c *gin.Context) {
 		// skip if request is health check
 		if isHealthCheckPath(c) {
@@ -82,127 +103,52 @@ func InjectKeycloakContext(options ...KeycloakContextOption) gin.HandlerFunc {
 		}
 		// get keycloak token from context which already set by injectAuthContext function
 		if tokenStr, ok := commonContext.TokenFromContext(ctx); ok {
-
-			// Split JWT into header, payload, and signature
-			parts := strings.Split(tokenStr, ".")
-			payloadBase64 := parts[1]
-
-			// Decode payload from base64
-			payloadBytes, err := base64.RawURLEncoding.DecodeString(payloadBase64)
-			if err != nil {
-				log.Error(ctx, err, "Error decoding token payload")
-				SetResponse(w, response.GeneralError)
-				c.Abort()
-				return
+			tokenVerifier := verifier
+			if tokenVerifier == nil {
+				tokenVerifier = keycloak.Verifier
 			}
 
-			// Parse JSON payload to access claims
-			if err := json.Unmarshal(payloadBytes, &claims); err != nil {
-				log.Error(ctx, err, "Error parsing token JSON payload")
-				SetResponse(w, response.GeneralError)
+			if tokenVerifier != nil {
+				// Verify token signature and claims against realm JWKS
+				verifiedClaims, err := tokenVerifier.Verify(ctx, tokenStr)
+				if err != nil {
+					log.Debugf(ctx, "Invalid keycloak token, path=%s, method=%s", c.Request.URL.Path, c.Request.Method)
+					SetResponse(w, response.UnauthorizedAccess)
+					c.Abort()
+					return
+				}
+				for k, v := range verifiedClaims {
+					claims[k] = v
+				}
+			} else if !keycloak.Disabled {
+				// Fail closed, unverified token is only trusted if verification is explicitly disabled
+				log.Error(ctx, errVerifierNotFound, "Keycloak token is refused")
+				SetResponse(w, response.UnauthorizedAccess)
 				c.Abort()
 				return
-			}
-		} else {
-			log.Tracef(ctx, "Unable to set context from keycloak token, path=%s, method=%s", c.Request.URL.Path, c.Request.Method)
-		}
-
-		// set common token claim to context
-		// set sub claim to context if exists
-		if v, ok := claims[keycloak.ClaimSub]; ok {
-			sc := v.(string)
-			if sc == str.Empty {
-				log.Warn(ctx, "Unable to set context, sub claim is empty")
-			} else {
-				ctx = commonContext.ContextWithUserId(ctx, sc)
-			}
-		} else {
-			log.Warn(ctx, "Unable to set context, missing sub claim")
-		}
-
-		// set realm from issuer claim to context if exists
-		if v, ok := claims[keycloak.ClaimIss]; ok {
-			iss := v.(string)
-			if iss == str.Empty {
-				log.Warn(ctx, "Unable to set context, iss claim is empty")
-			} else {
-				startIdx := strings.Index(iss, realmsPath) + len(realmsPath)
-				ctx = commonContext.ContextWithRealm(ctx, iss[startIdx:])
-			}
-		} else {
-			log.Warn(ctx, "Unable to set context, missing iss claim")
-		}
-
-		// set preferred_username claim to context if exists
-		var puc string
-		if v, ok := claims[keycloak.ClaimPreferredUsername]; ok {
-			puc = v.(string)
-			if puc == str.Empty {
-				log.Warn(ctx, "Unable to set context, preferred_username claim is empty")
 			} else {
-				ctx = commonContext.ContextWithUsername(ctx, puc)
-			}
-		} else {
-			log.Warn(ctx, "Unable to set context, missing preferred_username claim")
-		}
-
-		// set name claim to context if exists, or set with username if empty
-		var nc string
-		if v, ok := claims[keycloak.ClaimName]; ok {
-			nc = v.(string)
-			if nc == str.Empty {
-				log.Trace(ctx, "Name claim is empty, set context using preferred_username claim value instead")
-				nc = puc
-			}
-		} else {
-			log.Trace(ctx, "Missing name claim, set context using preferred_username claim value instead")
-			nc = puc
-		}
-		ctx = commonContext.ContextWithFullName(ctx, nc)
-
-		// set email claim to context if exists
-		if v, ok := claims[keycloak.ClaimEmail]; ok {
-			ec := v.(string)
-			if ec == str.Empty {
-				log.Trace(ctx, "Unable to set context, email claim is empty")
-			} else {
-				ctx = commonContext.ContextWithEmail(ctx, ec)
-			}
-		} else {
-			log.Trace(ctx, "Unable to set context, missing email claim")
-		}
-
-		// set realm access claim to context if exists
-		if v, ok := claims[keycloak.ClaimRealmAccess]; ok {
-			rac := v.(map[string]interface{})
-			if len(rac) == 0 {
-				log.Trace(ctx, "Unable to set context, realm access claim is empty")
-			} else if v, exists := rac[keycloak.ClaimRealmAccessRoles]; exists {
-				rc := v.([]interface{})
-				if len(rc) == 0 {
-					log.Trace(ctx, "Unable to set context, roles of realm access claim is empty")
-				} else {
-					roles := make([]string, 0)
-					for _, role := range rc {
-						roles = append(roles, role.(string))
+				// Decode claims without verification
+				decodedClaims, err := keycloak.DecodeClaims(tokenStr)
+				if err != nil {
+					if errors.Is(err, response.UnauthorizedAccess) {
+						log.Debug(ctx, "Malformed keycloak token")
+					} else {
+						log.Error(ctx, err, "Error decoding keycloak token")
 					}
-					ctx = commonContext.ContextWithRoles(ctx, roles)
+					SetResponse(w, err)
+					c.Abort()
+					return
+				}
+				for k, v := range decodedClaims {
+					claims[k] = v
 				}
 			}
 		} else {
-			log.Trace(ctx, "Unable to set context, missing email claim")
+			log.Tracef(ctx, "Unable to set context from keycloak token, path=%s, method=%s", c.Request.URL.Path, c.Request.Method)
 		}
 
-		// set custom provided claim to context if exists
-		for _, additionalClaim := range additionalClaims {
-			k := commonStrings.SnakeToCamel(additionalClaim)
-			if v, ok := claims[additionalClaim]; ok {
-				ctx = context.WithValue(ctx, k, v)
-				commonContext.AddManagedKey(k)
-			} else {
-				log.Tracef(ctx, "Unable to set context, missing %s claim", additionalClaim)
-			}
-		}
+		// set common and additional token claims to context
+		ctx = keycloak.ContextWithClaims(ctx, claims, additionalClaims...)
 
 		// override request context with new context
 		c.Request = c.Request.WithContext(ctx)
@@ -0,0 +1,768 @@
+package restserver
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/constant/sym"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/page"
+	"github.com/rosaekapratama/go-starter/response"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	openAPIVersion        = "3.0.3"
+	defaultOpenAPIPath    = "/openapi.json"
+	defaultOpenAPIUiPath  = "/docs"
+	defaultOpenAPIVersion = "1.0.0"
+	contentTypeTextHtml   = "text/html; charset=utf-8"
+
+	securitySchemeBearer = "bearerAuth"
+	schemaRefPrefix      = "#/components/schemas/"
+	schemaResponse       = "Response"
+	schemaPageResponse   = "PageResponse"
+	schemaFieldError     = "FieldError"
+	schemaProblemDetails = "ProblemDetails"
+
+	typeObject  = "object"
+	typeArray   = "array"
+	typeString  = "string"
+	typeInteger = "integer"
+	typeNumber  = "number"
+	typeBoolean = "boolean"
+)
+
+var (
+	// openAPIUi is set by SetOpenAPIUi, document is served without UI if it is nil
+	openAPIUi OpenAPIUi
+
+	routeDocs   = make(map[string]*routeDoc)
+	routeDocsMu sync.RWMutex
+
+	pathParamRegex = regexp.MustCompile(`[:*]([^/]+)`)
+	schemaNameRe   = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+	typeTime = reflect.TypeOf(time.Time{})
+	typeUUID = reflect.TypeOf(uuid.UUID{})
+	typeRaw  = reflect.TypeOf(json.RawMessage{})
+)
+
+// OpenAPIUi renders UI of OpenAPI document, it is set by SetOpenAPIUi
+type OpenAPIUi interface {
+	// Page returns HTML page which shows the document of docPath,
+	// assetPath followed by asset name is the path of each asset
+	Page(title string, docPath string, assetPath string) []byte
+
+	// Assets returns files of the page served under the UI path, keyed by name
+	Assets() map[string]*OpenAPIUiAsset
+}
+
+type OpenAPIUiAsset struct {
+	ContentType string
+	Content     []byte
+}
+
+// Schema is OpenAPI schema object, only the subset generated from Go types
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Example              interface{}        `json:"example,omitempty"`
+}
+
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       *openAPIInfo                            `json:"info"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components *openAPIComponents                      `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type openAPIOperation struct {
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+	Parameters  []*openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+	Security    []map[string][]string       `json:"security,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*openAPIMediaType `json:"content"`
+}
+
+type openAPIMediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type openAPIResponse struct {
+	Description string                       `json:"description"`
+	Headers     map[string]*openAPIHeader    `json:"headers,omitempty"`
+	Content     map[string]*openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIHeader struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*Schema                `json:"schemas"`
+	SecuritySchemes map[string]map[string]interface{} `json:"securitySchemes,omitempty"`
+}
+
+// routeDoc is documentation of a route, registered by Document
+type routeDoc struct {
+	summary     string
+	description string
+	tags        []string
+	deprecated  bool
+	bearerAuth  bool
+	request     reflect.Type
+	response    reflect.Type
+	page        bool
+	errors      []response.IResponse
+}
+
+type RouteDocOption interface {
+	Apply(d *routeDoc)
+}
+
+type docSummaryOption struct {
+	summary string
+}
+
+type docDescriptionOption struct {
+	description string
+}
+
+type docTagsOption struct {
+	tags []string
+}
+
+type docDeprecatedOption struct {
+}
+
+type docBearerAuthOption struct {
+}
+
+type docRequestOption struct {
+	t reflect.Type
+}
+
+type docResponseOption struct {
+	t    reflect.Type
+	page bool
+}
+
+type docErrorsOption struct {
+	responses []response.IResponse
+}
+
+func (o *docSummaryOption) Apply(d *routeDoc) {
+	d.summary = o.summary
+}
+
+func (o *docDescriptionOption) Apply(d *routeDoc) {
+	d.description = o.description
+}
+
+func (o *docTagsOption) Apply(d *routeDoc) {
+	d.tags = append(d.tags, o.tags...)
+}
+
+func (o *docDeprecatedOption) Apply(d *routeDoc) {
+	d.deprecated = true
+}
+
+func (o *docBearerAuthOption) Apply(d *routeDoc) {
+	d.bearerAuth = true
+}
+
+func (o *docRequestOption) Apply(d *routeDoc) {
+	d.request = o.t
+}
+
+func (o *docResponseOption) Apply(d *routeDoc) {
+	d.response = o.t
+	d.page = o.page
+}
+
+func (o *docErrorsOption) Apply(d *routeDoc) {
+	d.errors = append(d.errors, o.responses...)
+}
+
+// WithDocSummary set route summary
+func WithDocSummary(summary string) RouteDocOption {
+	return &docSummaryOption{summary: summary}
+}
+
+// WithDocDescription set route description
+func WithDocDescription(description string) RouteDocOption {
+	return &docDescriptionOption{description: description}
+}
+
+// WithDocTags groups route under the tags
+func WithDocTags(tags ...string) RouteDocOption {
+	return &docTagsOption{tags: tags}
+}
+
+// WithDocDeprecated marks route as deprecated
+func WithDocDeprecated() RouteDocOption {
+	return &docDeprecatedOption{}
+}
+
+// WithDocBearerAuth marks route as requiring bearer token, 401 and 403 responses are documented
+func WithDocBearerAuth() RouteDocOption {
+	return &docBearerAuthOption{}
+}
+
+// WithDocRequest documents request of type T the same way Bind decodes it,
+// uri tagged fields are path params, form tagged fields are query params and the rest is JSON body
+func WithDocRequest[T any]() RouteDocOption {
+	return &docRequestOption{t: reflect.TypeOf((*T)(nil)).Elem()}
+}
+
+// WithDocResponse documents T as data of BaseResponse
+func WithDocResponse[T any]() RouteDocOption {
+	return &docResponseOption{t: reflect.TypeOf((*T)(nil)).Elem()}
+}
+
+// WithDocPageResponse documents []T as data of BaseResponse with pagination, page query params are documented
+func WithDocPageResponse[T any]() RouteDocOption {
+	return &docResponseOption{t: reflect.TypeOf((*[]T)(nil)).Elem(), page: true}
+}
+
+// WithDocErrors documents error responses which route may return
+func WithDocErrors(responses ...response.IResponse) RouteDocOption {
+	return &docErrorsOption{responses: responses}
+}
+
+// Document describes route of Router in OpenAPI document, path is in gin format, ex: /v1/users/:id.
+// Registered routes which are not documented are still listed with BaseResponse envelope.
+// Example to use
+//
+//	restserver.Router.POST("/v1/users", createUser)
+//	restserver.Document(http.MethodPost, "/v1/users",
+//		restserver.WithDocSummary("Create user"),
+//		restserver.WithDocTags("user"),
+//		restserver.WithDocBearerAuth(),
+//		restserver.WithDocRequest[CreateUserRequest](),
+//		restserver.WithDocResponse[User](),
+//		restserver.WithDocErrors(response.DataNotFound))
+func Document(method string, path string, opts ...RouteDocOption) {
+	d := &routeDoc{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.Apply(d)
+		}
+	}
+
+	routeDocsMu.Lock()
+	defer routeDocsMu.Unlock()
+	routeDocs[strings.ToUpper(method)+str.Space+path] = d
+}
+
+// SetOpenAPIUi sets UI of OpenAPI document served on the UI path, it must be called before restserver is initiated,
+// import swaggerui package to set Swagger UI
+func SetOpenAPIUi(ui OpenAPIUi) {
+	openAPIUi = ui
+}
+
+// registerOpenAPI serves OpenAPI document and its UI if enabled
+func registerOpenAPI(ctx context.Context, cfg *config.OpenAPIConfig, appName string) {
+	if cfg == nil || !cfg.Enabled {
+		return
+	}
+
+	info := &openAPIInfo{Title: cfg.Title, Version: cfg.Version, Description: cfg.Description}
+	if info.Title == str.Empty {
+		info.Title = appName
+	}
+	if info.Version == str.Empty {
+		info.Version = defaultOpenAPIVersion
+	}
+	path := cfg.Path
+	if path == str.Empty {
+		path = defaultOpenAPIPath
+	}
+	uiPath := cfg.UiPath
+	if uiPath == str.Empty {
+		uiPath = defaultOpenAPIUiPath
+	}
+	excluded := map[string]bool{path: true}
+	if openAPIUi != nil {
+		excluded[uiPath] = true
+		for name := range openAPIUi.Assets() {
+			excluded[openAPIUiAssetPath(uiPath, name)] = true
+		}
+	}
+
+	Router.GET(path, func(c *gin.Context) {
+		b, err := json.Marshal(buildOpenAPI(info, Router.Routes(), excluded))
+		if err != nil {
+			log.Error(c.Request.Context(), err, "Failed to marshal OpenAPI document")
+			SetResponse(c.Writer, response.GeneralError)
+			return
+		}
+		SetRawResponse(c.Writer, response.Success)
+		c.Data(http.StatusOK, contentTypeApplicationJson, b)
+	})
+	if openAPIUi == nil {
+		log.Infof(ctx, "OpenAPI document is served on %s, no UI is set by SetOpenAPIUi", path)
+		return
+	}
+
+	uiPage := openAPIUi.Page(info.Title, path, openAPIUiAssetPath(uiPath, str.Empty))
+	Router.GET(uiPath, func(c *gin.Context) {
+		SetRawResponse(c.Writer, response.Success)
+		c.Data(http.StatusOK, contentTypeTextHtml, uiPage)
+	})
+	for name, asset := range openAPIUi.Assets() {
+		asset := asset
+		Router.GET(openAPIUiAssetPath(uiPath, name), func(c *gin.Context) {
+			SetRawResponse(c.Writer, response.Success)
+			c.Data(http.StatusOK, asset.ContentType, asset.Content)
+		})
+	}
+	log.Infof(ctx, "OpenAPI document is served on %s and its UI on %s", path, uiPath)
+}
+
+// openAPIUiAssetPath returns path of the UI asset name which is served under UI path
+func openAPIUiAssetPath(uiPath string, name string) string {
+	return strings.TrimSuffix(uiPath, sym.ForwardSlash) + sym.ForwardSlash + name
+}
+
+// buildOpenAPI generates OpenAPI document of the routes
+func buildOpenAPI(info *openAPIInfo, routes gin.RoutesInfo, excluded map[string]bool) *openAPIDocument {
+	g := &schemaGenerator{problem: responseFormat == ResponseFormatProblem, names: make(map[reflect.Type]string), schemas: map[string]*Schema{
+		schemaResponse: {
+			Type:     typeObject,
+			Required: []string{"code", "description"},
+			Properties: map[string]*Schema{
+				"code":        {Type: typeString, Example: response.Success.Code()},
+				"description": {Type: typeString, Example: response.Success.Description()},
+			},
+		},
+		schemaFieldError: {
+			Type:     typeObject,
+			Required: []string{"field", "tag", "message"},
+			Properties: map[string]*Schema{
+				"field":   {Type: typeString},
+				"tag":     {Type: typeString},
+				"param":   {Type: typeString},
+				"message": {Type: typeString},
+			},
+		},
+	}}
+	g.schemaOf(reflect.TypeOf(page.PageResponse{}))
+	if g.problem {
+		g.schemaOf(reflect.TypeOf(ProblemDetails{}))
+	}
+
+	doc := &openAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info:    info,
+		Paths:   make(map[string]map[string]*openAPIOperation),
+		Components: &openAPIComponents{
+			Schemas: g.schemas,
+		},
+	}
+
+	routeDocsMu.RLock()
+	defer routeDocsMu.RUnlock()
+	for _, route := range routes {
+		if excluded[route.Path] || isSoapRoute(route.Path) {
+			continue
+		}
+		d, ok := routeDocs[route.Method+str.Space+route.Path]
+		if !ok {
+			d = &routeDoc{}
+		}
+		if d.bearerAuth {
+			doc.Components.SecuritySchemes = map[string]map[string]interface{}{
+				securitySchemeBearer: {"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			}
+		}
+
+		path := pathParamRegex.ReplaceAllString(route.Path, "{$1}")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*openAPIOperation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = g.operation(route, d)
+	}
+	return doc
+}
+
+type schemaGenerator struct {
+	schemas map[string]*Schema
+
+	// names is component name of each named type, so types of the same name in different packages don't collide
+	names map[reflect.Type]string
+
+	// problem is true if response format is problem, error is problem details and success is raw data
+	problem bool
+}
+
+func (g *schemaGenerator) operation(route gin.RouteInfo, d *routeDoc) *openAPIOperation {
+	op := &openAPIOperation{
+		Summary:     d.summary,
+		Description: d.description,
+		Tags:        d.tags,
+		Deprecated:  d.deprecated,
+		Responses:   make(map[string]*openAPIResponse),
+	}
+
+	// Path params are strings unless request type documents them
+	params := make(map[string]*openAPIParameter)
+	for _, match := range pathParamRegex.FindAllStringSubmatch(route.Path, -1) {
+		params[match[1]] = &openAPIParameter{Name: match[1], In: "path", Required: true, Schema: &Schema{Type: typeString}}
+	}
+
+	if d.request != nil {
+		query, body := g.requestSchemas(d.request, params)
+		for _, p := range query {
+			params[p.In+p.Name] = p
+		}
+		if body != nil && route.Method != http.MethodGet && route.Method != http.MethodDelete {
+			op.RequestBody = &openAPIRequestBody{
+				Required: true,
+				Content:  map[string]*openAPIMediaType{contentTypeApplicationJson: {Schema: body}},
+			}
+		}
+	}
+	if d.page {
+		params["query"+page.PageNumQueryKey] = &openAPIParameter{Name: page.PageNumQueryKey, In: "query", Schema: &Schema{Type: typeInteger, Example: page.DefaultPageNum}}
+		params["query"+page.PageSizeQueryKey] = &openAPIParameter{Name: page.PageSizeQueryKey, In: "query", Schema: &Schema{Type: typeInteger, Example: page.DefaultPageSize}}
+	}
+	keys := make([]string, integer.Zero, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		op.Parameters = append(op.Parameters, params[k])
+	}
+
+	// Success response
+	op.Responses[strconv.Itoa(response.Success.HttpStatusCode())] = g.successResponse(d)
+
+	// Error responses
+	errs := make([]response.IResponse, integer.Zero)
+	if d.request != nil {
+		errs = append(errs, response.InvalidBodyRequest)
+	}
+	if d.bearerAuth {
+		errs = append(errs, response.UnauthorizedAccess, response.OperationNotPermitted)
+		op.Security = []map[string][]string{{securitySchemeBearer: {}}}
+	}
+	errs = append(errs, d.errors...)
+	errs = append(errs, response.GeneralError)
+	for _, e := range errs {
+		status := strconv.Itoa(e.HttpStatusCode())
+		if r, ok := op.Responses[status]; ok {
+			if !strings.Contains(r.Description, e.Description()) {
+				r.Description += ", " + e.Description()
+			}
+			continue
+		}
+		op.Responses[status] = &openAPIResponse{Description: e.Description()}
+		if g.problem {
+			op.Responses[status].Content = map[string]*openAPIMediaType{contentTypeProblemJson: {Schema: &Schema{Ref: schemaRefPrefix + schemaProblemDetails}}}
+		} else {
+			op.Responses[status].Content = map[string]*openAPIMediaType{contentTypeApplicationJson: {Schema: g.envelope(nil, false, e == response.InvalidBodyRequest)}}
+		}
+	}
+	return op
+}
+
+// successResponse returns BaseResponse of route response, or raw data with pagination headers if response format is problem
+func (g *schemaGenerator) successResponse(d *routeDoc) *openAPIResponse {
+	r := &openAPIResponse{Description: response.Success.Description()}
+	if !g.problem {
+		r.Content = map[string]*openAPIMediaType{contentTypeApplicationJson: {Schema: g.envelope(d.response, d.page, false)}}
+		return r
+	}
+
+	if d.response != nil {
+		r.Content = map[string]*openAPIMediaType{contentTypeApplicationJson: {Schema: g.schemaOf(d.response)}}
+	}
+	if d.page {
+		r.Headers = map[string]*openAPIHeader{
+			headers.Link:            {Description: "Links of first, prev, next and last page", Schema: &Schema{Type: typeString}},
+			page.TotalItemHeaderKey: {Description: "Total item", Schema: &Schema{Type: typeInteger}},
+			page.TotalPageHeaderKey: {Description: "Total page", Schema: &Schema{Type: typeInteger}},
+		}
+	}
+	return r
+}
+
+// envelope returns BaseResponse schema with data of type t
+func (g *schemaGenerator) envelope(t reflect.Type, withPage bool, withErrors bool) *Schema {
+	s := &Schema{
+		Type:       typeObject,
+		Required:   []string{"response"},
+		Properties: map[string]*Schema{"response": {Ref: schemaRefPrefix + schemaResponse}},
+	}
+	if withPage {
+		s.Properties["pagination"] = &Schema{Ref: schemaRefPrefix + schemaPageResponse}
+	}
+	if withErrors {
+		s.Properties["errors"] = &Schema{Type: typeArray, Items: &Schema{Ref: schemaRefPrefix + schemaFieldError}}
+	}
+	if t != nil {
+		s.Properties["data"] = g.schemaOf(t)
+	}
+	return s
+}
+
+// requestSchemas splits request type into query params and JSON body schema, path params of the type replace the given ones
+func (g *schemaGenerator) requestSchemas(t reflect.Type, pathParams map[string]*openAPIParameter) ([]*openAPIParameter, *Schema) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, g.schemaOf(t)
+	}
+
+	var query []*openAPIParameter
+	body := &Schema{Type: typeObject, Properties: make(map[string]*Schema)}
+	for _, field := range structFields(t) {
+		required := isRequired(field)
+		if name := tagName(field, tagUri); name != str.Empty {
+			if p, ok := pathParams[name]; ok {
+				p.Schema = g.schemaOf(field.Type)
+				applyBindingTag(p.Schema, field)
+			}
+			continue
+		}
+		if name := tagName(field, tagForm); name != str.Empty && tagName(field, tagJson) == str.Empty {
+			schema := g.schemaOf(field.Type)
+			applyBindingTag(schema, field)
+			query = append(query, &openAPIParameter{Name: name, In: "query", Required: required, Schema: schema})
+			continue
+		}
+
+		name := fieldName(field)
+		body.Properties[name] = g.schemaOf(field.Type)
+		applyBindingTag(body.Properties[name], field)
+		if required {
+			body.Required = append(body.Required, name)
+		}
+	}
+
+	if len(body.Properties) == integer.Zero {
+		return query, nil
+	}
+	return query, body
+}
+
+// schemaOf returns schema of type t, named struct is registered in components and referenced
+func (g *schemaGenerator) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case typeTime:
+		return &Schema{Type: typeString, Format: "date-time"}
+	case typeUUID:
+		return &Schema{Type: typeString, Format: "uuid"}
+	case typeRaw:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: typeBoolean}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: typeInteger, Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: typeInteger, Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: typeNumber, Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: typeNumber, Format: "double"}
+	case reflect.String:
+		return &Schema{Type: typeString}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: typeString, Format: "byte"}
+		}
+		return &Schema{Type: typeArray, Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: typeObject, AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if schemaName(t, false) == str.Empty {
+			return g.structSchema(t)
+		}
+		name, ok := g.names[t]
+		if !ok {
+			name = g.uniqueSchemaName(t)
+			g.names[t] = name
+
+			// Register before generating fields, so recursive type refers to itself
+			g.schemas[name] = &Schema{}
+			*g.schemas[name] = *g.structSchema(t)
+		}
+		return &Schema{Ref: schemaRefPrefix + name}
+	default:
+		return &Schema{}
+	}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: typeObject, Properties: make(map[string]*Schema)}
+	for _, field := range structFields(t) {
+		name := tagName(field, tagJson)
+		if name == str.Empty {
+			name = field.Name
+		}
+		s.Properties[name] = g.schemaOf(field.Type)
+		applyBindingTag(s.Properties[name], field)
+		if isRequired(field) {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// structFields returns exported fields of struct, fields of embedded struct without json tag are promoted like encoding/json does
+func structFields(t reflect.Type) []reflect.StructField {
+	fields := make([]reflect.StructField, integer.Zero)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Tag.Get(tagJson) == sym.Hyphen {
+			continue
+		}
+		if field.Anonymous && tagName(field, tagJson) == str.Empty {
+			ft := field.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fields = append(fields, structFields(ft)...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func tagName(field reflect.StructField, tag string) string {
+	name, _, _ := strings.Cut(field.Tag.Get(tag), sym.Comma)
+	if name == sym.Hyphen {
+		return str.Empty
+	}
+	return name
+}
+
+func isRequired(field reflect.StructField) bool {
+	for _, rule := range strings.Split(field.Tag.Get("binding"), sym.Comma) {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+// applyBindingTag documents format and enum of binding tag rules
+func applyBindingTag(s *Schema, field reflect.StructField) {
+	if s.Ref != str.Empty {
+		return
+	}
+	for _, rule := range strings.Split(field.Tag.Get("binding"), sym.Comma) {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "email", "uuid", "uri":
+			s.Format = name
+		case "url":
+			s.Format = "uri"
+		case "oneof":
+			s.Enum = strings.Fields(param)
+		}
+	}
+}
+
+// uniqueSchemaName returns schema name of the type which is not taken by other type yet,
+// name is qualified with package if it is taken, ex: order_Response, then numbered, ex: order_Response_2
+func (g *schemaGenerator) uniqueSchemaName(t reflect.Type) string {
+	name := schemaName(t, false)
+	if _, taken := g.schemas[name]; !taken {
+		return name
+	}
+	name = schemaName(t, true)
+	unique := name
+	for i := 2; ; i++ {
+		if _, taken := g.schemas[unique]; !taken {
+			return unique
+		}
+		unique = name + sym.Underscore + strconv.Itoa(i)
+	}
+}
+
+// schemaName returns component name of named type, ex: User, or Page_User for generic Page[pkg.User],
+// qualified name has package of the type, ex: user_User, or of its type arguments, ex: Page_user_User
+func schemaName(t reflect.Type, qualified bool) string {
+	name := t.Name()
+	if name == str.Empty {
+		return str.Empty
+	}
+
+	base, args, generic := strings.Cut(name, sym.OpenSquareBracket)
+	if !generic {
+		if qualified {
+			return schemaNameRe.ReplaceAllString(path.Base(t.PkgPath()), str.Empty) + sym.Underscore + base
+		}
+		return base
+	}
+	parts := []string{base}
+	for _, arg := range strings.Split(strings.TrimSuffix(args, sym.CLoseSquareBracket), sym.Comma) {
+		if qualified {
+			arg = strings.ReplaceAll(path.Base(arg), sym.Dot, sym.Underscore)
+		} else {
+			arg = arg[strings.LastIndex(arg, sym.Dot)+1:]
+		}
+		parts = append(parts, schemaNameRe.ReplaceAllString(arg, str.Empty))
+	}
+	return strings.Join(parts, sym.Underscore)
+}
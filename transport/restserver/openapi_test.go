@@ -0,0 +1,196 @@
+package restserver
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/response"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type docWrapper[T any] struct {
+	Data T `json:"data"`
+}
+
+type docAddress struct {
+	City string `json:"city" binding:"required"`
+}
+
+type docUser struct {
+	Id        string      `json:"id" binding:"uuid"`
+	Name      string      `json:"name"`
+	Status    string      `json:"status" binding:"oneof=active inactive"`
+	Address   *docAddress `json:"address"`
+	Friends   []*docUser  `json:"friends,omitempty"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+type docUpdateUserRequest struct {
+	Id     string `uri:"id" binding:"required,uuid"`
+	Notify bool   `form:"notify"`
+	Name   string `json:"name" binding:"required"`
+}
+
+type OpenAPITestSuite struct {
+	suite.Suite
+	doc map[string]interface{}
+}
+
+func (s *OpenAPITestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := func(c *gin.Context) {}
+	router.GET("/v1/users", handler)
+	router.PUT("/v1/users/:id", handler)
+	router.GET("/v1/health", handler)
+	router.GET("/openapi.json", handler)
+
+	Document(http.MethodGet, "/v1/users",
+		WithDocSummary("List users"),
+		WithDocTags("user"),
+		WithDocPageResponse[docUser]())
+	Document(http.MethodPut, "/v1/users/:id",
+		WithDocBearerAuth(),
+		WithDocRequest[docUpdateUserRequest](),
+		WithDocResponse[docUser](),
+		WithDocErrors(response.DataNotFound))
+
+	b, err := json.Marshal(buildOpenAPI(&openAPIInfo{Title: "test", Version: "1.0.0"}, router.Routes(), map[string]bool{"/openapi.json": true}))
+	s.Require().NoError(err)
+	s.doc = make(map[string]interface{})
+	s.Require().NoError(json.Unmarshal(b, &s.doc))
+}
+
+func (s *OpenAPITestSuite) TearDownTest() {
+	routeDocsMu.Lock()
+	routeDocs = make(map[string]*routeDoc)
+	routeDocsMu.Unlock()
+}
+
+func TestOpenAPITestSuite(t *testing.T) {
+	suite.Run(t, new(OpenAPITestSuite))
+}
+
+// get returns value of the path keys in document
+func (s *OpenAPITestSuite) get(keys ...string) interface{} {
+	var v interface{} = s.doc
+	for _, key := range keys {
+		m, ok := v.(map[string]interface{})
+		s.Require().True(ok, "missing %v", keys)
+		v = m[key]
+	}
+	return v
+}
+
+func (s *OpenAPITestSuite) TestPaths() {
+	paths := s.get("paths").(map[string]interface{})
+	s.Contains(paths, "/v1/users")
+	s.Contains(paths, "/v1/users/{id}")
+	s.Contains(paths, "/v1/health")
+	s.NotContains(paths, "/openapi.json")
+}
+
+func (s *OpenAPITestSuite) TestPageResponse() {
+	schema := s.get("paths", "/v1/users", "get", "responses", "200", "content", "application/json", "schema", "properties")
+	s.Equal("#/components/schemas/PageResponse", s.get("paths", "/v1/users", "get", "responses", "200", "content", "application/json", "schema", "properties", "pagination", "$ref"))
+	s.Equal("array", schema.(map[string]interface{})["data"].(map[string]interface{})["type"])
+	s.Len(s.get("paths", "/v1/users", "get", "parameters"), 2)
+
+	user := s.get("components", "schemas", "docUser", "properties")
+	s.Equal("#/components/schemas/docUser", user.(map[string]interface{})["friends"].(map[string]interface{})["items"].(map[string]interface{})["$ref"])
+	s.Equal("date-time", s.get("components", "schemas", "docUser", "properties", "createdAt", "format"))
+	s.Equal([]interface{}{"active", "inactive"}, s.get("components", "schemas", "docUser", "properties", "status", "enum"))
+	s.Equal([]interface{}{"city"}, s.get("components", "schemas", "docAddress", "required"))
+}
+
+func (s *OpenAPITestSuite) TestRequest() {
+	op := []string{"paths", "/v1/users/{id}", "put"}
+	params := s.get(append(op, "parameters")...).([]interface{})
+	s.Require().Len(params, 2)
+	s.Equal("id", params[0].(map[string]interface{})["name"])
+	s.Equal("uuid", params[0].(map[string]interface{})["schema"].(map[string]interface{})["format"])
+	s.Equal("notify", params[1].(map[string]interface{})["name"])
+	s.Equal("query", params[1].(map[string]interface{})["in"])
+
+	body := s.get(append(op, "requestBody", "content", "application/json", "schema")...).(map[string]interface{})
+	s.Equal([]interface{}{"name"}, body["required"])
+	s.Len(body["properties"], 1)
+
+	responses := s.get(append(op, "responses")...).(map[string]interface{})
+	for _, status := range []string{"200", "400", "401", "403", "500"} {
+		s.Contains(responses, status)
+	}
+
+	// Data not found is http 200 in this repo, so it is documented in success response
+	s.Equal("success, data not found", s.get(append(op, "responses", "200", "description")...))
+	s.Contains(s.get(append(op, "responses", "400", "content", "application/json", "schema", "properties")...), "errors")
+	s.NotNil(s.get("components", "securitySchemes", "bearerAuth"))
+}
+
+type testOpenAPIUi struct{}
+
+func (u *testOpenAPIUi) Page(title string, docPath string, assetPath string) []byte {
+	return []byte(title + " " + docPath + " " + assetPath + "ui.js")
+}
+
+func (u *testOpenAPIUi) Assets() map[string]*OpenAPIUiAsset {
+	return map[string]*OpenAPIUiAsset{"ui.js": {ContentType: "text/javascript", Content: []byte("ui")}}
+}
+
+func (s *OpenAPITestSuite) TestSchemaNameCollision() {
+	g := &schemaGenerator{names: make(map[reflect.Type]string), schemas: map[string]*Schema{schemaResponse: {Type: typeObject}}}
+
+	// Response is taken by BaseResponse schema, so restserver.Response is qualified with its package
+	s.Equal(schemaRefPrefix+"restserver_Response", g.schemaOf(reflect.TypeOf(Response{})).Ref)
+	s.Equal(schemaRefPrefix+"restserver_Response", g.schemaOf(reflect.TypeOf(Response{})).Ref)
+	s.Equal(typeObject, g.schemas[schemaResponse].Type)
+
+	// Type arguments of different packages with the same name
+	s.Equal(schemaRefPrefix+"docWrapper_Response", g.schemaOf(reflect.TypeOf(docWrapper[response.Response]{})).Ref)
+	s.Equal(schemaRefPrefix+"docWrapper_restserver_Response", g.schemaOf(reflect.TypeOf(docWrapper[Response]{})).Ref)
+	s.Equal(typeInteger, g.schemas["docWrapper_Response"].Properties["data"].Type)
+	s.Equal(schemaRefPrefix+"restserver_Response", g.schemas["docWrapper_restserver_Response"].Properties["data"].Ref)
+}
+
+func (s *OpenAPITestSuite) TestUi() {
+	router := Router
+	defer func() {
+		Router = router
+		openAPIUi = nil
+	}()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		Router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		s.Equal(http.StatusOK, rec.Code, path)
+		return rec
+	}
+
+	// Only the document is served if no UI is set
+	Router = gin.New()
+	Router.Use(interceptResponse(1024))
+	registerOpenAPI(context.Background(), &config.OpenAPIConfig{Enabled: true, Title: "test"}, "app")
+	get("/openapi.json")
+	for _, route := range Router.Routes() {
+		s.NotEqual("/docs", route.Path)
+	}
+
+	// Assets are served under the UI path
+	SetOpenAPIUi(&testOpenAPIUi{})
+	Router = gin.New()
+	Router.Use(interceptResponse(1024))
+	registerOpenAPI(context.Background(), &config.OpenAPIConfig{Enabled: true, Title: "test"}, "app")
+	s.Equal("test /openapi.json /docs/ui.js", get("/docs").Body.String())
+	rec := get("/docs/ui.js")
+	s.Equal("text/javascript", rec.Header().Get(headers.ContentType))
+	s.Equal("ui", rec.Body.String())
+
+	s.NotContains(get("/openapi.json").Body.String(), "/docs/ui.js")
+}
@@ -0,0 +1,156 @@
+package restserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/constant/sym"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/page"
+	"github.com/rosaekapratama/go-starter/response"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	ResponseFormatBase    = "base"
+	ResponseFormatProblem = "problem"
+
+	contentTypeProblemJson = "application/problem+json"
+	problemTypeAboutBlank  = "about:blank"
+	linkRelFirst           = "first"
+	linkRelPrev            = "prev"
+	linkRelNext            = "next"
+	linkRelLast            = "last"
+)
+
+var (
+	// responseFormat is format of every intercepted JSON response, it is set from config on Init
+	responseFormat = ResponseFormatBase
+
+	// problemTypeBaseUrl is prefix of problem type, empty means about:blank
+	problemTypeBaseUrl string
+)
+
+// ProblemDetails is RFC 7807 error response, used if response format is problem
+type ProblemDetails struct {
+	Type     string        `json:"type"`
+	Title    string        `json:"title"`
+	Status   int           `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Instance string        `json:"instance,omitempty"`
+	Code     string        `json:"code"`
+	TraceId  string        `json:"traceId,omitempty"`
+	Errors   []*FieldError `json:"errors,omitempty"`
+}
+
+// writeProblemFormat writes error response as problem details, or success response as raw data
+// with pagination in Link, Total-Item and Total-Page headers
+func (w *WriterInterceptor) writeProblemFormat(b []byte) (int, error) {
+	if w.response == nil {
+		w.response = response.UnknownResponse
+	}
+	status := w.response.HttpStatusCode()
+
+	var rb []byte
+	if w.response.IsError() || status >= http.StatusBadRequest {
+		var err error
+		rb, err = json.Marshal(w.problemDetails(status))
+		if err != nil {
+			log.Error(w.ctx, err, "Failed to marshal problem details")
+		}
+		w.Header().Set(headers.ContentType, contentTypeProblemJson)
+	} else {
+		// Get slice of data with optional leading whitespace removed.
+		rb = bytes.TrimLeft(b, " \t\r\n")
+		w.setPageHeaders()
+		if len(rb) == integer.Zero {
+			w.Header().Del(headers.ContentType)
+		}
+	}
+	w.Header().Set(headers.ContentLength, strconv.Itoa(len(rb)))
+
+	w.IsWritten = true
+	w.size = len(b)
+	w.status = status
+	w.setLogBody(rb)
+	if w.capture {
+		w.captured = append(w.captured, rb...)
+	}
+	w.ResponseWriter.WriteHeader(status)
+	_, err := w.ResponseWriter.Write(rb)
+	return len(b), err
+}
+
+func (w *WriterInterceptor) problemDetails(status int) *ProblemDetails {
+	p := &ProblemDetails{
+		Type:   problemTypeAboutBlank,
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   w.response.Code(),
+	}
+	if problemTypeBaseUrl != str.Empty {
+		p.Type = strings.TrimSuffix(problemTypeBaseUrl, sym.ForwardSlash) + sym.ForwardSlash + p.Code
+	}
+	if len(w.a) > integer.Zero {
+		p.Detail = fmt.Sprintf(w.response.Description(), w.a...)
+	} else {
+		p.Detail = w.response.Description()
+	}
+
+	// Query is left out of instance, it may have access token
+	if w.request != nil {
+		p.Instance = w.request.URL.Path
+	}
+	if spanContext := trace.SpanContextFromContext(w.ctx); spanContext.HasTraceID() {
+		p.TraceId = spanContext.TraceID().String()
+	}
+	if validationErr, ok := w.response.(*ValidationError); ok {
+		p.Errors = validationErr.Fields
+	}
+	return p
+}
+
+// setPageHeaders sets pagination as RFC 8288 Link header with first, prev, next and last relation
+func (w *WriterInterceptor) setPageHeaders() {
+	if w.page == nil {
+		return
+	}
+
+	h := w.Header()
+	h.Set(page.TotalItemHeaderKey, strconv.Itoa(w.page.TotalItem))
+	h.Set(page.TotalPageHeaderKey, strconv.Itoa(w.page.TotalPage))
+	if w.request == nil || w.page.TotalPage == integer.Zero {
+		return
+	}
+
+	links := []string{pageLink(w.request, page.DefaultPageNum, linkRelFirst)}
+	if w.page.PrevPage > integer.Zero {
+		links = append(links, pageLink(w.request, w.page.PrevPage, linkRelPrev))
+	}
+	if w.page.NextPage > integer.Zero {
+		links = append(links, pageLink(w.request, w.page.NextPage, linkRelNext))
+	}
+	links = append(links, pageLink(w.request, w.page.TotalPage, linkRelLast))
+	h.Set(headers.Link, strings.Join(links, ", "))
+}
+
+// pageLink returns link of the request URI with pageNum query replaced,
+// page size sent in header is moved to query so the link keeps it
+func pageLink(r *http.Request, pageNum int, rel string) string {
+	u := *r.URL
+	q := u.Query()
+	q.Set(page.PageNumQueryKey, strconv.Itoa(pageNum))
+	if !q.Has(page.PageSizeQueryKey) {
+		if pageSize := r.Header.Get(page.PageSizeHeaderKey); pageSize != str.Empty {
+			q.Set(page.PageSizeQueryKey, pageSize)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return fmt.Sprintf("<%s>; rel=\"%s\"", u.RequestURI(), rel)
+}
@@ -0,0 +1,120 @@
+package restserver
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/page"
+	"github.com/rosaekapratama/go-starter/response"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type problemCreateRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type ProblemTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+}
+
+func (s *ProblemTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	responseFormat = ResponseFormatProblem
+	problemTypeBaseUrl = "https://example.com/problems/"
+
+	s.router = gin.New()
+	s.router.Use(interceptResponse(16))
+	s.router.NoRoute(func(c *gin.Context) {
+		SetResponse(c.Writer, response.APINotRegistered, c.Request.URL.Path, c.Request.Method)
+	})
+	s.router.GET("/v1/users", func(c *gin.Context) {
+		SetResponse(c.Writer, response.Success)
+		SetPagination(c.Writer, page.NewPageResponse(page.NewPageRequest(2, 10), 35))
+		c.JSON(http.StatusOK, []string{"user-11", "user-12"})
+	})
+	s.router.POST("/v1/users", func(c *gin.Context) {
+		if _, err := Bind[problemCreateRequest](c); err != nil {
+			SetResponse(c.Writer, err)
+			return
+		}
+		SetResponse(c.Writer, response.Success)
+	})
+}
+
+func (s *ProblemTestSuite) TearDownTest() {
+	responseFormat = ResponseFormatBase
+	problemTypeBaseUrl = ""
+}
+
+func TestProblemTestSuite(t *testing.T) {
+	suite.Run(t, new(ProblemTestSuite))
+}
+
+func (s *ProblemTestSuite) do(method, target string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(headers.ContentType, contentTypeApplicationJson)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *ProblemTestSuite) TestSuccessIsRawData() {
+	rec := s.do(http.MethodGet, "/v1/users?pageNum=2&pageSize=10&q=a", "")
+	s.Equal(http.StatusOK, rec.Code)
+	s.JSONEq(`["user-11","user-12"]`, rec.Body.String())
+	s.Equal("35", rec.Header().Get(page.TotalItemHeaderKey))
+	s.Equal("4", rec.Header().Get(page.TotalPageHeaderKey))
+	s.Equal(`</v1/users?pageNum=1&pageSize=10&q=a>; rel="first", `+
+		`</v1/users?pageNum=1&pageSize=10&q=a>; rel="prev", `+
+		`</v1/users?pageNum=3&pageSize=10&q=a>; rel="next", `+
+		`</v1/users?pageNum=4&pageSize=10&q=a>; rel="last"`, rec.Header().Get(headers.Link))
+
+	// Success without data has empty body
+	rec = s.do(http.MethodPost, "/v1/users", `{"name":"john"}`)
+	s.Equal(http.StatusOK, rec.Code)
+	s.Empty(rec.Body.String())
+}
+
+func (s *ProblemTestSuite) TestErrorIsProblemDetails() {
+	rec := s.do(http.MethodGet, "/v1/orders?access_token=secret", "")
+	s.Equal(response.APINotRegistered.HttpStatusCode(), rec.Code)
+	s.Equal(contentTypeProblemJson, rec.Header().Get(headers.ContentType))
+
+	p := &ProblemDetails{}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), p))
+	s.Equal("https://example.com/problems/"+response.APINotRegistered.Code(), p.Type)
+	s.Equal(http.StatusText(rec.Code), p.Title)
+	s.Equal(rec.Code, p.Status)
+	s.Contains(p.Detail, "/v1/orders")
+	s.Equal("/v1/orders", p.Instance)
+	s.Equal(response.APINotRegistered.Code(), p.Code)
+}
+
+func (s *ProblemTestSuite) TestValidationErrorIsProblemDetails() {
+	rec := s.do(http.MethodPost, "/v1/users", `{}`)
+	s.Equal(response.InvalidBodyRequest.HttpStatusCode(), rec.Code)
+
+	p := &ProblemDetails{}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), p))
+	s.Require().Len(p.Errors, 1)
+	s.Equal("name", p.Errors[0].Field)
+	s.Equal("required", p.Errors[0].Tag)
+}
+
+func (s *ProblemTestSuite) TestOpenAPI() {
+	Document(http.MethodGet, "/v1/users", WithDocPageResponse[string]())
+	doc := buildOpenAPI(&openAPIInfo{Title: "test", Version: "1.0.0"}, s.router.Routes(), nil)
+
+	op := doc.Paths["/v1/users"]["get"]
+	s.Equal(typeArray, op.Responses["200"].Content[contentTypeApplicationJson].Schema.Type)
+	s.Contains(op.Responses["200"].Headers, headers.Link)
+	s.Equal(schemaRefPrefix+schemaProblemDetails, op.Responses["500"].Content[contentTypeProblemJson].Schema.Ref)
+	s.Contains(doc.Components.Schemas, schemaProblemDetails)
+}
@@ -0,0 +1,108 @@
+package restserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	commonContext "github.com/rosaekapratama/go-starter/context"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/ratelimit"
+	"github.com/rosaekapratama/go-starter/response"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	rateLimitKeyIp     = "ip:"
+	rateLimitKeyUser   = "user:"
+	rateLimitKeyAPIKey = "apikey:"
+	apiKeyHashLen      = 16
+)
+
+// RateLimitKeyFunc returns the key which requests are counted by, empty key is not limited
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// RateLimitByIP counts requests by client IP, X-Forwarded-For and X-Real-IP are only used if gin trusts the proxy
+func RateLimitByIP() RateLimitKeyFunc {
+	return func(c *gin.Context) string {
+		return rateLimitKeyIp + c.ClientIP()
+	}
+}
+
+// RateLimitByUserId counts requests by user ID set by InjectKeycloakContext, anonymous requests are counted by client IP
+func RateLimitByUserId() RateLimitKeyFunc {
+	return func(c *gin.Context) string {
+		if userId, ok := commonContext.UserIdFromContext(c.Request.Context()); ok && userId != str.Empty {
+			return rateLimitKeyUser + userId
+		}
+		return rateLimitKeyIp + c.ClientIP()
+	}
+}
+
+// RateLimitByAPIKey counts requests by hash of API key of the header, default header is X-API-Key,
+// so the key itself is never logged nor stored in rate limit store. Requests without API key are counted by client IP
+func RateLimitByAPIKey(header ...string) RateLimitKeyFunc {
+	h := headers.XAPIKey
+	if len(header) > 0 && header[0] != str.Empty {
+		h = header[0]
+	}
+	return func(c *gin.Context) string {
+		if apiKey := c.GetHeader(h); apiKey != str.Empty {
+			sum := sha256.Sum256([]byte(apiKey))
+			return rateLimitKeyAPIKey + hex.EncodeToString(sum[:apiKeyHashLen])
+		}
+		return rateLimitKeyIp + c.ClientIP()
+	}
+}
+
+// RateLimit rejects request with response.TooManyRequests once the key exceeds limiter limit,
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers are set on every response,
+// and Retry-After on rejected ones. Request is allowed if limiter fails, so rate limit store outage doesn't take down the API.
+// Example to use
+//
+//	limiter := ratelimit.New("api", ratelimit.SlidingWindow, 100, time.Minute)
+//	router.Use(restserver.InjectKeycloakContext(), restserver.RateLimit(limiter, restserver.RateLimitByUserId()))
+func RateLimit(limiter ratelimit.Limiter, keyFunc RateLimitKeyFunc) gin.HandlerFunc {
+	if keyFunc == nil {
+		keyFunc = RateLimitByIP()
+	}
+
+	return func(c *gin.Context) {
+		// skip if request is health check
+		if isHealthCheckPath(c) {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		key := keyFunc(c)
+		if key == str.Empty {
+			return
+		}
+
+		result, err := limiter.Allow(ctx, key)
+		if err != nil {
+			log.Warnf(ctx, "Rate limiter is unavailable, request is allowed, key=%s, error=%v", key, err)
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set(headers.RateLimitLimit, strconv.Itoa(result.Limit))
+		h.Set(headers.RateLimitRemaining, strconv.Itoa(result.Remaining))
+		h.Set(headers.RateLimitReset, seconds(result.ResetAfter))
+		if !result.Allowed {
+			log.Debugf(ctx, "Rate limit is exceeded, key=%s, path=%s, method=%s", key, c.Request.URL.Path, c.Request.Method)
+			h.Set(headers.RetryAfter, seconds(result.RetryAfter))
+			SetResponse(c.Writer, response.TooManyRequests)
+			c.Abort()
+		}
+	}
+}
+
+// seconds formats duration as whole seconds, rounded up
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,40 @@
+package restserver
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type RateLimitTestSuite struct {
+	suite.Suite
+}
+
+func TestRateLimitTestSuite(t *testing.T) {
+	suite.Run(t, new(RateLimitTestSuite))
+}
+
+func (s *RateLimitTestSuite) TestRateLimitByAPIKey() {
+	gin.SetMode(gin.TestMode)
+	keyOf := func(header, apiKey string) string {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		if apiKey != "" {
+			c.Request.Header.Set(header, apiKey)
+		}
+		return RateLimitByAPIKey(header)(c)
+	}
+
+	// API key is hashed, so it is never logged nor stored
+	key := keyOf("X-API-Key", "secret-key")
+	s.True(strings.HasPrefix(key, rateLimitKeyAPIKey))
+	s.NotContains(key, "secret-key")
+	s.Len(strings.TrimPrefix(key, rateLimitKeyAPIKey), apiKeyHashLen*2)
+	s.Equal(key, keyOf("X-Client-Key", "secret-key"))
+	s.NotEqual(key, keyOf("X-API-Key", "other-key"))
+
+	s.True(strings.HasPrefix(keyOf("X-API-Key", ""), rateLimitKeyIp))
+}
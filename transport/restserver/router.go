@@ -3,7 +3,7 @@ package restserver
 import (
 	"context"
 	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"io"
@@ -11,6 +11,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -38,7 +39,6 @@ import (
 
 const (
 	contentTypeApplicationJson = "application/json"
-	realmsPath                 = "realms/"
 )
 
 var (
@@ -46,6 +46,14 @@ var (
 	propagator    = otel.GetTextMapPropagator()
 	Router        *gin.Engine
 	logRepository repositories.ITransportLogRepository
+
+	// servers are started by Run and guarded by serverMu, Run starts no server once Shutdown sets serverClosed
+	servers      []*http.Server
+	serverClosed bool
+	serverMu     sync.Mutex
+
+	// serverCtx is cancelled by Shutdown to stop background jobs of REST server
+	serverCtx, serverCancel = context.WithCancel(context.Background())
 )
 
 func logging(payloadLogSizeLimit int) func(c *gin.Context) {
@@ -60,6 +68,15 @@ func logging(payloadLogSizeLimit int) func(c *gin.Context) {
 		isStdoutLogEnabled := cfg.Transport.Server.Rest.Logging.Stdout
 		databaseLog := cfg.Transport.Server.Rest.Logging.Database
 
+		// Request to mounted SOAP service is logged as SOAP
+		logType := constant.LogTypeRest
+		isSoap := isSoapRequest(c)
+		var soapAction string
+		if isSoap {
+			logType = constant.LogTypeSoap
+			soapAction = soapActionOf(c.Request)
+		}
+
 		var clonedReq *http.Request
 		var clearFunc func()
 		var body string
@@ -90,8 +107,11 @@ func logging(payloadLogSizeLimit int) func(c *gin.Context) {
 		if isStdoutLogEnabled {
 			log.Trace(ctx, "Start stdout http request log writing")
 			httpFields := make(map[string]interface{})
-			httpFields[constant.LogTypeFieldLogKey] = constant.LogTypeRest
-			httpFields[constant.UrlLogKey] = clonedReq.URL
+			httpFields[constant.LogTypeFieldLogKey] = logType
+			if isSoap {
+				httpFields[constant.SoapActionLogKey] = soapAction
+			}
+			httpFields[constant.UrlLogKey] = redactUrl(clonedReq.URL)
 			httpFields[constant.MethodLogKey] = clonedReq.Method
 			httpFields[constant.IsServerLogKey] = true
 			httpFields[constant.IsRequestLogKey] = true
@@ -105,10 +125,10 @@ func logging(payloadLogSizeLimit int) func(c *gin.Context) {
 		if databaseLog != str.Empty {
 			go func(ctx context.Context) {
 				log.Trace(ctx, "Start database http request log writing")
-				restLog, err := json.Marshal(&models.TransportRestLog{
+				restLog, err := marshalTransportLog(isSoap, soapAction, &models.TransportRestLog{
 					IsServer:   true,
 					IsRequest:  true,
-					URL:        fmt.Sprintf("%v", clonedReq.URL),
+					URL:        redactUrl(clonedReq.URL),
 					Method:     clonedReq.Method,
 					Headers:    utils.StringP(fmt.Sprintf("%v", clonedReq.Header)),
 					Body:       utils.StringP(body),
@@ -123,7 +143,7 @@ func logging(payloadLogSizeLimit int) func(c *gin.Context) {
 					ID:        uuid.New(),
 					TraceID:   commonContext.TraceIdFromContext(ctx),
 					SpanID:    commonContext.SpanIdFromContext(ctx),
-					Type:      constant.LogTypeRest,
+					Type:      logType,
 					Log:       datatypes.JSON(restLog),
 					ProcessDT: time.Now().In(location.AsiaJakarta),
 					ProcessBy: config.Instance.GetObject().App.Name,
@@ -140,8 +160,11 @@ func logging(payloadLogSizeLimit int) func(c *gin.Context) {
 		if isStdoutLogEnabled {
 			log.Trace(ctx, "Start stdout http response log writing")
 			httpFields := make(map[string]interface{})
-			httpFields[constant.LogTypeFieldLogKey] = constant.LogTypeRest
-			httpFields[constant.UrlLogKey] = clonedReq.URL
+			httpFields[constant.LogTypeFieldLogKey] = logType
+			if isSoap {
+				httpFields[constant.SoapActionLogKey] = soapAction
+			}
+			httpFields[constant.UrlLogKey] = redactUrl(clonedReq.URL)
 			httpFields[constant.MethodLogKey] = clonedReq.Method
 			httpFields[constant.IsServerLogKey] = true
 			httpFields[constant.IsRequestLogKey] = false
@@ -159,10 +182,10 @@ func logging(payloadLogSizeLimit int) func(c *gin.Context) {
 
 			go func(ctx context.Context) {
 				log.Trace(ctx, "Start database http response log writing")
-				restLog, marshalErr := json.Marshal(&models.TransportRestLog{
+				restLog, marshalErr := marshalTransportLog(isSoap, soapAction, &models.TransportRestLog{
 					IsServer:   true,
 					IsRequest:  false,
-					URL:        fmt.Sprintf("%v", clonedReq.URL),
+					URL:        redactUrl(clonedReq.URL),
 					Method:     clonedReq.Method,
 					Headers:    utils.StringP(fmt.Sprintf("%v", i.ResponseWriter.Header())),
 					Body:       utils.StringP(body),
@@ -177,7 +200,7 @@ func logging(payloadLogSizeLimit int) func(c *gin.Context) {
 					ID:           uuid.New(),
 					TraceID:      commonContext.TraceIdFromContext(ctx),
 					SpanID:       commonContext.SpanIdFromContext(ctx),
-					Type:         constant.LogTypeRest,
+					Type:         logType,
 					Log:          datatypes.JSON(restLog),
 					ErrorMessage: utils.StringP(i.response.Description()),
 					ProcessDT:    time.Now().In(location.AsiaJakarta),
@@ -257,19 +280,10 @@ func interceptResponse(payloadLogSizeLimit int) func(c *gin.Context) {
 	return func(c *gin.Context) {
 		r := c.Request
 		i := NewWriterInterceptor(r.Context(), c.Writer, payloadLogSizeLimit)
+		i.request = r
 		c.Writer = i
 		c.Next()
-		contentType := strings.ToLower(strings.Split(i.ResponseWriter.Header().Get(headers.ContentType), sym.SemiColon)[0])
-		if contentType == str.Empty {
-			i.ResponseWriter.Header().Set(headers.ContentType, contentTypeApplicationJson)
-			contentType = contentTypeApplicationJson
-		}
-		if !i.IsWritten && contentType == contentTypeApplicationJson {
-			_, err := i.Write(nil)
-			if err != nil {
-				log.Error(r.Context(), err)
-			}
-		}
+		i.writeIfNotWritten()
 	}
 }
 
@@ -298,6 +312,15 @@ func injectAuthContext(c *gin.Context) {
 	// Start middleware logic
 	ctx := c.Request.Context()
 	auth := c.GetHeader(headers.Authorization)
+
+	// Browser EventSource and WebSocket can't set header, so accept token from query param for them
+	// Query param is removed from request URL, so it is not seen by handler or logged afterward
+	if isStreamRoute(c) {
+		if token := accessTokenFromQuery(c); token != str.Empty && auth == str.Empty {
+			auth = headers.BearerTokenPrefix + token
+		}
+	}
+
 	if auth != str.Empty && strings.HasPrefix(auth, headers.BasicAuthPrefix) {
 		auth = strings.TrimPrefix(auth, headers.BasicAuthPrefix)
 
@@ -339,12 +362,27 @@ func Init(ctx context.Context, config config.Config, newLogRepository repositori
 	}
 	payloadLogSizeLimit := int(_payloadLogSizeLimit)
 
+	// Get response format
+	if responseCfg := cfg.Transport.Server.Rest.Response; responseCfg != nil {
+		switch responseCfg.Format {
+		case str.Empty, ResponseFormatBase:
+		case ResponseFormatProblem:
+			responseFormat = ResponseFormatProblem
+			problemTypeBaseUrl = responseCfg.ProblemTypeBaseUrl
+			log.Info(ctx, "REST server response format is RFC 7807 problem details")
+		default:
+			log.Fatalf(ctx, response.InvalidConfig, "Invalid value of REST server response format config, format=%s", responseCfg.Format)
+			return
+		}
+	}
+
 	// List of mandatory middleware
 	Router.Use(
 		extractTraceParent,
 		otelgin.Middleware(cfg.App.Name),
 		logging(payloadLogSizeLimit),
 		enableCors(),
+		encodeResponse(ctx, cfg.Transport.Server.Rest.Compression, cfg.Transport.Server.Rest.ETag),
 		interceptResponse(payloadLogSizeLimit),
 		gin.Recovery(),
 		injectTraceParent,
@@ -359,6 +397,9 @@ func Init(ctx context.Context, config config.Config, newLogRepository repositori
 
 	// Set health check endpoint
 	Router.GET("/v1/health", gin.WrapH(healthcheck.HandlerV1()))
+
+	// Serve OpenAPI document of registered routes
+	registerOpenAPI(ctx, cfg.Transport.Server.Rest.OpenAPI, cfg.App.Name)
 }
 
 func Run() {
@@ -370,10 +411,57 @@ func Run() {
 		return
 	}
 
-	port := cfg.Transport.Server.Rest.Port.Http
+	// Serve HTTPS if TLS is enabled
+	restCfg := cfg.Transport.Server.Rest
+	if restCfg.TLS != nil && restCfg.TLS.Enabled {
+		runTLS(ctx, restCfg)
+		return
+	}
+
+	port := restCfg.Port.Http
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", "0.0.0.0", port),
+		Handler: Router.Handler(),
+	}
+	if !addServers(httpServer) {
+		log.Warn(ctx, "REST server is already shut down")
+		return
+	}
 	log.Infof(ctx, "Starting REST server on port %d", port)
-	err := Router.Run(fmt.Sprintf("%s:%d", "0.0.0.0", port))
-	if err != nil {
+	err := httpServer.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf(ctx, err, "Failed to run REST server, port=%d", port)
 	}
 }
+
+// addServers keeps the servers to be shut down by Shutdown, it returns false if Shutdown is already called
+func addServers(newServers ...*http.Server) bool {
+	serverMu.Lock()
+	defer serverMu.Unlock()
+	if serverClosed {
+		return false
+	}
+	servers = append(servers, newServers...)
+	return true
+}
+
+// Shutdown closes open SSE streams and WebSocket connections,
+// then gracefully shuts down REST server until the context is done,
+// REST server is not started if Run is called afterwards
+func Shutdown(ctx context.Context) error {
+	closeStreams()
+
+	serverMu.Lock()
+	serverClosed = true
+	runningServers := servers
+	serverMu.Unlock()
+	serverCancel()
+
+	var errs []error
+	for _, server := range runningServers {
+		if err := server.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,80 @@
+package restserver
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type RouterTestSuite struct {
+	suite.Suite
+	cfg *config.Object
+}
+
+func (s *RouterTestSuite) SetupTest() {
+	s.cfg = cfg
+	cfg = &config.Object{
+		Transport: &config.TransportConfig{
+			Server: &config.ServerConfig{
+				Rest: &config.RestServerConfig{Port: &config.HttpHttpsPortConfig{}},
+			},
+		},
+	}
+	Router = gin.New()
+}
+
+func (s *RouterTestSuite) TearDownTest() {
+	cfg = s.cfg
+	serverMu.Lock()
+	defer serverMu.Unlock()
+	servers = nil
+	serverClosed = false
+	serverCtx, serverCancel = context.WithCancel(context.Background())
+}
+
+func TestRouterTestSuite(t *testing.T) {
+	suite.Run(t, new(RouterTestSuite))
+}
+
+// run runs Run in background and returns channel which is closed once Run returns
+func (s *RouterTestSuite) run() chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Run()
+	}()
+	return done
+}
+
+func (s *RouterTestSuite) TestShutdown() {
+	done := s.run()
+	s.Eventually(func() bool {
+		serverMu.Lock()
+		defer serverMu.Unlock()
+		return len(servers) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	s.NoError(Shutdown(context.Background()))
+	s.Error(serverCtx.Err())
+	s.Eventually(func() bool {
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *RouterTestSuite) TestShutdownBeforeRun() {
+	s.NoError(Shutdown(context.Background()))
+
+	// Run must not start server which is never shut down
+	<-s.run()
+	serverMu.Lock()
+	defer serverMu.Unlock()
+	s.Empty(servers)
+}
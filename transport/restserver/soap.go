@@ -0,0 +1,383 @@
+package restserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/beevik/etree"
+	"github.com/gin-gonic/gin"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/constant/headers/contenttype"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/constant/sym"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/log/transport/models"
+	"github.com/rosaekapratama/go-starter/response"
+	"golang.org/x/net/html/charset"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	soapNamespace11 = "http://schemas.xmlsoap.org/soap/envelope/"
+	soapNamespace12 = "http://www.w3.org/2003/05/soap-envelope"
+	soapPrefix      = "soap"
+	charsetUtf8     = "; charset=utf-8"
+
+	soapFaultClient   = "soap:Client"
+	soapFaultServer   = "soap:Server"
+	soapFaultSender   = "soap:Sender"
+	soapFaultReceiver = "soap:Receiver"
+)
+
+var (
+	// soapRoutes are full paths of mounted SOAP services, POST request to them is logged as SOAP
+	soapRoutes   = make(map[string]bool)
+	soapRoutesMu sync.RWMutex
+)
+
+// SoapService is SOAP endpoint of a WSDL, request is dispatched to handler of its SOAPAction,
+// or to handler of its body element name if the action is empty or unknown
+type SoapService struct {
+	wsdl     []byte
+	actions  map[string]string
+	handlers map[string]soapHandler
+}
+
+// soapHandler decodes body element of the request from the envelope decoder, start is nil if the body is empty
+type soapHandler func(ctx context.Context, decoder *xml.Decoder, start *xml.StartElement) (interface{}, error)
+
+// soapFaultResponse is response of SOAP fault, its HTTP status follows SOAP version instead of the response
+type soapFaultResponse struct {
+	response.IResponse
+	statusCode int
+}
+
+type wsdlDefinitions struct {
+	Bindings []struct {
+		Operations []struct {
+			Name           string `xml:"name,attr"`
+			SoapOperations []struct {
+				SoapAction string `xml:"soapAction,attr"`
+			} `xml:"operation"`
+		} `xml:"operation"`
+	} `xml:"binding"`
+}
+
+var errInvalidSoapEnvelope = errors.New("invalid SOAP envelope")
+
+// NewSoapService returns SOAP service of the WSDL, SOAPAction of every binding operation is read from it.
+// Example to use
+//
+//	service, err := restserver.NewSoapService(wsdl)
+//	restserver.HandleSoap(service, "SayHello", sayHello)
+//	service.Mount(restserver.Router, "/ws/hello")
+func NewSoapService(wsdl []byte) (*SoapService, error) {
+	definitions := &wsdlDefinitions{}
+	decoder := xml.NewDecoder(bytes.NewReader(wsdl))
+	decoder.CharsetReader = charset.NewReaderLabel
+	if err := decoder.Decode(definitions); err != nil {
+		return nil, err
+	}
+
+	s := &SoapService{
+		wsdl:     wsdl,
+		actions:  make(map[string]string),
+		handlers: make(map[string]soapHandler),
+	}
+	for _, binding := range definitions.Bindings {
+		for _, operation := range binding.Operations {
+			for _, soapOperation := range operation.SoapOperations {
+				if soapOperation.SoapAction != str.Empty {
+					s.actions[soapOperation.SoapAction] = operation.Name
+				}
+			}
+		}
+	}
+	return s, nil
+}
+
+// HandleSoap registers handler of the WSDL operation, body element of the request is decoded into Req with namespace
+// prefixes declared on the envelope, and Res is encoded as body element of the response, so Res should have XMLName with its namespace.
+// Error which is response.IResponse is sent as its fault, other error is sent as response.GeneralError fault
+func HandleSoap[Req any, Res any](s *SoapService, operation string, handler func(ctx context.Context, req *Req) (*Res, error)) {
+	s.handlers[operation] = func(ctx context.Context, decoder *xml.Decoder, start *xml.StartElement) (interface{}, error) {
+		req := new(Req)
+		if start != nil {
+			if err := decoder.DecodeElement(req, start); err != nil {
+				log.Warnf(ctx, "Failed to decode SOAP request, operation=%s, error=%v", operation, err)
+				return nil, response.InvalidBodyRequest
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Mount serves the WSDL on GET and dispatches SOAP request on POST of the path
+func (s *SoapService) Mount(router gin.IRoutes, path string) {
+	router.GET(path, s.serveWsdl)
+	router.POST(path, s.handle)
+
+	soapRoutesMu.Lock()
+	defer soapRoutesMu.Unlock()
+	soapRoutes[fullPathOf(router, path)] = true
+}
+
+func (s *SoapService) serveWsdl(c *gin.Context) {
+	SetRawResponse(c.Writer, response.Success)
+	c.Data(http.StatusOK, contenttype.TextXml+charsetUtf8, s.wsdl)
+}
+
+func (s *SoapService) handle(c *gin.Context) {
+	ctx := c.Request.Context()
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		log.Error(ctx, err, "Failed to read SOAP request")
+		writeSoapFault(c, soapNamespace11, response.InvalidBodyRequest)
+		return
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = charset.NewReaderLabel
+	namespace, start, err := soapBodyElement(decoder)
+	if err != nil {
+		log.Warnf(ctx, "Invalid SOAP envelope, path=%s, error=%v", c.Request.URL.Path, err)
+		writeSoapFault(c, soapNamespace11, response.InvalidBodyRequest)
+		return
+	}
+
+	operation := s.operation(c.Request, start)
+	handler, ok := s.handlers[operation]
+	if !ok {
+		log.Warnf(ctx, response.APINotRegistered.Description(), c.Request.URL.Path, operation)
+		writeSoapFault(c, namespace, response.APINotRegistered, c.Request.URL.Path, operation)
+		return
+	}
+
+	res, err := handler(ctx, decoder, start)
+	if err != nil {
+		writeSoapFault(c, namespace, err)
+		return
+	}
+
+	doc, soapBody := newSoapEnvelope(namespace)
+	if err = appendSoapBody(soapBody, res); err != nil {
+		log.Errorf(ctx, err, "Failed to encode SOAP response, operation=%s", operation)
+		writeSoapFault(c, namespace, response.GeneralError)
+		return
+	}
+	writeSoapEnvelope(c, namespace, doc, http.StatusOK, response.Success)
+}
+
+// operation returns operation of SOAPAction header or SOAP 1.2 action parameter,
+// or the body element name if the action is empty or unknown
+func (s *SoapService) operation(r *http.Request, start *xml.StartElement) string {
+	if operation, ok := s.actions[soapActionOf(r)]; ok {
+		return operation
+	}
+	if start == nil {
+		return str.Empty
+	}
+	return start.Name.Local
+}
+
+// soapBodyElement walks the decoder through the envelope and header to the first element inside the body,
+// so namespace prefixes declared on the envelope are kept for decoding it. It returns namespace of the envelope,
+// and nil element if the body is empty
+func soapBodyElement(decoder *xml.Decoder) (namespace string, start *xml.StartElement, err error) {
+	envelope, err := nextStartElement(decoder)
+	if err != nil {
+		return
+	}
+	if envelope == nil || envelope.Name.Local != "Envelope" ||
+		(envelope.Name.Space != soapNamespace11 && envelope.Name.Space != soapNamespace12) {
+		err = errInvalidSoapEnvelope
+		return
+	}
+	namespace = envelope.Name.Space
+
+	for {
+		var el *xml.StartElement
+		if el, err = nextStartElement(decoder); err != nil {
+			return
+		}
+		if el == nil {
+			err = errInvalidSoapEnvelope
+			return
+		}
+		if el.Name.Local == "Body" && el.Name.Space == namespace {
+			break
+		}
+		if err = decoder.Skip(); err != nil {
+			return
+		}
+	}
+	start, err = nextStartElement(decoder)
+	return
+}
+
+// nextStartElement returns the next child element of the current element, or nil if the current element ends first
+func nextStartElement(decoder *xml.Decoder) (*xml.StartElement, error) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			return &t, nil
+		case xml.EndElement:
+			return nil, nil
+		}
+	}
+}
+
+// HttpStatusCode returns HTTP status of the fault
+func (r *soapFaultResponse) HttpStatusCode() int {
+	return r.statusCode
+}
+
+func (r *soapFaultResponse) Error() string {
+	return r.Description()
+}
+
+// writeSoapFault writes fault of the error, code is client or sender if the response is not error or its HTTP status is 4xx.
+// SOAP 1.1 fault is always sent with status 500, SOAP 1.2 sender fault is sent with status 400
+func writeSoapFault(c *gin.Context, namespace string, err error, a ...any) {
+	var res response.IResponse
+	if !errors.As(err, &res) {
+		log.Error(c.Request.Context(), err, "SOAP handler failed")
+		res = response.GeneralError
+	}
+	description := res.Description()
+	if len(a) > integer.Zero {
+		description = fmt.Sprintf(description, a...)
+	}
+
+	isClient := !res.IsError() ||
+		(res.HttpStatusCode() >= http.StatusBadRequest && res.HttpStatusCode() < http.StatusInternalServerError)
+	statusCode := http.StatusInternalServerError
+	doc, body := newSoapEnvelope(namespace)
+	fault := body.CreateElement(soapPrefix + sym.Colon + "Fault")
+	if namespace == soapNamespace12 {
+		code := soapFaultReceiver
+		if isClient {
+			code = soapFaultSender
+			statusCode = http.StatusBadRequest
+		}
+		fault.CreateElement(soapPrefix + sym.Colon + "Code").CreateElement(soapPrefix + sym.Colon + "Value").SetText(code)
+		text := fault.CreateElement(soapPrefix + sym.Colon + "Reason").CreateElement(soapPrefix + sym.Colon + "Text")
+		text.CreateAttr("xml:lang", "en")
+		text.SetText(description)
+		appendSoapFaultDetail(fault.CreateElement(soapPrefix+sym.Colon+"Detail"), res, description)
+	} else {
+		code := soapFaultServer
+		if isClient {
+			code = soapFaultClient
+		}
+		fault.CreateElement("faultcode").SetText(code)
+		fault.CreateElement("faultstring").SetText(description)
+		appendSoapFaultDetail(fault.CreateElement("detail"), res, description)
+	}
+	writeSoapEnvelope(c, namespace, doc, statusCode, &soapFaultResponse{IResponse: res, statusCode: statusCode})
+}
+
+// appendSoapFaultDetail adds response code and description of the fault
+func appendSoapFaultDetail(detail *etree.Element, res response.IResponse, description string) {
+	e := detail.CreateElement("error")
+	e.CreateElement("code").SetText(res.Code())
+	e.CreateElement("description").SetText(description)
+}
+
+func newSoapEnvelope(namespace string) (*etree.Document, *etree.Element) {
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
+	envelope := doc.CreateElement(soapPrefix + sym.Colon + "Envelope")
+	envelope.CreateAttr("xmlns"+sym.Colon+soapPrefix, namespace)
+	return doc, envelope.CreateElement(soapPrefix + sym.Colon + "Body")
+}
+
+// appendSoapBody appends xml.Marshal result of the response to the body
+func appendSoapBody(body *etree.Element, res interface{}) error {
+	b, err := xml.Marshal(res)
+	if err != nil || len(b) == integer.Zero {
+		return err
+	}
+	doc := etree.NewDocument()
+	if err = doc.ReadFromBytes(b); err != nil {
+		return err
+	}
+	for _, el := range doc.ChildElements() {
+		body.AddChild(el)
+	}
+	return nil
+}
+
+func writeSoapEnvelope(c *gin.Context, namespace string, doc *etree.Document, statusCode int, res error) {
+	b, err := doc.WriteToBytes()
+	if err != nil {
+		log.Error(c.Request.Context(), err, "Failed to write SOAP envelope")
+		SetResponse(c.Writer, response.GeneralError)
+		return
+	}
+
+	contentType := contenttype.TextXml + charsetUtf8
+	if namespace == soapNamespace12 {
+		contentType = contenttype.ApplicationSoapXml + charsetUtf8
+	}
+	SetRawResponse(c.Writer, res)
+	c.Data(statusCode, contentType, b)
+}
+
+// soapActionOf returns SOAPAction header, or action parameter of SOAP 1.2 content type
+func soapActionOf(r *http.Request) string {
+	if action := strings.Trim(r.Header.Get(headers.SOAPAction), sym.DoubleQuote); action != str.Empty {
+		return action
+	}
+	if _, params, err := mime.ParseMediaType(r.Header.Get(headers.ContentType)); err == nil {
+		return params["action"]
+	}
+	return str.Empty
+}
+
+// isSoapRequest returns true if the request is POST to mounted SOAP service
+func isSoapRequest(c *gin.Context) bool {
+	if c.Request.Method != http.MethodPost {
+		return false
+	}
+	return isSoapRoute(c.FullPath())
+}
+
+func isSoapRoute(path string) bool {
+	soapRoutesMu.RLock()
+	defer soapRoutesMu.RUnlock()
+	return soapRoutes[path]
+}
+
+// marshalTransportLog marshals REST server log, or its TransportSoapLog if the request is SOAP request
+func marshalTransportLog(isSoap bool, soapAction string, restLog *models.TransportRestLog) ([]byte, error) {
+	if !isSoap {
+		return json.Marshal(restLog)
+	}
+
+	soapLog := &models.TransportSoapLog{
+		IsServer:   restLog.IsServer,
+		IsRequest:  restLog.IsRequest,
+		URL:        restLog.URL,
+		SOAPAction: soapAction,
+		Method:     restLog.Method,
+		Body:       restLog.Body,
+		StatusCode: restLog.StatusCode,
+	}
+	if restLog.Headers != nil {
+		soapLog.Headers = *restLog.Headers
+	}
+	return json.Marshal(soapLog)
+}
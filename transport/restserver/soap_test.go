@@ -0,0 +1,189 @@
+package restserver
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/beevik/etree"
+	"github.com/gin-gonic/gin"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/log/transport/models"
+	"github.com/rosaekapratama/go-starter/response"
+	"github.com/rosaekapratama/go-starter/utils"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const (
+	testSoapAction = "http://example.com/hello/SayHello"
+	testSoapWsdl   = `<?xml version="1.0" encoding="UTF-8"?>
+<definitions xmlns="http://schemas.xmlsoap.org/wsdl/" xmlns:soap="http://schemas.xmlsoap.org/wsdl/soap/"
+	xmlns:tns="http://example.com/hello" targetNamespace="http://example.com/hello">
+	<binding name="HelloBinding" type="tns:Hello">
+		<soap:binding transport="http://schemas.xmlsoap.org/soap/http"/>
+		<operation name="SayHello"><soap:operation soapAction="http://example.com/hello/SayHello"/></operation>
+	</binding>
+</definitions>`
+	testSoapRequest = `<soap:Envelope xmlns:soap="%s"><soap:Body>` +
+		`<SayHello xmlns="http://example.com/hello"><name>%s</name></SayHello>` +
+		`</soap:Body></soap:Envelope>`
+)
+
+type sayHelloRequest struct {
+	XMLName xml.Name `xml:"http://example.com/hello SayHello"`
+	Name    string   `xml:"name"`
+}
+
+type sayHelloResponse struct {
+	XMLName  xml.Name `xml:"http://example.com/hello SayHelloResponse"`
+	Greeting string   `xml:"greeting"`
+}
+
+type SoapTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+}
+
+func (s *SoapTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.router = gin.New()
+	s.router.Use(interceptResponse(1024))
+
+	service, err := NewSoapService([]byte(testSoapWsdl))
+	s.Require().NoError(err)
+	HandleSoap(service, "SayHello", func(ctx context.Context, req *sayHelloRequest) (*sayHelloResponse, error) {
+		switch req.Name {
+		case "unknown":
+			return nil, response.DataNotFound
+		case "broken":
+			return nil, errors.New("connection refused")
+		}
+		return &sayHelloResponse{Greeting: "Hello " + req.Name}, nil
+	})
+	service.Mount(s.router.Group("/ws"), "/hello")
+}
+
+func TestSoapTestSuite(t *testing.T) {
+	suite.Run(t, new(SoapTestSuite))
+}
+
+func (s *SoapTestSuite) post(namespace string, name string, header http.Header) (*httptest.ResponseRecorder, *etree.Document) {
+	req := httptest.NewRequest(http.MethodPost, "/ws/hello", strings.NewReader(fmt.Sprintf(testSoapRequest, namespace, name)))
+	req.Header = header
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	doc := etree.NewDocument()
+	s.Require().NoError(doc.ReadFromBytes(rec.Body.Bytes()), rec.Body.String())
+	s.Equal(namespace, doc.Root().NamespaceURI())
+	return rec, doc
+}
+
+func (s *SoapTestSuite) TestWsdl() {
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ws/hello?wsdl", nil))
+	s.Equal(http.StatusOK, rec.Code)
+	s.Equal("text/xml; charset=utf-8", rec.Header().Get(headers.ContentType))
+	s.Equal(testSoapWsdl, rec.Body.String())
+	s.True(isSoapRoute("/ws/hello"))
+}
+
+func (s *SoapTestSuite) TestDispatch() {
+	// SOAP 1.1 is dispatched by SOAPAction header
+	rec, doc := s.post(soapNamespace11, "john", http.Header{
+		headers.ContentType: {"text/xml; charset=utf-8"},
+		headers.SOAPAction:  {`"` + testSoapAction + `"`},
+	})
+	s.Equal(http.StatusOK, rec.Code)
+	s.Equal("text/xml; charset=utf-8", rec.Header().Get(headers.ContentType))
+	s.Equal("Hello john", doc.FindElement("//Body/SayHelloResponse/greeting").Text())
+
+	// SOAP 1.2 without action is dispatched by body element name
+	rec, doc = s.post(soapNamespace12, "jane", http.Header{headers.ContentType: {"application/soap+xml; charset=utf-8"}})
+	s.Equal(http.StatusOK, rec.Code)
+	s.Equal("application/soap+xml; charset=utf-8", rec.Header().Get(headers.ContentType))
+	s.Equal("http://example.com/hello", doc.FindElement("//Body/SayHelloResponse").NamespaceURI())
+	s.Equal("Hello jane", doc.FindElement("//Body/SayHelloResponse/greeting").Text())
+}
+
+func (s *SoapTestSuite) TestEnvelopePrefix() {
+	// Prefix of body element is declared on the envelope, header is skipped
+	req := httptest.NewRequest(http.MethodPost, "/ws/hello", strings.NewReader(
+		`<soapenv:Envelope xmlns:soapenv="`+soapNamespace11+`" xmlns:hel="http://example.com/hello">`+
+			`<soapenv:Header><hel:Token>abc</hel:Token></soapenv:Header>`+
+			`<soapenv:Body><hel:SayHello><hel:name>john</hel:name></hel:SayHello></soapenv:Body></soapenv:Envelope>`))
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	s.Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	doc := etree.NewDocument()
+	s.Require().NoError(doc.ReadFromBytes(rec.Body.Bytes()))
+	s.Equal("Hello john", doc.FindElement("//Body/SayHelloResponse/greeting").Text())
+
+	// Body element of other namespace is refused
+	req = httptest.NewRequest(http.MethodPost, "/ws/hello", strings.NewReader(
+		`<soapenv:Envelope xmlns:soapenv="`+soapNamespace11+`" xmlns:hel="http://example.com/other">`+
+			`<soapenv:Body><hel:SayHello><hel:name>john</hel:name></hel:SayHello></soapenv:Body></soapenv:Envelope>`))
+	rec = httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	s.Equal(http.StatusInternalServerError, rec.Code)
+	s.Contains(rec.Body.String(), response.InvalidBodyRequest.Code())
+}
+
+func (s *SoapTestSuite) TestFault() {
+	rec, doc := s.post(soapNamespace11, "unknown", http.Header{headers.SOAPAction: {testSoapAction}})
+	s.Equal(http.StatusInternalServerError, rec.Code)
+	s.Equal(soapFaultClient, doc.FindElement("//Fault/faultcode").Text())
+	s.Equal(response.DataNotFound.Description(), doc.FindElement("//Fault/faultstring").Text())
+	s.Equal(response.DataNotFound.Code(), doc.FindElement("//Fault/detail/error/code").Text())
+
+	rec, doc = s.post(soapNamespace12, "unknown", http.Header{headers.ContentType: {`application/soap+xml; action="` + testSoapAction + `"`}})
+	s.Equal(http.StatusBadRequest, rec.Code)
+	s.Equal(soapFaultSender, doc.FindElement("//Fault/Code/Value").Text())
+	s.Equal(response.DataNotFound.Description(), doc.FindElement("//Fault/Reason/Text").Text())
+
+	rec, doc = s.post(soapNamespace12, "broken", http.Header{})
+	s.Equal(http.StatusInternalServerError, rec.Code)
+	s.Equal(soapFaultReceiver, doc.FindElement("//Fault/Code/Value").Text())
+	s.Equal(response.GeneralError.Code(), doc.FindElement("//Fault/Detail/error/code").Text())
+
+	req := httptest.NewRequest(http.MethodPost, "/ws/hello", strings.NewReader(
+		`<soap:Envelope xmlns:soap="`+soapNamespace11+`"><soap:Body><SayBye/></soap:Body></soap:Envelope>`))
+	rec = httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	s.Equal(http.StatusInternalServerError, rec.Code)
+	s.Contains(rec.Body.String(), "method=SayBye")
+
+	req = httptest.NewRequest(http.MethodPost, "/ws/hello", strings.NewReader(`{"name":"john"}`))
+	rec = httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	s.Equal(http.StatusInternalServerError, rec.Code)
+	s.Contains(rec.Body.String(), response.InvalidBodyRequest.Code())
+}
+
+func (s *SoapTestSuite) TestTransportLog() {
+	restLog := &models.TransportRestLog{
+		IsServer:   true,
+		URL:        "/ws/hello",
+		Method:     http.MethodPost,
+		Headers:    utils.StringP("map[]"),
+		StatusCode: utils.StringP("200"),
+	}
+	b, err := marshalTransportLog(true, testSoapAction, restLog)
+	s.Require().NoError(err)
+	soapLog := &models.TransportSoapLog{}
+	s.Require().NoError(json.Unmarshal(b, soapLog))
+	s.True(soapLog.IsServer)
+	s.Equal(testSoapAction, soapLog.SOAPAction)
+	s.Equal("map[]", soapLog.Headers)
+	s.Equal("200", *soapLog.StatusCode)
+
+	b, err = marshalTransportLog(false, testSoapAction, restLog)
+	s.Require().NoError(err)
+	s.NotContains(string(b), "SOAPAction")
+}
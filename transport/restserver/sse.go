@@ -0,0 +1,211 @@
+package restserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/log/constant"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	contentTypeEventStream = "text/event-stream"
+	sseHeartbeat           = ": ping\n\n"
+	sseFrameEvent          = "event"
+	sseFrameComment        = "comment"
+)
+
+// SSEEvent is a server-sent event, Data is sent as is if it is string or []byte, otherwise as JSON
+type SSEEvent struct {
+	Id    string
+	Event string
+	Data  interface{}
+	Retry time.Duration
+}
+
+// SSEStream is a server-sent events response, it is not wrapped in BaseResponse
+type SSEStream struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	w      http.ResponseWriter
+	rc     *http.ResponseController
+	logger *frameLogger
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewSSE starts server-sent events response of the request, stream is closed when client disconnects,
+// Close is called or server shuts down, handler should return once Done is closed.
+// Auth and trace context of the request are kept in Context, EventSource can't set Authorization header,
+// so bearer token may be sent in access_token query param instead if route is registered by HandleStream.
+// Example to use
+//
+//	restserver.HandleStream(router, "/v1/notifications", restserver.InjectKeycloakContext(), func(c *gin.Context) {
+//		stream, err := restserver.NewSSE(c)
+//		if err != nil {
+//			restserver.SetResponse(c.Writer, err)
+//			return
+//		}
+//		defer stream.Close()
+//		for {
+//			select {
+//			case <-stream.Done():
+//				return
+//			case n := <-notifications:
+//				_ = stream.Send("notification", n)
+//			}
+//		}
+//	})
+func NewSSE(c *gin.Context, opts ...StreamOption) (*SSEStream, error) {
+	o := newStreamOptions(opts)
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	s := &SSEStream{
+		ctx:    ctx,
+		cancel: cancel,
+		logger: newFrameLogger(c, constant.LogTypeSse),
+	}
+	if err := registerStream(s); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	i := bypassInterceptor(c, http.StatusOK)
+	if i != nil {
+		s.w = i.ResponseWriter
+	} else {
+		s.w = c.Writer
+	}
+	s.rc = http.NewResponseController(s.w)
+
+	h := s.w.Header()
+	h.Set(headers.ContentType, contentTypeEventStream)
+	h.Set(headers.CacheControl, "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	s.w.WriteHeader(http.StatusOK)
+	if err := s.rc.Flush(); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	if o.heartbeat > integer.Zero {
+		go s.heartbeat(o.heartbeat)
+	}
+	return s, nil
+}
+
+// Context returns request context which is cancelled when stream is closed
+func (s *SSEStream) Context() context.Context {
+	return s.ctx
+}
+
+// Done is closed when stream is closed
+func (s *SSEStream) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// Send sends data with event name, empty event name is default message event
+func (s *SSEStream) Send(event string, data interface{}) error {
+	return s.SendEvent(&SSEEvent{Event: event, Data: data})
+}
+
+// SendEvent sends the event
+func (s *SSEStream) SendEvent(e *SSEEvent) error {
+	var data []byte
+	switch v := e.Data.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return err
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if e.Id != str.Empty {
+		fmt.Fprintf(buf, "id: %s\n", oneLine(e.Id))
+	}
+	if e.Event != str.Empty {
+		fmt.Fprintf(buf, "event: %s\n", oneLine(e.Event))
+	}
+	if e.Retry > integer.Zero {
+		fmt.Fprintf(buf, "retry: %s\n", strconv.FormatInt(e.Retry.Milliseconds(), 10))
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fmt.Fprintf(buf, "data: %s\n", line)
+	}
+	buf.WriteString("\n")
+
+	if err := s.write(buf.Bytes()); err != nil {
+		return err
+	}
+	s.logger.log(false, sseFrameEvent, data)
+	return nil
+}
+
+// Close ends the stream, it is safe to be called more than once
+func (s *SSEStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.cancel()
+	unregisterStream(s)
+}
+
+func (s *SSEStream) shutdown() {
+	log.Debug(s.ctx, "Close SSE stream, REST server is shutting down")
+	s.Close()
+}
+
+func (s *SSEStream) write(b []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.ctx.Err() != nil {
+		return errors.New("SSE stream is closed")
+	}
+	if _, err := s.w.Write(b); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+func (s *SSEStream) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			s.Close()
+			return
+		case <-ticker.C:
+			if err := s.write([]byte(sseHeartbeat)); err != nil {
+				log.Debugf(s.ctx, "Failed to send SSE heartbeat, close the stream, error=%v", err)
+				s.Close()
+				return
+			}
+			s.logger.log(false, sseFrameComment, nil)
+		}
+	}
+}
+
+// oneLine removes line breaks which would end SSE field
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", str.Empty, "\n", str.Empty).Replace(s)
+}
@@ -0,0 +1,229 @@
+package restserver
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/constant/sym"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/log/constant"
+	"github.com/rosaekapratama/go-starter/response"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHeartbeatInterval = 15 * time.Second
+	accessTokenQueryKey      = "access_token"
+	redactedValue            = "REDACTED"
+)
+
+var (
+	// streams are open SSE streams and WebSocket connections, closed by Shutdown
+	streams      = make(map[streamCloser]struct{})
+	streamsMu    sync.Mutex
+	shuttingDown bool
+
+	// streamRoutes are full paths of routes registered by HandleStream, only they accept access_token query param
+	streamRoutes   = make(map[string]bool)
+	streamRoutesMu sync.RWMutex
+)
+
+type streamCloser interface {
+	// shutdown closes the stream because server is shutting down
+	shutdown()
+}
+
+type StreamOption interface {
+	Apply(o *streamOptions)
+}
+
+type streamOptions struct {
+	heartbeat   time.Duration
+	checkOrigin func(r *http.Request) bool
+}
+
+type heartbeatOption struct {
+	interval time.Duration
+}
+
+type checkOriginOption struct {
+	checkOrigin func(r *http.Request) bool
+}
+
+func (o *heartbeatOption) Apply(opts *streamOptions) {
+	opts.heartbeat = o.interval
+}
+
+func (o *checkOriginOption) Apply(opts *streamOptions) {
+	opts.checkOrigin = o.checkOrigin
+}
+
+// WithHeartbeat set interval of SSE comment or WebSocket ping, default is 15s, zero or negative disables it
+func WithHeartbeat(interval time.Duration) StreamOption {
+	return &heartbeatOption{interval: interval}
+}
+
+// WithCheckOrigin set WebSocket origin check, default only allows request from the same host
+func WithCheckOrigin(checkOrigin func(r *http.Request) bool) StreamOption {
+	return &checkOriginOption{checkOrigin: checkOrigin}
+}
+
+func newStreamOptions(opts []StreamOption) *streamOptions {
+	o := &streamOptions{heartbeat: defaultHeartbeatInterval}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.Apply(o)
+		}
+	}
+	return o
+}
+
+// registerStream tracks the stream until unregistered, it returns error if server is shutting down
+func registerStream(s streamCloser) error {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	if shuttingDown {
+		return errors.New("REST server is shutting down")
+	}
+	streams[s] = struct{}{}
+	return nil
+}
+
+func unregisterStream(s streamCloser) {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	delete(streams, s)
+}
+
+// closeStreams closes every open stream and rejects new ones
+func closeStreams() {
+	streamsMu.Lock()
+	shuttingDown = true
+	closers := make([]streamCloser, integer.Zero, len(streams))
+	for s := range streams {
+		closers = append(closers, s)
+	}
+	streamsMu.Unlock()
+
+	for _, s := range closers {
+		s.shutdown()
+	}
+}
+
+// bypassInterceptor marks response as written raw, so WriterInterceptor doesn't wrap or buffer it
+func bypassInterceptor(c *gin.Context, status int) *WriterInterceptor {
+	i := castInterceptor(c.Writer)
+	if i == nil {
+		return nil
+	}
+	i.response = response.Success
+	i.IsRaw = true
+	i.IsWritten = true
+	i.status = status
+	return i
+}
+
+// frameLogger logs frames of a stream to stdout if REST server stdout logging is enabled, payload is truncated to size limit
+type frameLogger struct {
+	ctx       context.Context
+	logType   string
+	url       string
+	enabled   bool
+	sizeLimit int
+}
+
+func newFrameLogger(c *gin.Context, logType string) *frameLogger {
+	l := &frameLogger{
+		ctx:     c.Request.Context(),
+		logType: logType,
+		url:     redactUrl(c.Request.URL),
+	}
+	if cfg != nil && cfg.Transport.Server.Rest.Logging != nil {
+		l.enabled = cfg.Transport.Server.Rest.Logging.Stdout
+	}
+	if i := castInterceptor(c.Writer); i != nil {
+		l.sizeLimit = i.payloadLogSizeLimit
+	}
+	return l
+}
+
+func (l *frameLogger) log(isRequest bool, frameType string, payload []byte) {
+	if !l.enabled {
+		return
+	}
+
+	body := payload
+	if l.sizeLimit > integer.Zero && len(payload) > l.sizeLimit {
+		body = append(append(make([]byte, integer.Zero, l.sizeLimit+len(sym.Ellipsis)), payload[:l.sizeLimit]...), sym.Ellipsis...)
+	}
+	fields := map[string]interface{}{
+		constant.LogTypeFieldLogKey: l.logType,
+		constant.UrlLogKey:          l.url,
+		constant.IsServerLogKey:     true,
+		constant.IsRequestLogKey:    isRequest,
+		constant.FrameTypeLogKey:    frameType,
+		constant.SizeLogKey:         len(payload),
+		constant.BodyLogKey:         string(body),
+	}
+	log.WithTraceFields(l.ctx).WithFields(fields).GetLogrusLogger().Info()
+}
+
+// HandleStream registers GET route of SSE or WebSocket handler. Browser EventSource and WebSocket can't set
+// Authorization header, so only routes registered by it accept bearer token in access_token query param.
+func HandleStream(router gin.IRoutes, path string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	streamRoutesMu.Lock()
+	streamRoutes[fullPathOf(router, path)] = true
+	streamRoutesMu.Unlock()
+	return router.GET(path, handlers...)
+}
+
+// isStreamRoute returns true if the request is GET to route registered by HandleStream
+func isStreamRoute(c *gin.Context) bool {
+	if c.Request.Method != http.MethodGet {
+		return false
+	}
+	streamRoutesMu.RLock()
+	defer streamRoutesMu.RUnlock()
+	return streamRoutes[c.FullPath()]
+}
+
+// accessTokenFromQuery removes access_token query param from request URL and returns its value
+func accessTokenFromQuery(c *gin.Context) string {
+	query := c.Request.URL.Query()
+	token := query.Get(accessTokenQueryKey)
+	if query.Has(accessTokenQueryKey) {
+		query.Del(accessTokenQueryKey)
+		c.Request.URL.RawQuery = query.Encode()
+		c.Request.RequestURI = c.Request.URL.RequestURI()
+	}
+	return token
+}
+
+// redactUrl returns the URL with value of access_token query param replaced, so token is never logged
+func redactUrl(u *url.URL) string {
+	if u == nil {
+		return str.Empty
+	}
+	query := u.Query()
+	if !query.Has(accessTokenQueryKey) {
+		return u.String()
+	}
+	query.Set(accessTokenQueryKey, redactedValue)
+	redacted := *u
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// fullPathOf returns path prefixed by base path of the router if it is router group
+func fullPathOf(router gin.IRoutes, path string) string {
+	if group, ok := router.(interface{ BasePath() string }); ok {
+		return strings.TrimSuffix(group.BasePath(), sym.ForwardSlash) + sym.ForwardSlash + strings.TrimPrefix(path, sym.ForwardSlash)
+	}
+	return path
+}
@@ -0,0 +1,174 @@
+package restserver
+
+import (
+	"bufio"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	commonContext "github.com/rosaekapratama/go-starter/context"
+	"github.com/stretchr/testify/suite"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type StreamTestSuite struct {
+	suite.Suite
+	server *httptest.Server
+}
+
+func (s *StreamTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	streamsMu.Lock()
+	shuttingDown = false
+	streamsMu.Unlock()
+
+	router := gin.New()
+	router.Use(encodeResponse(context.Background(), nil, nil), interceptResponse(16), injectAuthContext)
+	HandleStream(router, "/events", func(c *gin.Context) {
+		stream, err := NewSSE(c, WithHeartbeat(20*time.Millisecond))
+		if err != nil {
+			return
+		}
+		defer stream.Close()
+		token, _ := commonContext.TokenFromContext(stream.Context())
+		_ = stream.Send("greeting", map[string]string{"token": token})
+		_ = stream.SendEvent(&SSEEvent{Id: "2", Data: "line1\nline2"})
+		<-stream.Done()
+	})
+	HandleStream(router, "/ws", func(c *gin.Context) {
+		conn, err := UpgradeWebSocket(c)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			messageType, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			_ = conn.WriteMessage(messageType, message)
+		}
+	})
+	router.GET("/plain", func(c *gin.Context) {
+		token, _ := commonContext.TokenFromContext(c.Request.Context())
+		c.String(http.StatusOK, token)
+	})
+	group := router.Group("/v1")
+	HandleStream(group, "/echo", func(c *gin.Context) {
+		token, _ := commonContext.TokenFromContext(c.Request.Context())
+		c.String(http.StatusOK, token+" "+c.Request.URL.String()+" "+c.Request.RequestURI)
+	})
+	s.server = httptest.NewServer(router)
+}
+
+func (s *StreamTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func TestStreamTestSuite(t *testing.T) {
+	suite.Run(t, new(StreamTestSuite))
+}
+
+func (s *StreamTestSuite) TestSSE() {
+	req, err := http.NewRequest(http.MethodGet, s.server.URL+"/events?access_token=abc", nil)
+	s.Require().NoError(err)
+	req.Header.Set("Accept", contentTypeEventStream)
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Equal(http.StatusOK, resp.StatusCode)
+	s.Equal(contentTypeEventStream, resp.Header.Get("Content-Type"))
+
+	// Read until heartbeat comment is received
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		s.Require().NoError(err)
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+		if line == ": ping\n" {
+			break
+		}
+	}
+	s.Equal([]string{
+		"event: greeting",
+		`data: {"token":"abc"}`,
+		"",
+		"id: 2",
+		"data: line1",
+		"data: line2",
+		"",
+		": ping",
+	}, lines)
+}
+
+func (s *StreamTestSuite) TestWebSocket() {
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.server.URL, "http")+"/ws", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	s.Require().NoError(conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+	messageType, message, err := conn.ReadMessage()
+	s.Require().NoError(err)
+	s.Equal(websocket.TextMessage, messageType)
+	s.Equal("hello", string(message))
+}
+
+func (s *StreamTestSuite) TestShutdown() {
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.server.URL, "http")+"/ws", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	// Make sure connection is registered before shutdown
+	s.Require().NoError(conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+	_, _, err = conn.ReadMessage()
+	s.Require().NoError(err)
+
+	closeStreams()
+	_, _, err = conn.ReadMessage()
+	s.True(websocket.IsCloseError(err, websocket.CloseGoingAway), "unexpected error %v", err)
+
+	// New connection is closed right away while server is shutting down
+	conn2, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.server.URL, "http")+"/ws", nil)
+	s.Require().NoError(err)
+	defer conn2.Close()
+	_, _, err = conn2.ReadMessage()
+	s.True(websocket.IsCloseError(err, websocket.CloseGoingAway), "unexpected error %v", err)
+}
+
+func (s *StreamTestSuite) TestQueryToken() {
+	get := func(path string) string {
+		req, err := http.NewRequest(http.MethodGet, s.server.URL+path, nil)
+		s.Require().NoError(err)
+		req.Header.Set("Accept", contentTypeEventStream)
+		resp, err := http.DefaultClient.Do(req)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+		return string(body)
+	}
+
+	// Query token is ignored on route not registered by HandleStream even if client asks for event stream
+	s.Empty(get("/plain?access_token=abc"))
+
+	// Query token is removed from request URL once it is read
+	s.Equal("abc /v1/echo?page=1 /v1/echo?page=1", get("/v1/echo?page=1&access_token=abc"))
+}
+
+func (s *StreamTestSuite) TestRedactUrl() {
+	u, err := url.Parse("/events?access_token=abc&page=1")
+	s.Require().NoError(err)
+	s.Equal("/events?access_token=REDACTED&page=1", redactUrl(u))
+
+	u, err = url.Parse("/events?page=1")
+	s.Require().NoError(err)
+	s.Equal("/events?page=1", redactUrl(u))
+	s.Empty(redactUrl(nil))
+}
@@ -0,0 +1,61 @@
+// Package swaggerui serves Swagger UI of restserver OpenAPI document without internet access,
+// its assets are embedded, so it is opt-in by importing the package for its side effect
+//
+//	import _ "github.com/rosaekapratama/go-starter/transport/restserver/swaggerui"
+package swaggerui
+
+import (
+	"embed"
+	"fmt"
+	"github.com/rosaekapratama/go-starter/transport/restserver"
+	"html"
+	"strconv"
+)
+
+const (
+	contentTypeTextCss    = "text/css; charset=utf-8"
+	contentTypeJavascript = "text/javascript; charset=utf-8"
+	swaggerUiCss          = "swagger-ui.css"
+	swaggerUiBundle       = "swagger-ui-bundle.js"
+
+	// Page loads Swagger UI assets served under the UI path
+	swaggerUiHtml = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8"/>
+<title>%s</title>
+<link rel="stylesheet" href="%s"/>
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="%s"></script>
+<script>SwaggerUIBundle({url: %s, dom_id: "#swagger-ui"});</script>
+</body>
+</html>`
+)
+
+var (
+	// assets is Swagger UI 5.18.2 dist, see README.md
+	//go:embed swagger-ui.css swagger-ui-bundle.js
+	assets embed.FS
+)
+
+type swaggerUi struct{}
+
+func init() {
+	restserver.SetOpenAPIUi(&swaggerUi{})
+}
+
+func (u *swaggerUi) Page(title string, docPath string, assetPath string) []byte {
+	return []byte(fmt.Sprintf(swaggerUiHtml, html.EscapeString(title),
+		html.EscapeString(assetPath+swaggerUiCss), html.EscapeString(assetPath+swaggerUiBundle), strconv.Quote(docPath)))
+}
+
+func (u *swaggerUi) Assets() map[string]*restserver.OpenAPIUiAsset {
+	css, _ := assets.ReadFile(swaggerUiCss)
+	bundle, _ := assets.ReadFile(swaggerUiBundle)
+	return map[string]*restserver.OpenAPIUiAsset{
+		swaggerUiCss:    {ContentType: contentTypeTextCss, Content: css},
+		swaggerUiBundle: {ContentType: contentTypeJavascript, Content: bundle},
+	}
+}
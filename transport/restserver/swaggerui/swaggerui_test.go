@@ -0,0 +1,32 @@
+package swaggerui
+
+import (
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type SwaggerUiTestSuite struct {
+	suite.Suite
+}
+
+func TestSwaggerUiTestSuite(t *testing.T) {
+	suite.Run(t, new(SwaggerUiTestSuite))
+}
+
+func (s *SwaggerUiTestSuite) TestPage() {
+	// Assets are loaded from the UI path instead of CDN
+	page := string((&swaggerUi{}).Page("<test>", "/openapi.json", "/docs/"))
+	s.Contains(page, "<title>&lt;test&gt;</title>")
+	s.Contains(page, `href="/docs/swagger-ui.css"`)
+	s.Contains(page, `src="/docs/swagger-ui-bundle.js"`)
+	s.Contains(page, `url: "/openapi.json"`)
+	s.NotContains(page, "https://")
+}
+
+func (s *SwaggerUiTestSuite) TestAssets() {
+	assets := (&swaggerUi{}).Assets()
+	s.Equal(contentTypeJavascript, assets[swaggerUiBundle].ContentType)
+	s.Contains(string(assets[swaggerUiBundle].Content), "SwaggerUIBundle")
+	s.Equal(contentTypeTextCss, assets[swaggerUiCss].ContentType)
+	s.NotEmpty(assets[swaggerUiCss].Content)
+}
@@ -0,0 +1,245 @@
+package restserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/healthcheck"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/response"
+	"github.com/rosaekapratama/go-starter/utils"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultCertReloadInterval = time.Minute
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":             tls.NoClientCert,
+	"request":          tls.RequestClientCert,
+	"require":          tls.RequireAnyClientCert,
+	"verifyifgiven":    tls.VerifyClientCertIfGiven,
+	"requireandverify": tls.RequireAndVerifyClientCert,
+}
+
+// certReloader keeps server certificate and client CA bundle up to date with the files on disk,
+// so rotated certificates are served without restarting the server
+type certReloader struct {
+	cfg        *config.ServerTLSConfig
+	clientAuth tls.ClientAuthType
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTime   time.Time
+}
+
+func newCertReloader(cfg *config.ServerTLSConfig) (*certReloader, error) {
+	if cfg.CertFile == str.Empty || cfg.KeyFile == str.Empty {
+		return nil, response.ConfigNotFound
+	}
+
+	r := &certReloader{cfg: cfg, clientAuth: tls.NoClientCert}
+	if cfg.ClientCaFile != str.Empty {
+		r.clientAuth = tls.RequireAndVerifyClientCert
+	}
+	if cfg.ClientAuth != str.Empty {
+		clientAuth, ok := clientAuthTypes[strings.ToLower(cfg.ClientAuth)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported client auth '%s', valid values are none, request, require, verifyIfGiven or requireAndVerify", cfg.ClientAuth)
+		}
+		r.clientAuth = clientAuth
+	}
+	if r.clientAuth >= tls.VerifyClientCertIfGiven && cfg.ClientCaFile == str.Empty {
+		return nil, fmt.Errorf("client auth '%s' requires clientCaFile", cfg.ClientAuth)
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// load reads certificate files, current ones are kept if any of the files is invalid
+func (r *certReloader) load() error {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS key pair, certFile=%s, keyFile=%s: %w", r.cfg.CertFile, r.cfg.KeyFile, err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.cfg.ClientCaFile != str.Empty {
+		clientCAs, err = utils.NewCertPool(r.cfg.ClientCaFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.modTime = modTime
+	return nil
+}
+
+func (r *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCaFile} {
+		if file == str.Empty {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return latest, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// watch reloads certificate files whenever any of them is modified, until ctx is done
+func (r *certReloader) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			modTime, err := r.latestModTime()
+			if err != nil {
+				log.Warnf(ctx, "Failed to check REST server certificate files, error=%v", err)
+				continue
+			}
+
+			r.mu.RLock()
+			modified := modTime.After(r.modTime)
+			r.mu.RUnlock()
+			if !modified {
+				continue
+			}
+
+			if err = r.load(); err != nil {
+				log.Error(ctx, err, "Failed to reload REST server certificate, keep serving the current one")
+				continue
+			}
+			log.Info(ctx, "REST server certificate is reloaded")
+		}
+	}
+}
+
+func (r *certReloader) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.cert, nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				NextProtos:   []string{"h2", "http/1.1"},
+				Certificates: []tls.Certificate{*r.cert},
+				ClientAuth:   r.clientAuth,
+				ClientCAs:    r.clientCAs,
+			}, nil
+		},
+	}
+}
+
+// runTLS serves HTTPS on https port and either serves or redirects plain HTTP on http port
+func runTLS(ctx context.Context, restCfg *config.RestServerConfig) {
+	tlsCfg := restCfg.TLS
+	reloader, err := newCertReloader(tlsCfg)
+	if err != nil {
+		log.Fatal(ctx, err, "Invalid REST server TLS config")
+		return
+	}
+
+	reloadInterval := defaultCertReloadInterval
+	if tlsCfg.ReloadInterval != nil && tlsCfg.ReloadInterval.Duration > integer.Zero {
+		reloadInterval = tlsCfg.ReloadInterval.Duration
+	}
+
+	httpPort := restCfg.Port.Http
+	httpsPort := restCfg.Port.Https
+	httpsServer := &http.Server{
+		Addr:      fmt.Sprintf("%s:%d", "0.0.0.0", httpsPort),
+		Handler:   Router.Handler(),
+		TLSConfig: reloader.tlsConfig(),
+	}
+	httpHandler := Router.Handler()
+	if tlsCfg.RedirectHttp {
+		httpHandler = redirectToHttps(httpsPort)
+	}
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", "0.0.0.0", httpPort),
+		Handler: httpHandler,
+	}
+	if !addServers(httpsServer, httpServer) {
+		log.Warn(ctx, "REST server is already shut down")
+		return
+	}
+	go reloader.watch(serverCtx, reloadInterval)
+
+	errs := make(chan error, 2)
+	go func() {
+		log.Infof(ctx, "Starting REST server on HTTPS port %d, clientAuth=%s", httpsPort, reloader.clientAuth)
+		errs <- httpsServer.ListenAndServeTLS(str.Empty, str.Empty)
+	}()
+	go func() {
+		if tlsCfg.RedirectHttp {
+			log.Infof(ctx, "Starting REST server HTTPS redirection on port %d", httpPort)
+		} else {
+			log.Infof(ctx, "Starting REST server on port %d", httpPort)
+		}
+		errs <- httpServer.ListenAndServe()
+	}()
+
+	if err = <-errs; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf(ctx, err, "Failed to run REST server, httpPort=%d, httpsPort=%d", httpPort, httpsPort)
+	}
+}
+
+// redirectToHttps redirects every request except health check to the same URL on https port
+func redirectToHttps(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isHealthCheck, _ := regexp.MatchString(healthcheck.URLPathRegex, r.URL.Path); isHealthCheck {
+			Router.ServeHTTP(w, r)
+			return
+		}
+
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		}
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,104 @@
+package restserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/stretchr/testify/suite"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type TLSTestSuite struct {
+	suite.Suite
+	dir string
+	cfg *config.ServerTLSConfig
+}
+
+func (s *TLSTestSuite) SetupTest() {
+	s.dir = s.T().TempDir()
+	s.cfg = &config.ServerTLSConfig{
+		Enabled:  true,
+		CertFile: filepath.Join(s.dir, "server.crt"),
+		KeyFile:  filepath.Join(s.dir, "server.key"),
+	}
+	s.writeCert("first")
+}
+
+func TestTLSTestSuite(t *testing.T) {
+	suite.Run(t, new(TLSTestSuite))
+}
+
+// writeCert writes self-signed certificate with the given common name
+func (s *TLSTestSuite) writeCert(commonName string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.Require().NoError(err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	s.Require().NoError(err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	s.Require().NoError(err)
+
+	s.Require().NoError(os.WriteFile(s.cfg.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	s.Require().NoError(os.WriteFile(s.cfg.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+}
+
+func (s *TLSTestSuite) commonName(r *certReloader) string {
+	tlsConfig, err := r.tlsConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	s.Require().NoError(err)
+	cert, err := x509.ParseCertificate(tlsConfig.Certificates[0].Certificate[0])
+	s.Require().NoError(err)
+	return cert.Subject.CommonName
+}
+
+func (s *TLSTestSuite) TestReload() {
+	r, err := newCertReloader(s.cfg)
+	s.Require().NoError(err)
+	s.Equal(tls.NoClientCert, r.clientAuth)
+	s.Equal("first", s.commonName(r))
+
+	// Make sure modification time is changed
+	s.writeCert("second")
+	future := time.Now().Add(time.Minute)
+	s.Require().NoError(os.Chtimes(s.cfg.CertFile, future, future))
+
+	modTime, err := r.latestModTime()
+	s.Require().NoError(err)
+	s.True(modTime.After(r.modTime))
+	s.Require().NoError(r.load())
+	s.Equal("second", s.commonName(r))
+}
+
+func (s *TLSTestSuite) TestClientAuthRequiresCa() {
+	s.cfg.ClientAuth = "requireAndVerify"
+	_, err := newCertReloader(s.cfg)
+	s.Error(err)
+
+	s.cfg.ClientAuth = "unknown"
+	_, err = newCertReloader(s.cfg)
+	s.Error(err)
+}
+
+func (s *TLSTestSuite) TestRedirectToHttps() {
+	req := httptest.NewRequest(http.MethodGet, "http://example.com:8080/v1/users?id=1", nil)
+	rec := httptest.NewRecorder()
+	redirectToHttps(8443).ServeHTTP(rec, req)
+	s.Equal(http.StatusPermanentRedirect, rec.Code)
+	s.Equal("https://example.com:8443/v1/users?id=1", rec.Header().Get(headers.Location))
+}
@@ -0,0 +1,195 @@
+package restserver
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/log"
+	"github.com/rosaekapratama/go-starter/log/constant"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	wsWriteTimeout = 10 * time.Second
+	wsFrameText    = "text"
+	wsFrameBinary  = "binary"
+	wsFramePing    = "ping"
+	wsFrameClose   = "close"
+)
+
+var wsFrameTypes = map[int]string{
+	websocket.TextMessage:   wsFrameText,
+	websocket.BinaryMessage: wsFrameBinary,
+	websocket.PingMessage:   wsFramePing,
+	websocket.CloseMessage:  wsFrameClose,
+}
+
+// WebSocketConn is an upgraded WebSocket connection, writes are safe for concurrent use,
+// reads must be done by one goroutine and are required to process ping, pong and close frames
+type WebSocketConn struct {
+	conn      *websocket.Conn
+	ctx       context.Context
+	cancel    context.CancelFunc
+	logger    *frameLogger
+	pongWait  time.Duration
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+}
+
+// UpgradeWebSocket upgrades the request to WebSocket connection, connection is closed by Close or when server shuts down.
+// Auth and trace context of the request are kept in Context, browser WebSocket can't set Authorization header,
+// so bearer token may be sent in access_token query param instead if route is registered by HandleStream.
+// If heartbeat is enabled, connection is closed if client doesn't answer ping within 2 heartbeat intervals.
+// Example to use
+//
+//	restserver.HandleStream(router, "/v1/chat", restserver.InjectKeycloakContext(), func(c *gin.Context) {
+//		conn, err := restserver.UpgradeWebSocket(c)
+//		if err != nil {
+//			return
+//		}
+//		defer conn.Close()
+//		for {
+//			messageType, message, err := conn.ReadMessage()
+//			if err != nil {
+//				return
+//			}
+//			_ = conn.WriteMessage(messageType, message)
+//		}
+//	})
+func UpgradeWebSocket(c *gin.Context, opts ...StreamOption) (*WebSocketConn, error) {
+	o := newStreamOptions(opts)
+	upgrader := websocket.Upgrader{CheckOrigin: o.checkOrigin}
+
+	w := http.ResponseWriter(c.Writer)
+	if i := bypassInterceptor(c, http.StatusSwitchingProtocols); i != nil {
+		w = i.ResponseWriter
+	}
+	conn, err := upgrader.Upgrade(w, c.Request, nil)
+	if err != nil {
+		// Upgrader already replied with error status
+		log.Warnf(c.Request.Context(), "Failed to upgrade WebSocket connection, error=%v", err)
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	ws := &WebSocketConn{
+		conn:   conn,
+		ctx:    ctx,
+		cancel: cancel,
+		logger: newFrameLogger(c, constant.LogTypeWebSocket),
+	}
+	if err = registerStream(ws); err != nil {
+		ws.closeWith(websocket.CloseGoingAway, err.Error())
+		return nil, err
+	}
+
+	if o.heartbeat > integer.Zero {
+		ws.pongWait = 2 * o.heartbeat
+		_ = conn.SetReadDeadline(time.Now().Add(ws.pongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(ws.pongWait))
+		})
+		go ws.heartbeat(o.heartbeat)
+	}
+	return ws, nil
+}
+
+// Context returns request context which is cancelled when connection is closed
+func (ws *WebSocketConn) Context() context.Context {
+	return ws.ctx
+}
+
+// Conn returns underlying gorilla connection
+func (ws *WebSocketConn) Conn() *websocket.Conn {
+	return ws.conn
+}
+
+// ReadMessage reads next text or binary message
+func (ws *WebSocketConn) ReadMessage() (messageType int, message []byte, err error) {
+	messageType, message, err = ws.conn.ReadMessage()
+	if err != nil {
+		ws.Close()
+		return
+	}
+	ws.logger.log(true, wsFrameTypes[messageType], message)
+	return
+}
+
+// ReadJSON reads next message as JSON into v
+func (ws *WebSocketConn) ReadJSON(v interface{}) error {
+	_, message, err := ws.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(message, v)
+}
+
+// WriteMessage writes text or binary message
+func (ws *WebSocketConn) WriteMessage(messageType int, message []byte) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	_ = ws.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := ws.conn.WriteMessage(messageType, message); err != nil {
+		return err
+	}
+	ws.logger.log(false, wsFrameTypes[messageType], message)
+	return nil
+}
+
+// WriteJSON writes v as JSON text message
+func (ws *WebSocketConn) WriteJSON(v interface{}) error {
+	message, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ws.WriteMessage(websocket.TextMessage, message)
+}
+
+// Close sends normal closure frame and closes the connection, it is safe to be called more than once
+func (ws *WebSocketConn) Close() {
+	ws.closeWith(websocket.CloseNormalClosure, "")
+}
+
+func (ws *WebSocketConn) shutdown() {
+	log.Debug(ws.ctx, "Close WebSocket connection, REST server is shutting down")
+	ws.closeWith(websocket.CloseGoingAway, "server is shutting down")
+}
+
+func (ws *WebSocketConn) closeWith(code int, reason string) {
+	ws.closeOnce.Do(func() {
+		ws.writeMu.Lock()
+		message := websocket.FormatCloseMessage(code, reason)
+		if err := ws.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(wsWriteTimeout)); err == nil {
+			ws.logger.log(false, wsFrameClose, []byte(reason))
+		}
+		ws.writeMu.Unlock()
+
+		_ = ws.conn.Close()
+		ws.cancel()
+		unregisterStream(ws)
+	})
+}
+
+func (ws *WebSocketConn) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ws.ctx.Done():
+			return
+		case <-ticker.C:
+			ws.writeMu.Lock()
+			err := ws.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+			ws.writeMu.Unlock()
+			if err != nil {
+				log.Debugf(ws.ctx, "Failed to send WebSocket ping, close the connection, error=%v", err)
+				ws.Close()
+				return
+			}
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package soapclient
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"github.com/go-resty/resty/v2"
 	"github.com/inhies/go-bytesize"
@@ -16,11 +19,15 @@ import (
 	"github.com/rosaekapratama/go-starter/utils"
 	"github.com/tiaguinho/gosoap"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/net/html/charset"
+	"io"
 	"net/http"
 	"time"
 )
 
 var (
+	errUnexpectedStatusCode = errors.New("unexpected status code")
+
 	_config             config.Config
 	Manager             IManager
 	LogRepository       repositories.ITransportLogRepository
@@ -74,8 +81,12 @@ func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error)
 		}
 	}
 
-	// Perform the actual HTTP request
-	res, err := t.transport.RoundTrip(req)
+	// Perform the actual HTTP request, or replay it from cassette
+	var next http.RoundTripper = t.transport
+	if t.recorder != nil {
+		next = t.recorder.Wrap(t.transport)
+	}
+	res, err := next.RoundTrip(req)
 	if err != nil && t.logging != nil {
 		if t.logging.Stdout {
 			go errorStdoutLogging(clonedReq, err)
@@ -111,8 +122,10 @@ func (m *managerImpl) GetDefaultClient() *Client {
 func newClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
 	transport := &loggingTransport{transport: http.DefaultTransport.(*http.Transport)}
 	client := &Client{
-		httpClient: &http.Client{Transport: otelhttp.NewTransport(transport)},
-		transport:  transport,
+		httpClient:     &http.Client{Transport: otelhttp.NewTransport(transport)},
+		transport:      transport,
+		definitionsMap: make(map[string]*wsdlDefinitions),
+		soapVersion:    SoapVersion11,
 	}
 	for _, opt := range opts {
 		err := opt.Apply(ctx, client)
@@ -128,25 +141,95 @@ func (m *managerImpl) NewClient(ctx context.Context, opts ...ClientOption) (*Cli
 	return newClient(ctx, opts...)
 }
 
+// Call calls the method of the WSDL, fault of the response is returned as *Fault together with the response
 func (c *Client) Call(ctx context.Context, wsdlAddress string, method string, params gosoap.SoapParams) (res *gosoap.Response, err error) {
-	var soapClient *gosoap.Client
-	var exists bool
-	if soapClient, exists = c.soapClientMap[wsdlAddress]; !exists {
-		soapClient, err = gosoap.SoapClient(wsdlAddress, c.httpClient)
-		if err != nil {
-			log.Errorf(ctx, err, "failed to get SOAP client, wsdlAddress=%s", wsdlAddress)
-			return
-		}
-		c.soapClientMap[wsdlAddress] = soapClient
+	op, err := c.operation(ctx, wsdlAddress, method)
+	if err != nil {
+		log.Errorf(ctx, err, "failed to get SOAP operation, wsdlAddress=%s, method=%s", wsdlAddress, method)
+		return
 	}
 
-	res, err = soapClient.Call(method, params)
+	res, err = c.do(ctx, op, params)
 	if err != nil {
-		log.Error(ctx, err, "failed to call SOAP method, wsdlAddress=%s, method=%s", wsdlAddress, method)
+		log.Errorf(ctx, err, "failed to call SOAP method, wsdlAddress=%s, method=%s", wsdlAddress, method)
 	}
 	return
 }
 
+func (c *Client) do(ctx context.Context, op *operation, params gosoap.SoapParams) (*gosoap.Response, error) {
+	doc, parts, err := c.envelope(op, params)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := doc.WriteToBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	body, contentType := payload, c.contentType(op.action)
+	if c.mtom {
+		if body, contentType, err = c.writeMtom(payload, op.action, parts); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, op.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(headers.ContentType, contentType)
+	req.Header.Set(headers.Accept, c.mediaType())
+	if c.soapVersion == SoapVersion11 {
+		req.Header.Set(headers.SOAPAction, op.action)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if b, err = readMtom(b, resp.Header.Get(headers.ContentType)); err != nil {
+		return nil, err
+	}
+
+	envelope := &responseEnvelope{}
+	decoder := xml.NewDecoder(bytes.NewReader(b))
+	decoder.CharsetReader = charset.NewReaderLabel
+	if err = decoder.Decode(envelope); err != nil {
+		if resp.StatusCode >= http.StatusBadRequest {
+			return nil, fmt.Errorf("%w: %s", errUnexpectedStatusCode, resp.Status)
+		}
+		return nil, err
+	}
+
+	res := &gosoap.Response{Body: envelope.Body.Contents, Payload: payload}
+	if envelope.Header != nil {
+		res.Header = envelope.Header.Contents
+	}
+	if envelope.Body.Fault != nil {
+		return res, newFault(envelope.Body.Fault, resp.StatusCode)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return res, fmt.Errorf("%w: %s", errUnexpectedStatusCode, resp.Status)
+	}
+	return res, nil
+}
+
+// securityHeader returns WS-Security header config of the client, it is created on first call
+func (c *Client) securityHeader() *wsSecurity {
+	if c.security == nil {
+		c.security = &wsSecurity{
+			timestampTtl: defaultTimestampTtl,
+			now:          time.Now,
+		}
+	}
+	return c.security
+}
+
 func preStdoutLogging(r *http.Request) {
 	httpFields := make(map[string]interface{})
 	httpFields[constant.LogTypeFieldLogKey] = constant.LogTypeSoap
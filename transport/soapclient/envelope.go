@@ -0,0 +1,228 @@
+package soapclient
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/beevik/etree"
+	"github.com/rosaekapratama/go-starter/constant/headers/contenttype"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/constant/sym"
+	"github.com/tiaguinho/gosoap"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	SoapVersion11 = "1.1"
+	SoapVersion12 = "1.2"
+
+	soapPrefix      = "soap"
+	soapNamespace11 = "http://schemas.xmlsoap.org/soap/envelope/"
+	soapNamespace12 = "http://www.w3.org/2003/05/soap-envelope"
+	xsiNamespace    = "http://www.w3.org/2001/XMLSchema-instance"
+	xsdNamespace    = "http://www.w3.org/2001/XMLSchema"
+	xmlnsPrefix     = "xmlns"
+)
+
+var (
+	errInvalidSoapVersion  = errors.New("invalid SOAP version")
+	errSoapAddressNotFound = errors.New("soap address not found in wsdl definitions")
+)
+
+// operation returns WSDL operation of the method on the port bound to the SOAP version of the client,
+// WSDL of the address is fetched once and cached
+func (c *Client) operation(ctx context.Context, wsdlAddress string, method string) (*operation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	definitions, exists := c.definitionsMap[wsdlAddress]
+	if !exists {
+		var err error
+		if definitions, err = fetchWsdl(ctx, c.httpClient, wsdlAddress); err != nil {
+			return nil, err
+		}
+		c.definitionsMap[wsdlAddress] = definitions
+	}
+
+	endpoint, binding := definitions.endpoint(c.soapVersion)
+	if endpoint == str.Empty {
+		return nil, errSoapAddressNotFound
+	}
+	return &operation{
+		method:    method,
+		namespace: definitions.namespace(),
+		action:    definitions.soapAction(binding, method),
+		endpoint:  endpoint,
+	}, nil
+}
+
+// envelope builds SOAP envelope of the operation,
+// attachments referenced by xop:Include are returned as MTOM parts
+func (c *Client) envelope(op *operation, params gosoap.SoapParams) (*etree.Document, []*mtomPart, error) {
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
+	env := doc.CreateElement(soapPrefix + sym.Colon + "Envelope")
+	env.CreateAttr(xmlnsPrefix+sym.Colon+soapPrefix, c.soapNamespace())
+	env.CreateAttr(xmlnsPrefix+sym.Colon+"xsi", xsiNamespace)
+	env.CreateAttr(xmlnsPrefix+sym.Colon+"xsd", xsdNamespace)
+
+	header := env.CreateElement(soapPrefix + sym.Colon + "Header")
+	for _, h := range c.soapHeaders {
+		if err := appendXml(header, h); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	body := env.CreateElement(soapPrefix + sym.Colon + "Body")
+	request := body.CreateElement(op.method)
+	request.CreateAttr(xmlnsPrefix, op.namespace)
+	encoder := &paramEncoder{mtom: c.mtom}
+	if err := encoder.encode(request, params); err != nil {
+		return nil, nil, err
+	}
+
+	if c.security != nil {
+		if err := c.security.apply(header, body); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(header.ChildElements()) == integer.Zero {
+		env.RemoveChild(header)
+	}
+	return doc, encoder.parts, nil
+}
+
+// soapNamespace returns envelope namespace of the SOAP version
+func (c *Client) soapNamespace() string {
+	if c.soapVersion == SoapVersion12 {
+		return soapNamespace12
+	}
+	return soapNamespace11
+}
+
+// mediaType returns content type of SOAP envelope without parameter
+func (c *Client) mediaType() string {
+	if c.soapVersion == SoapVersion12 {
+		return contenttype.ApplicationSoapXml
+	}
+	return contenttype.TextXml
+}
+
+// contentType returns content type of SOAP envelope, SOAP 1.2 action is sent as its parameter
+func (c *Client) contentType(action string) string {
+	contentType := c.mediaType() + "; charset=utf-8"
+	if c.soapVersion == SoapVersion12 && action != str.Empty {
+		contentType += fmt.Sprintf(`; action="%s"`, action)
+	}
+	return contentType
+}
+
+// paramEncoder encodes call params the same way gosoap does, and also struct, number, bool and attachment
+type paramEncoder struct {
+	mtom  bool
+	parts []*mtomPart
+}
+
+func (e *paramEncoder) encode(parent *etree.Element, v interface{}) error {
+	switch p := v.(type) {
+	case nil:
+		return nil
+	case *Attachment:
+		if !e.mtom {
+			parent.CreateText(base64.StdEncoding.EncodeToString(p.Data))
+			return nil
+		}
+		part := &mtomPart{contentId: newContentId(), attachment: p}
+		include := parent.CreateElement(xopPrefix + sym.Colon + "Include")
+		include.CreateAttr(xmlnsPrefix+sym.Colon+xopPrefix, xopNamespace)
+		include.CreateAttr("href", cidScheme+part.contentId)
+		e.parts = append(e.parts, part)
+		return nil
+	case *etree.Element:
+		parent.AddChild(p.Copy())
+		return nil
+	case []byte:
+		parent.CreateText(base64.StdEncoding.EncodeToString(p))
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+		for _, key := range keys {
+			if err := e.encode(parent.CreateElement(key.String()), rv.MapIndex(key).Interface()); err != nil {
+				return err
+			}
+		}
+	case reflect.Array:
+		// Array of two with string label is element of gosoap.ArrayParams
+		if rv.Len() == 2 {
+			if label, ok := rv.Index(0).Interface().(string); ok {
+				return e.encode(parent.CreateElement(label), rv.Index(1).Interface())
+			}
+		}
+		fallthrough
+	case reflect.Slice:
+		for i := 0; i < rv.Len(); i++ {
+			if err := e.encode(parent, rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		parent.CreateText(rv.String())
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil
+		}
+		if rv.Elem().Kind() == reflect.Struct {
+			return appendXml(parent, v)
+		}
+		return e.encode(parent, rv.Elem().Interface())
+	case reflect.Struct:
+		return appendXml(parent, v)
+	default:
+		parent.CreateText(fmt.Sprint(v))
+	}
+	return nil
+}
+
+// appendXml appends XML of the value to the element, string and byte slice are appended as raw XML,
+// other value is encoded with xml.Marshal
+func appendXml(parent *etree.Element, v interface{}) error {
+	var b []byte
+	switch x := v.(type) {
+	case *etree.Element:
+		parent.AddChild(x.Copy())
+		return nil
+	case string:
+		b = []byte(x)
+	case []byte:
+		b = x
+	default:
+		var err error
+		if b, err = xml.Marshal(v); err != nil {
+			return err
+		}
+	}
+	if len(strings.TrimSpace(string(b))) == integer.Zero {
+		return nil
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(b); err != nil {
+		return err
+	}
+	for _, el := range doc.ChildElements() {
+		parent.AddChild(el)
+	}
+	return nil
+}
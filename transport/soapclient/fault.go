@@ -0,0 +1,51 @@
+package soapclient
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+)
+
+// Error returns fault code and string of the fault
+func (f *Fault) Error() string {
+	if f.Subcode != str.Empty {
+		return fmt.Sprintf("SOAP fault, code=%s, subcode=%s, string=%s", f.Code, f.Subcode, f.String)
+	}
+	return fmt.Sprintf("SOAP fault, code=%s, string=%s", f.Code, f.String)
+}
+
+// UnmarshalDetail decodes detail of the fault into v
+func (f *Fault) UnmarshalDetail(v interface{}) error {
+	return xml.Unmarshal(f.Detail, v)
+}
+
+// newFault returns typed fault of SOAP 1.1 or SOAP 1.2 fault element
+func newFault(rf *responseFault, statusCode int) *Fault {
+	fault := &Fault{
+		Code:       rf.FaultCode,
+		String:     rf.FaultString,
+		Actor:      rf.FaultActor,
+		StatusCode: statusCode,
+	}
+	if rf.FaultDetail != nil {
+		fault.Detail = rf.FaultDetail.Contents
+	}
+
+	if rf.Code != nil {
+		fault.Code = rf.Code.Value
+		if rf.Code.Subcode != nil {
+			fault.Subcode = rf.Code.Subcode.Value
+		}
+	}
+	if rf.Reason != nil && len(rf.Reason.Text) > integer.Zero {
+		fault.String = rf.Reason.Text[0]
+	}
+	if rf.Role != str.Empty {
+		fault.Actor = rf.Role
+	}
+	if rf.Detail != nil {
+		fault.Detail = rf.Detail.Contents
+	}
+	return fault
+}
@@ -0,0 +1,190 @@
+package soapclient
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"github.com/beevik/etree"
+	"github.com/google/uuid"
+	"github.com/rosaekapratama/go-starter/constant/headers"
+	"github.com/rosaekapratama/go-starter/constant/headers/contenttype"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"strings"
+)
+
+const (
+	xopPrefix    = "xop"
+	xopNamespace = "http://www.w3.org/2004/08/xop/include"
+	cidScheme    = "cid:"
+
+	contentIdDomain               = "@soapclient"
+	contentTransferEncodingBinary = "binary"
+	contentTransferEncoding8Bit   = "8bit"
+	contentTransferEncodingBase64 = "base64"
+)
+
+var (
+	errMtomRootNotFound   = errors.New("root part of MTOM response not found")
+	errAttachmentNotFound = errors.New("attachment of MTOM response not found")
+)
+
+// MarshalXML encodes data of the attachment as base64 text
+func (a Attachment) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(base64.StdEncoding.EncodeToString(a.Data), start)
+}
+
+// UnmarshalXML decodes base64 text of the element into data of the attachment,
+// xop:Include of MTOM response is already replaced by base64 text before the body is returned
+func (a *Attachment) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var text string
+	if err := d.DecodeElement(&text, &start); err != nil {
+		return err
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), str.Empty))
+	if err != nil {
+		return err
+	}
+	a.Data = data
+	return nil
+}
+
+func newContentId() string {
+	return uuid.NewString() + contentIdDomain
+}
+
+// writeMtom writes envelope and attachments as multipart/related XOP package,
+// returns the package and its content type
+func (c *Client) writeMtom(envelope []byte, action string, parts []*mtomPart) ([]byte, string, error) {
+	buf := &bytes.Buffer{}
+	writer := multipart.NewWriter(buf)
+
+	rootId := newContentId()
+	rootHeader := textproto.MIMEHeader{}
+	rootHeader.Set(headers.ContentType, mime.FormatMediaType(contenttype.ApplicationXopXml, map[string]string{
+		"charset": "utf-8",
+		"type":    c.mediaType(),
+	}))
+	rootHeader.Set(headers.ContentTransferEncoding, contentTransferEncoding8Bit)
+	rootHeader.Set(headers.ContentID, "<"+rootId+">")
+	partWriter, err := writer.CreatePart(rootHeader)
+	if err != nil {
+		return nil, str.Empty, err
+	}
+	if _, err = partWriter.Write(envelope); err != nil {
+		return nil, str.Empty, err
+	}
+
+	for _, part := range parts {
+		contentType := part.attachment.ContentType
+		if contentType == str.Empty {
+			contentType = contenttype.ApplicationOctetStream
+		}
+		partHeader := textproto.MIMEHeader{}
+		partHeader.Set(headers.ContentType, contentType)
+		partHeader.Set(headers.ContentTransferEncoding, contentTransferEncodingBinary)
+		partHeader.Set(headers.ContentID, "<"+part.contentId+">")
+		if partWriter, err = writer.CreatePart(partHeader); err != nil {
+			return nil, str.Empty, err
+		}
+		if _, err = partWriter.Write(part.attachment.Data); err != nil {
+			return nil, str.Empty, err
+		}
+	}
+	if err = writer.Close(); err != nil {
+		return nil, str.Empty, err
+	}
+
+	params := map[string]string{
+		"type":       contenttype.ApplicationXopXml,
+		"boundary":   writer.Boundary(),
+		"start":      "<" + rootId + ">",
+		"start-info": c.mediaType(),
+	}
+	if c.soapVersion == SoapVersion12 && action != str.Empty {
+		params["action"] = action
+	}
+	return buf.Bytes(), mime.FormatMediaType(contenttype.MultipartRelated, params), nil
+}
+
+// readMtom returns root envelope of multipart/related response with every xop:Include
+// replaced by base64 text of the referenced attachment, other response is returned as is
+func readMtom(body []byte, contentType string) ([]byte, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != contenttype.MultipartRelated {
+		return body, nil
+	}
+
+	start := strings.Trim(params["start"], "<>")
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	var root []byte
+	attachments := make(map[string][]byte)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(part.Header.Get(headers.ContentTransferEncoding), contentTransferEncodingBase64) {
+			if data, err = base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(data)), str.Empty)); err != nil {
+				return nil, err
+			}
+		}
+
+		contentId := strings.Trim(part.Header.Get(headers.ContentID), "<>")
+		if root == nil && (start == str.Empty || contentId == start) {
+			root = data
+			continue
+		}
+		attachments[contentId] = data
+	}
+	if root == nil {
+		return nil, errMtomRootNotFound
+	}
+
+	doc := etree.NewDocument()
+	if err = doc.ReadFromBytes(root); err != nil {
+		return nil, err
+	}
+	for _, include := range findIncludes(doc.Root(), nil) {
+		contentId, err := url.PathUnescape(strings.TrimPrefix(include.SelectAttrValue("href", str.Empty), cidScheme))
+		if err != nil {
+			return nil, err
+		}
+		data, ok := attachments[contentId]
+		if !ok {
+			return nil, errAttachmentNotFound
+		}
+		parent := include.Parent()
+		index := include.Index()
+		parent.RemoveChildAt(index)
+		parent.InsertChildAt(index, etree.NewText(base64.StdEncoding.EncodeToString(data)))
+	}
+	return doc.WriteToBytes()
+}
+
+func findIncludes(el *etree.Element, includes []*etree.Element) []*etree.Element {
+	if el == nil {
+		return includes
+	}
+	for _, child := range el.ChildElements() {
+		if child.Tag == "Include" && child.NamespaceURI() == xopNamespace {
+			includes = append(includes, child)
+			continue
+		}
+		includes = findIncludes(child, includes)
+	}
+	return includes
+}
@@ -3,6 +3,7 @@ package soapclient
 import (
 	"context"
 	"crypto/tls"
+	"github.com/rosaekapratama/go-starter/transport/recorder"
 	"time"
 )
 
@@ -23,6 +24,31 @@ type timeoutClientOption struct {
 	timeout time.Duration
 }
 
+type recorderOption struct {
+	recorder *recorder.Recorder
+}
+
+type soapVersionOption struct {
+	version string
+}
+
+type soapHeadersOption struct {
+	headers []interface{}
+}
+
+type usernameTokenOption struct {
+	username       string
+	password       string
+	passwordDigest bool
+}
+
+type x509SignatureOption struct {
+	cert tls.Certificate
+}
+
+type mtomOption struct {
+}
+
 func (o *loggingOption) Apply(_ context.Context, client *Client) error {
 	client.transport.logging = &clientLogging{
 		Stdout:   o.stdout,
@@ -46,6 +72,48 @@ func (o *timeoutClientOption) Apply(_ context.Context, client *Client) error {
 	return nil
 }
 
+func (o *recorderOption) Apply(_ context.Context, client *Client) error {
+	client.transport.recorder = o.recorder
+	return nil
+}
+
+func (o *soapVersionOption) Apply(_ context.Context, client *Client) error {
+	if o.version != SoapVersion11 && o.version != SoapVersion12 {
+		return errInvalidSoapVersion
+	}
+	client.soapVersion = o.version
+	return nil
+}
+
+func (o *soapHeadersOption) Apply(_ context.Context, client *Client) error {
+	client.soapHeaders = append(client.soapHeaders, o.headers...)
+	return nil
+}
+
+func (o *usernameTokenOption) Apply(_ context.Context, client *Client) error {
+	security := client.securityHeader()
+	security.username = o.username
+	security.password = o.password
+	security.passwordDigest = o.passwordDigest
+	return nil
+}
+
+func (o *x509SignatureOption) Apply(_ context.Context, client *Client) error {
+	cert, signer, err := newSigner(o.cert)
+	if err != nil {
+		return err
+	}
+	security := client.securityHeader()
+	security.cert = cert
+	security.signer = signer
+	return nil
+}
+
+func (o *mtomOption) Apply(_ context.Context, client *Client) error {
+	client.mtom = true
+	return nil
+}
+
 func WithLogging(stdout bool, database string) ClientOption {
 	return &loggingOption{stdout: stdout, database: database}
 }
@@ -57,3 +125,37 @@ func WithInsecureSkipVerify(insecureSkipVerify bool) ClientOption {
 func WithTimeout(timeout time.Duration) ClientOption {
 	return &timeoutClientOption{timeout: timeout}
 }
+
+// WithRecorder replay response from cassette of the recorder, or record it from the real server, for tests only
+func WithRecorder(recorder *recorder.Recorder) ClientOption {
+	return &recorderOption{recorder: recorder}
+}
+
+// WithSoapVersion sets SOAP version of the envelope, SoapVersion11 or SoapVersion12, default is SoapVersion11,
+// request is sent to the WSDL port bound to the version
+func WithSoapVersion(version string) ClientOption {
+	return &soapVersionOption{version: version}
+}
+
+// WithSoapHeaders adds custom SOAP headers to every request, string is added as raw XML,
+// *etree.Element is added as is, and other value is encoded with xml.Marshal
+func WithSoapHeaders(headers ...interface{}) ClientOption {
+	return &soapHeadersOption{headers: headers}
+}
+
+// WithUsernameToken adds WS-Security UsernameToken to every request,
+// password is sent as digest with nonce and created time if passwordDigest is true
+func WithUsernameToken(username string, password string, passwordDigest bool) ClientOption {
+	return &usernameTokenOption{username: username, password: password, passwordDigest: passwordDigest}
+}
+
+// WithX509Signature signs timestamp and body of every request with RSA key of the certificate,
+// the certificate is sent as WS-Security BinarySecurityToken
+func WithX509Signature(cert tls.Certificate) ClientOption {
+	return &x509SignatureOption{cert: cert}
+}
+
+// WithMTOM sends *Attachment of call params as MTOM part instead of base64 text
+func WithMTOM() ClientOption {
+	return &mtomOption{}
+}
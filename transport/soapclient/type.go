@@ -2,8 +2,13 @@ package soapclient
 
 import (
 	"context"
-	"github.com/tiaguinho/gosoap"
+	"crypto"
+	"crypto/x509"
+	"encoding/xml"
+	"github.com/rosaekapratama/go-starter/transport/recorder"
 	"net/http"
+	"sync"
+	"time"
 )
 
 type IManager interface {
@@ -16,9 +21,15 @@ type managerImpl struct {
 }
 
 type Client struct {
-	httpClient    *http.Client
-	transport     *loggingTransport
-	soapClientMap map[string]*gosoap.Client
+	httpClient     *http.Client
+	transport      *loggingTransport
+	definitionsMap map[string]*wsdlDefinitions
+	mu             sync.Mutex
+
+	soapVersion string
+	soapHeaders []interface{}
+	security    *wsSecurity
+	mtom        bool
 }
 
 type clientLogging struct {
@@ -29,4 +40,87 @@ type clientLogging struct {
 type loggingTransport struct {
 	transport *http.Transport
 	logging   *clientLogging
+	recorder  *recorder.Recorder
+}
+
+// operation is WSDL operation of the called method
+type operation struct {
+	method    string
+	namespace string
+	action    string
+	endpoint  string
+}
+
+// Attachment is binary content of SOAP message, it is sent as MTOM part if MTOM is enabled, otherwise as base64 text.
+// Put it in call params as is to send it as MTOM part, attachment inside struct param is always sent as base64 text
+type Attachment struct {
+	ContentType string
+	Data        []byte
+}
+
+// mtomPart is attachment referenced by xop:Include of the envelope
+type mtomPart struct {
+	contentId  string
+	attachment *Attachment
+}
+
+// Fault is SOAP 1.1 or SOAP 1.2 fault of the response
+type Fault struct {
+	// Code is faultcode of SOAP 1.1 or Code/Value of SOAP 1.2, ex: soap:Server
+	Code string
+	// Subcode is Code/Subcode/Value of SOAP 1.2
+	Subcode string
+	// String is faultstring of SOAP 1.1 or first Reason/Text of SOAP 1.2
+	String string
+	// Actor is faultactor of SOAP 1.1 or Role of SOAP 1.2
+	Actor string
+	// Detail is inner XML of detail element, decode it with UnmarshalDetail
+	Detail     []byte
+	StatusCode int
+}
+
+// wsSecurity is WS-Security header of every request
+type wsSecurity struct {
+	username       string
+	password       string
+	passwordDigest bool
+	cert           *x509.Certificate
+	signer         crypto.Signer
+	timestampTtl   time.Duration
+	now            func() time.Time
+}
+
+type responseEnvelope struct {
+	XMLName xml.Name             `xml:"Envelope"`
+	Header  *responseInnerXml    `xml:"Header"`
+	Body    responseEnvelopeBody `xml:"Body"`
+}
+
+type responseEnvelopeBody struct {
+	Contents []byte         `xml:",innerxml"`
+	Fault    *responseFault `xml:"Fault"`
+}
+
+type responseInnerXml struct {
+	Contents []byte `xml:",innerxml"`
+}
+
+// responseFault has fields of both SOAP 1.1 and SOAP 1.2 fault
+type responseFault struct {
+	FaultCode   string            `xml:"faultcode"`
+	FaultString string            `xml:"faultstring"`
+	FaultActor  string            `xml:"faultactor"`
+	FaultDetail *responseInnerXml `xml:"detail"`
+
+	Code *struct {
+		Value   string `xml:"Value"`
+		Subcode *struct {
+			Value string `xml:"Value"`
+		} `xml:"Subcode"`
+	} `xml:"Code"`
+	Reason *struct {
+		Text []string `xml:"Text"`
+	} `xml:"Reason"`
+	Role   string            `xml:"Role"`
+	Detail *responseInnerXml `xml:"Detail"`
 }
@@ -0,0 +1,199 @@
+package soapclient
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/constant/sym"
+	"golang.org/x/net/html/charset"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	uriSchemeFile = "file"
+)
+
+// wsdlDefinitions is the part of WSDL 1.1 used to call an operation, with both SOAP 1.1 and SOAP 1.2 bindings
+type wsdlDefinitions struct {
+	TargetNamespace string         `xml:"targetNamespace,attr"`
+	Types           []*wsdlTypes   `xml:"http://schemas.xmlsoap.org/wsdl/ types"`
+	Bindings        []*wsdlBinding `xml:"http://schemas.xmlsoap.org/wsdl/ binding"`
+	Services        []*wsdlService `xml:"http://schemas.xmlsoap.org/wsdl/ service"`
+}
+
+type wsdlTypes struct {
+	Schemas []*struct {
+		TargetNamespace string `xml:"targetNamespace,attr"`
+	} `xml:"http://www.w3.org/2001/XMLSchema schema"`
+}
+
+type wsdlBinding struct {
+	Name       string           `xml:"name,attr"`
+	Soap11     []*struct{}      `xml:"http://schemas.xmlsoap.org/wsdl/soap/ binding"`
+	Soap12     []*struct{}      `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ binding"`
+	Operations []*wsdlOperation `xml:"http://schemas.xmlsoap.org/wsdl/ operation"`
+}
+
+type wsdlOperation struct {
+	Name   string            `xml:"name,attr"`
+	Soap11 []*wsdlSoapAction `xml:"http://schemas.xmlsoap.org/wsdl/soap/ operation"`
+	Soap12 []*wsdlSoapAction `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ operation"`
+}
+
+type wsdlSoapAction struct {
+	SoapAction string `xml:"soapAction,attr"`
+}
+
+type wsdlService struct {
+	Ports []*wsdlPort `xml:"http://schemas.xmlsoap.org/wsdl/ port"`
+}
+
+type wsdlPort struct {
+	Binding string         `xml:"binding,attr"`
+	Soap11  []*wsdlAddress `xml:"http://schemas.xmlsoap.org/wsdl/soap/ address"`
+	Soap12  []*wsdlAddress `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ address"`
+}
+
+type wsdlAddress struct {
+	Location string `xml:"location,attr"`
+}
+
+// fetchWsdl reads WSDL definitions of the address, file scheme is read from local file
+func fetchWsdl(ctx context.Context, httpClient *http.Client, wsdlAddress string) (*wsdlDefinitions, error) {
+	u, err := url.Parse(wsdlAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.ReadCloser
+	if u.Scheme == uriSchemeFile {
+		if reader, err = os.Open(u.Path); err != nil {
+			return nil, err
+		}
+	} else {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, wsdlAddress, nil)
+		if err != nil {
+			return nil, err
+		}
+		res, err := httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode >= http.StatusBadRequest {
+			_ = res.Body.Close()
+			return nil, fmt.Errorf("%w: %s", errUnexpectedStatusCode, res.Status)
+		}
+		reader = res.Body
+	}
+	defer reader.Close()
+
+	definitions := &wsdlDefinitions{}
+	decoder := xml.NewDecoder(reader)
+	decoder.CharsetReader = charset.NewReaderLabel
+	if err = decoder.Decode(definitions); err != nil {
+		return nil, err
+	}
+	return definitions, nil
+}
+
+// namespace returns target namespace of the first schema, or of the definitions if there is no schema
+func (d *wsdlDefinitions) namespace() string {
+	if len(d.Types) > integer.Zero && len(d.Types[0].Schemas) > integer.Zero {
+		return d.Types[0].Schemas[0].TargetNamespace
+	}
+	return d.TargetNamespace
+}
+
+// binding returns binding of the qualified name, ex: tns:HelloBinding
+func (d *wsdlDefinitions) binding(name string) *wsdlBinding {
+	name = name[strings.LastIndex(name, sym.Colon)+1:]
+	for _, binding := range d.Bindings {
+		if binding.Name == name {
+			return binding
+		}
+	}
+	return nil
+}
+
+// endpoint returns address and binding of the first port bound to the SOAP version,
+// or of the first port with address if no port is bound to the version
+func (d *wsdlDefinitions) endpoint(soapVersion string) (string, *wsdlBinding) {
+	var fallback string
+	var fallbackBinding *wsdlBinding
+	for _, service := range d.Services {
+		for _, port := range service.Ports {
+			binding := d.binding(port.Binding)
+			if address := port.address(soapVersion); address != str.Empty && binding.is(soapVersion) {
+				return address, binding
+			}
+			if fallback == str.Empty {
+				if fallback = port.address(SoapVersion11); fallback == str.Empty {
+					fallback = port.address(SoapVersion12)
+				}
+				fallbackBinding = binding
+			}
+		}
+	}
+	return fallback, fallbackBinding
+}
+
+// soapAction returns SOAP action of the operation in the binding, or in any binding if it is not found
+func (d *wsdlDefinitions) soapAction(binding *wsdlBinding, method string) string {
+	if action := binding.soapAction(method); action != str.Empty {
+		return action
+	}
+	for _, b := range d.Bindings {
+		if action := b.soapAction(method); action != str.Empty {
+			return action
+		}
+	}
+	return str.Empty
+}
+
+// is returns true if the binding is bound to the SOAP version
+func (b *wsdlBinding) is(soapVersion string) bool {
+	if b == nil {
+		return false
+	}
+	if soapVersion == SoapVersion12 {
+		return len(b.Soap12) > integer.Zero
+	}
+	return len(b.Soap11) > integer.Zero
+}
+
+func (b *wsdlBinding) soapAction(method string) string {
+	if b == nil {
+		return str.Empty
+	}
+	for _, op := range b.Operations {
+		if op.Name != method {
+			continue
+		}
+		for _, actions := range [][]*wsdlSoapAction{op.Soap11, op.Soap12} {
+			for _, action := range actions {
+				if action.SoapAction != str.Empty {
+					return action.SoapAction
+				}
+			}
+		}
+	}
+	return str.Empty
+}
+
+// address returns location of the port address of the SOAP version
+func (p *wsdlPort) address(soapVersion string) string {
+	addresses := p.Soap11
+	if soapVersion == SoapVersion12 {
+		addresses = p.Soap12
+	}
+	if len(addresses) == integer.Zero {
+		return str.Empty
+	}
+	return addresses[0].Location
+}
@@ -0,0 +1,202 @@
+package soapclient
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"github.com/beevik/etree"
+	"github.com/rosaekapratama/go-starter/constant/integer"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/constant/sym"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+	"time"
+)
+
+const (
+	wssePrefix    = "wsse"
+	wsuPrefix     = "wsu"
+	dsPrefix      = "ds"
+	wsseNamespace = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd"
+	wsuNamespace  = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd"
+	dsNamespace   = "http://www.w3.org/2000/09/xmldsig#"
+
+	wssPasswordText   = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordText"
+	wssPasswordDigest = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordDigest"
+	wssBase64Binary   = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-soap-message-security-1.0#Base64Binary"
+	wssX509v3         = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-x509-token-profile-1.0#X509v3"
+	digestSha256      = "http://www.w3.org/2001/04/xmlenc#sha256"
+
+	wsuTimeFormat       = "2006-01-02T15:04:05.000Z"
+	wsuIdTimestamp      = "TS-1"
+	wsuIdBody           = "Body-1"
+	wsuIdToken          = "X509-1"
+	defaultTimestampTtl = 5 * time.Minute
+	nonceSize           = 16
+)
+
+var (
+	errCertificateNotFound = errors.New("certificate of X.509 signature not found")
+	errUnsupportedKey      = errors.New("private key of X.509 signature must be RSA")
+)
+
+// newSigner returns leaf certificate and RSA signer of the TLS certificate
+func newSigner(cert tls.Certificate) (*x509.Certificate, crypto.Signer, error) {
+	if len(cert.Certificate) == integer.Zero {
+		return nil, nil, errCertificateNotFound
+	}
+	leaf := cert.Leaf
+	if leaf == nil {
+		var err error
+		if leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return nil, nil, err
+		}
+	}
+	signer, ok := cert.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, nil, errUnsupportedKey
+	}
+	if _, ok = signer.Public().(*rsa.PublicKey); !ok {
+		return nil, nil, errUnsupportedKey
+	}
+	return leaf, signer, nil
+}
+
+// apply adds wsse:Security header with timestamp, username token and X.509 signature of the body
+func (s *wsSecurity) apply(header *etree.Element, body *etree.Element) error {
+	now := s.now().UTC()
+	security := etree.NewElement(wssePrefix + sym.Colon + "Security")
+	header.InsertChildAt(integer.Zero, security)
+	security.CreateAttr(xmlnsPrefix+sym.Colon+wssePrefix, wsseNamespace)
+	security.CreateAttr(xmlnsPrefix+sym.Colon+wsuPrefix, wsuNamespace)
+	security.CreateAttr(soapPrefix+sym.Colon+"mustUnderstand", "1")
+
+	timestamp := security.CreateElement(wsuPrefix + sym.Colon + "Timestamp")
+	timestamp.CreateAttr(wsuPrefix+sym.Colon+"Id", wsuIdTimestamp)
+	timestamp.CreateElement(wsuPrefix + sym.Colon + "Created").SetText(now.Format(wsuTimeFormat))
+	timestamp.CreateElement(wsuPrefix + sym.Colon + "Expires").SetText(now.Add(s.timestampTtl).Format(wsuTimeFormat))
+
+	if s.username != str.Empty {
+		if err := s.usernameToken(security, now); err != nil {
+			return err
+		}
+	}
+	if s.signer != nil {
+		return s.sign(security, timestamp, body)
+	}
+	return nil
+}
+
+// usernameToken adds wsse:UsernameToken, digest password is Base64(SHA-1(nonce + created + password))
+func (s *wsSecurity) usernameToken(security *etree.Element, now time.Time) error {
+	token := security.CreateElement(wssePrefix + sym.Colon + "UsernameToken")
+	token.CreateElement(wssePrefix + sym.Colon + "Username").SetText(s.username)
+	password := token.CreateElement(wssePrefix + sym.Colon + "Password")
+	if !s.passwordDigest {
+		password.CreateAttr("Type", wssPasswordText)
+		password.SetText(s.password)
+		return nil
+	}
+
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	created := now.Format(wsuTimeFormat)
+	digest := sha1.New()
+	digest.Write(nonce)
+	digest.Write([]byte(created))
+	digest.Write([]byte(s.password))
+
+	password.CreateAttr("Type", wssPasswordDigest)
+	password.SetText(base64.StdEncoding.EncodeToString(digest.Sum(nil)))
+	nonceElement := token.CreateElement(wssePrefix + sym.Colon + "Nonce")
+	nonceElement.CreateAttr("EncodingType", wssBase64Binary)
+	nonceElement.SetText(base64.StdEncoding.EncodeToString(nonce))
+	token.CreateElement(wsuPrefix + sym.Colon + "Created").SetText(created)
+	return nil
+}
+
+// sign adds X.509 binary security token and RSA-SHA256 signature of the timestamp and the body
+func (s *wsSecurity) sign(security *etree.Element, timestamp *etree.Element, body *etree.Element) error {
+	token := security.CreateElement(wssePrefix + sym.Colon + "BinarySecurityToken")
+	token.CreateAttr("EncodingType", wssBase64Binary)
+	token.CreateAttr("ValueType", wssX509v3)
+	token.CreateAttr(wsuPrefix+sym.Colon+"Id", wsuIdToken)
+	token.SetText(base64.StdEncoding.EncodeToString(s.cert.Raw))
+
+	body.CreateAttr(xmlnsPrefix+sym.Colon+wsuPrefix, wsuNamespace)
+	body.CreateAttr(wsuPrefix+sym.Colon+"Id", wsuIdBody)
+
+	signature := security.CreateElement(dsPrefix + sym.Colon + "Signature")
+	signature.CreateAttr(xmlnsPrefix+sym.Colon+dsPrefix, dsNamespace)
+	signedInfo := signature.CreateElement(dsPrefix + sym.Colon + "SignedInfo")
+	signedInfo.CreateElement(dsPrefix+sym.Colon+"CanonicalizationMethod").
+		CreateAttr("Algorithm", string(dsig.CanonicalXML10ExclusiveAlgorithmId))
+	signedInfo.CreateElement(dsPrefix+sym.Colon+"SignatureMethod").
+		CreateAttr("Algorithm", dsig.RSASHA256SignatureMethod)
+
+	references := []struct {
+		id string
+		el *etree.Element
+	}{{wsuIdTimestamp, timestamp}, {wsuIdBody, body}}
+	for _, ref := range references {
+		digest, err := digestOf(ref.el)
+		if err != nil {
+			return err
+		}
+		reference := signedInfo.CreateElement(dsPrefix + sym.Colon + "Reference")
+		reference.CreateAttr("URI", "#"+ref.id)
+		reference.CreateElement(dsPrefix+sym.Colon+"Transforms").
+			CreateElement(dsPrefix+sym.Colon+"Transform").
+			CreateAttr("Algorithm", string(dsig.CanonicalXML10ExclusiveAlgorithmId))
+		reference.CreateElement(dsPrefix+sym.Colon+"DigestMethod").CreateAttr("Algorithm", digestSha256)
+		reference.CreateElement(dsPrefix + sym.Colon + "DigestValue").SetText(base64.StdEncoding.EncodeToString(digest))
+	}
+
+	digest, err := digestOf(signedInfo)
+	if err != nil {
+		return err
+	}
+	signatureValue, err := s.signer.Sign(rand.Reader, digest, crypto.SHA256)
+	if err != nil {
+		return err
+	}
+	signature.CreateElement(dsPrefix + sym.Colon + "SignatureValue").SetText(base64.StdEncoding.EncodeToString(signatureValue))
+
+	reference := signature.CreateElement(dsPrefix + sym.Colon + "KeyInfo").
+		CreateElement(wssePrefix + sym.Colon + "SecurityTokenReference").
+		CreateElement(wssePrefix + sym.Colon + "Reference")
+	reference.CreateAttr("URI", "#"+wsuIdToken)
+	reference.CreateAttr("ValueType", wssX509v3)
+	return nil
+}
+
+// digestOf returns SHA-256 of exclusive canonical XML of the element
+func digestOf(el *etree.Element) ([]byte, error) {
+	canonical, err := canonicalize(el)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(canonical)
+	return digest[:], nil
+}
+
+// canonicalize returns exclusive canonical XML of the element, namespaces declared by its ancestors are kept
+func canonicalize(el *etree.Element) ([]byte, error) {
+	ctx, err := etreeutils.NSBuildParentContext(el)
+	if err != nil {
+		return nil, err
+	}
+	detached, err := etreeutils.NSDetatch(ctx, el)
+	if err != nil {
+		return nil, err
+	}
+	return dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList(str.Empty).Canonicalize(detached)
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/rosaekapratama/go-starter/constant/str"
+	"os"
+)
+
+// NewTLSConfig builds client *tls.Config from TLS configuration,
+// returns nil if the configuration is nil or not enabled
+func NewTLSConfig(cfg *config.TLSConfig) (*tls.Config, error) {
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CaFile != str.Empty {
+		caPool, err := NewCertPool(cfg.CaFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = caPool
+	}
+
+	if cfg.CertFile != str.Empty || cfg.KeyFile != str.Empty {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS key pair, certFile=%s, keyFile=%s: %w", cfg.CertFile, cfg.KeyFile, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// NewCertPool returns certificate pool of the given PEM encoded CA certificate file
+func NewCertPool(caFile string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file, caFile=%s: %w", caFile, err)
+	}
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no valid PEM certificate found in CA file, caFile=%s", caFile)
+	}
+	return caPool, nil
+}
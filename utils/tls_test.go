@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/rosaekapratama/go-starter/config"
+	"github.com/stretchr/testify/suite"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type TLSTestSuite struct {
+	suite.Suite
+	certFile    string
+	keyFile     string
+	invalidFile string
+}
+
+func (s *TLSTestSuite) SetupTest() {
+	dir := s.T().TempDir()
+	s.certFile = filepath.Join(dir, "ca.crt")
+	s.keyFile = filepath.Join(dir, "ca.key")
+	s.invalidFile = filepath.Join(dir, "invalid.crt")
+
+	// Self-signed certificate is used as both CA and client certificate
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.Require().NoError(err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	s.Require().NoError(err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	s.Require().NoError(err)
+	s.Require().NoError(os.WriteFile(s.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	s.Require().NoError(os.WriteFile(s.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	s.Require().NoError(os.WriteFile(s.invalidFile, []byte("not a certificate"), 0600))
+}
+
+func TestTLSTestSuite(t *testing.T) {
+	suite.Run(t, new(TLSTestSuite))
+}
+
+func (s *TLSTestSuite) TestNewTLSConfig() {
+	tests := []struct {
+		name   string
+		cfg    *config.TLSConfig
+		assert func(tlsConfig *tls.Config)
+		hasErr bool
+	}{
+		{
+			name: "nil config",
+			assert: func(tlsConfig *tls.Config) {
+				s.Nil(tlsConfig)
+			},
+		},
+		{
+			name: "disabled",
+			cfg:  &config.TLSConfig{CaFile: "not-found.crt"},
+			assert: func(tlsConfig *tls.Config) {
+				s.Nil(tlsConfig)
+			},
+		},
+		{
+			name: "system CA pool",
+			cfg:  &config.TLSConfig{Enabled: true},
+			assert: func(tlsConfig *tls.Config) {
+				s.Equal(uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+				s.Nil(tlsConfig.RootCAs)
+				s.Empty(tlsConfig.Certificates)
+				s.False(tlsConfig.InsecureSkipVerify)
+			},
+		},
+		{
+			name: "insecure",
+			cfg:  &config.TLSConfig{Enabled: true, InsecureSkipVerify: true, ServerName: "redis.local"},
+			assert: func(tlsConfig *tls.Config) {
+				s.True(tlsConfig.InsecureSkipVerify)
+				s.Equal("redis.local", tlsConfig.ServerName)
+			},
+		},
+		{
+			name: "CA and client certificate",
+			cfg:  &config.TLSConfig{Enabled: true, CaFile: s.certFile, CertFile: s.certFile, KeyFile: s.keyFile},
+			assert: func(tlsConfig *tls.Config) {
+				pool, err := NewCertPool(s.certFile)
+				s.Require().NoError(err)
+				s.True(pool.Equal(tlsConfig.RootCAs))
+				s.Len(tlsConfig.Certificates, 1)
+			},
+		},
+		{name: "CA not found", cfg: &config.TLSConfig{Enabled: true, CaFile: "not-found.crt"}, hasErr: true},
+		{name: "invalid CA", cfg: &config.TLSConfig{Enabled: true, CaFile: s.invalidFile}, hasErr: true},
+		{name: "key not found", cfg: &config.TLSConfig{Enabled: true, CertFile: s.certFile, KeyFile: "not-found.key"}, hasErr: true},
+		{name: "missing key", cfg: &config.TLSConfig{Enabled: true, CertFile: s.certFile}, hasErr: true},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			tlsConfig, err := NewTLSConfig(tt.cfg)
+			if tt.hasErr {
+				s.Error(err)
+				s.Nil(tlsConfig)
+				return
+			}
+			s.Require().NoError(err)
+			tt.assert(tlsConfig)
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/camunda/zeebe/clients/go/v8/pkg/zbc"
 	"github.com/rosaekapratama/go-starter/config"
 	"github.com/rosaekapratama/go-starter/constant/str"
+	"github.com/rosaekapratama/go-starter/healthcheck"
 	"github.com/rosaekapratama/go-starter/log"
 	"github.com/rosaekapratama/go-starter/response"
 	"os"
@@ -15,6 +16,7 @@ const (
 	zeebeClientIdEnvVarKey               = "ZEEBE_CLIENT_ID"
 	zeebeClientSecretEnvVarKey           = "ZEEBE_CLIENT_SECRET"
 	zeebeAuthorizationServerUrlEnvVarKey = "ZEEBE_AUTHORIZATION_SERVER_URL"
+	healthCheckName                      = "zeebe"
 )
 
 var (
@@ -79,4 +81,10 @@ func Init(ctx context.Context, config config.Config) {
 		return
 	}
 	log.Infof(ctx, "Zeebe client is initiated, gatewayVersion=%s, clusterSize=%d", res.GetGatewayVersion(), res.GetClusterSize())
+
+	// Add health checker
+	healthcheck.AddCheckerWithConfig(healthCheckName, cfg.HealthCheck, func(ctx context.Context) error {
+		_, err := Client.NewTopologyCommand().Send(ctx)
+		return err
+	})
 }